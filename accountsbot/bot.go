@@ -4,8 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
@@ -14,10 +14,22 @@ import (
 	"github.com/globalsign/mgo/bson"
 	"gopkg.in/cenkalti/backoff.v2"
 
+	"github.com/l3a0/carbon/backfill"
 	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/liquidation"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/metrics"
 	"github.com/l3a0/carbon/models"
+	"github.com/l3a0/carbon/reconciliation"
+	"github.com/l3a0/carbon/sharding"
 )
 
+// BotType is the type of the AccountsBot's state.
+const BotType = "AccountsBot"
+
+// appliedEventsBatchSize is the number of events looked up per applied events query.
+const appliedEventsBatchSize = 100
+
 // Bot represents some logic that runs in background.
 type Bot interface {
 	Wake(ctx context.Context, statusChannel chan int)
@@ -25,25 +37,52 @@ type Bot interface {
 	Sleep(ctx context.Context, statusChannel chan int)
 }
 
+// ShardWorker checks the accounts of its shard while another instance leads the bot.
+type ShardWorker interface {
+	CheckShard(ctx context.Context, statusChannel chan int)
+}
+
 // AccountsBot maintains state for accounts with debt.
 type AccountsBot struct {
-	accounts           map[string]*models.Account
-	tokens             map[string]contracts.Token
-	tokenAddresses     map[string]common.Address
-	botsService        models.BotsService
-	accountsService    models.AccountsService
-	comptrollerService models.ComptrollerService
-	state              *BotState
-	logger             *log.Logger
+	accounts             map[string]*models.Account
+	tokens               map[string]contracts.Token
+	tokenAddresses       map[string]common.Address
+	botsService          models.BotsService
+	accountsService      models.AccountsService
+	comptrollerService   models.ComptrollerService
+	accountEventsService models.AccountEventsService
+	accountEvents        []*models.AccountEvent
+	interestAccrual      models.InterestAccrual
+	marketsService       models.MarketsService
+	reconciler           reconciliation.Reconciler
+	eventTotalBorrows    map[string]*reconciliation.EventTotalBorrows
+	headerReader         models.HeaderReader
+	chunker              backfill.Chunker
+	logFetcher           contracts.LogFetcher
+	appliedEventsService models.AppliedEventsService
+	appliedEvents        []*models.AppliedEvent
+	coordinator          sharding.Coordinator
+	metrics              *metrics.Metrics
+	state                *BotState
+	baseLogger           logging.Logger
+	// logger adds the fields of the context of the running step, e.g. the cycle ID.
+	logger logging.Logger
 }
 
 // BotState represents the state of the bot.
 type BotState struct {
-	ShardKey               string
-	BotType                string
-	LastWakeTime           time.Time
-	LastSleepTime          time.Time
-	LastBorrowBlockByToken map[string]uint64
+	ShardKey            string
+	BotType             string
+	InstanceID          string
+	Version             int64
+	LastWakeTime        time.Time
+	LastSleepTime       time.Time
+	ScannedBlockByToken map[string]uint64
+	BorrowCursorByToken map[string]*backfill.Cursor
+	LastMembershipBlock uint64
+	MembershipCursor    *backfill.Cursor
+	// LastBorrowBlockByToken is the legacy checkpoint, migrated to ScannedBlockByToken.
+	LastBorrowBlockByToken map[string]uint64 `json:",omitempty"`
 }
 
 // GetShardKey returns the shard key.
@@ -51,48 +90,115 @@ func (state *BotState) GetShardKey() string {
 	return state.ShardKey
 }
 
+// GetBotType returns the bot type.
+func (state *BotState) GetBotType() string {
+	return state.BotType
+}
+
+// GetInstanceID returns the bot instance ID.
+func (state *BotState) GetInstanceID() string {
+	return state.InstanceID
+}
+
+// GetVersion returns the version of the stored state.
+func (state *BotState) GetVersion() int64 {
+	return state.Version
+}
+
+// SetVersion sets the version of the stored state.
+func (state *BotState) SetVersion(version int64) {
+	state.Version = version
+}
+
 // NewAccountsBot creates a new AccountsBot.
 func NewAccountsBot(
 	tokensProvider contracts.TokensProvider,
-	logger *log.Logger,
+	logger logging.Logger,
 	accountsService models.AccountsService,
 	botsService models.BotsService,
-	comptrollerService models.ComptrollerService) Bot {
+	comptrollerService models.ComptrollerService,
+	accountEventsService models.AccountEventsService,
+	interestAccrual models.InterestAccrual,
+	marketsService models.MarketsService,
+	reconciler reconciliation.Reconciler,
+	headerReader models.HeaderReader,
+	chunker backfill.Chunker,
+	logFetcher contracts.LogFetcher,
+	appliedEventsService models.AppliedEventsService,
+	coordinator sharding.Coordinator,
+	metrics *metrics.Metrics) Bot {
 	return &AccountsBot{
-		botsService:        botsService,
-		accountsService:    accountsService,
-		comptrollerService: comptrollerService,
-		tokens:             tokensProvider.GetTokens(),
-		tokenAddresses:     tokensProvider.GetAddresses(),
-		logger:             logger,
+		botsService:          botsService,
+		accountsService:      accountsService,
+		comptrollerService:   comptrollerService,
+		accountEventsService: accountEventsService,
+		interestAccrual:      interestAccrual,
+		marketsService:       marketsService,
+		reconciler:           reconciler,
+		eventTotalBorrows:    make(map[string]*reconciliation.EventTotalBorrows),
+		headerReader:         headerReader,
+		chunker:              chunker,
+		logFetcher:           logFetcher,
+		appliedEventsService: appliedEventsService,
+		coordinator:          coordinator,
+		metrics:              metrics,
+		tokens:               tokensProvider.GetTokens(),
+		tokenAddresses:       tokensProvider.GetAddresses(),
+		baseLogger:           logger,
+		logger:               logger,
 	}
 }
 
 // Wake gets the bot ready for work.
 func (bot *AccountsBot) Wake(ctx context.Context, statusChannel chan int) {
-	bot.logger.Printf("%v waking...\n", bot)
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Waking", nil)
 	bot.initializeState(ctx)
-	bot.initializeAccounts(ctx)
+	err := bot.initializeAccounts(ctx)
+	if err != nil {
+		bot.logger.Error("Failed to initialize accounts", logging.Fields{logging.ErrorField: err})
+		statusChannel <- 1
+		return
+	}
 	statusChannel <- 0
 }
 
 // Work puts the bot to work.
 func (bot *AccountsBot) Work(ctx context.Context, status chan int) {
-	bot.logger.Printf("%v working...\n", bot)
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Working", nil)
 	// TODO: go routine per token contract?
 	modifiedAccounts := map[string]*models.Account{}
-	for tokenSymbol, token := range bot.tokens {
-		tokenName, err := token.Name(nil)
-		if err != nil {
-			bot.logger.Fatalf("Failed to retrieve %v token name: %v", tokenSymbol, err)
-		}
-		iter := bot.filterBorrowEvents(tokenSymbol, tokenName, token)
-		if iter != nil {
-			if bot.state.LastBorrowBlockByToken == nil {
-				bot.state.LastBorrowBlockByToken = make(map[string]uint64)
-			}
-			bot.state.LastBorrowBlockByToken[tokenSymbol] = bot.parseAccountBorrowBalancesFromBorrowEvents(iter, tokenSymbol, modifiedAccounts)
-		}
+	bot.accountEvents = []*models.AccountEvent{}
+	bot.appliedEvents = []*models.AppliedEvent{}
+	err := bot.interestAccrual.Refresh(ctx)
+	if err != nil {
+		bot.logger.Warn("Failed to refresh borrow indexes", logging.Fields{logging.ErrorField: err})
+	}
+	head, err := bot.getHeadBlock(ctx)
+	if err != nil {
+		bot.logger.Error("Failed to get head block", logging.Fields{logging.ErrorField: err})
+		status <- 1
+		return
+	}
+	if bot.state.ScannedBlockByToken == nil {
+		bot.state.ScannedBlockByToken = make(map[string]uint64)
+	}
+	if bot.state.BorrowCursorByToken == nil {
+		bot.state.BorrowCursorByToken = make(map[string]*backfill.Cursor)
+	}
+	bot.migrateBorrowCheckpoints()
+	err = bot.backfillTokenEvents(ctx, head, modifiedAccounts)
+	if err != nil {
+		bot.logger.Error("Failed to fetch token events", logging.Fields{logging.ErrorField: err})
+		status <- 1
+		return
+	}
+	err = bot.backfillMarketMembership(ctx, head, modifiedAccounts)
+	if err != nil {
+		bot.logger.Error("Failed to filter market membership events", logging.Fields{logging.ErrorField: err})
+		status <- 1
+		return
 	}
 	numberOfModifiedAccounts := len(modifiedAccounts)
 	numberOfAccounts := len(bot.accounts)
@@ -100,29 +206,57 @@ func (bot *AccountsBot) Work(ctx context.Context, status chan int) {
 	if numberOfModifiedAccounts > numberOfAccounts {
 		bot.logger.Panicf("numberOfModifiedAccounts > numberOfAccounts.\n")
 	}
-	bot.logger.Printf("numberOfModifiedAccounts: %v\n", numberOfModifiedAccounts)
-	bot.logger.Printf("numberOfAccounts: %v\n", numberOfAccounts)
-	if numberOfModifiedAccounts > 0 {
-		// TODO: go routine per account w/ bounded parallelism?
-		for _, account := range modifiedAccounts {
-			// TODO: move backoff logic into accounts service.
-			operation := func() error {
-				bot.logger.Printf("Upserting account: %v\n", account)
-				err := bot.accountsService.UpsertAccount(ctx, account)
-				if err != nil {
-					bot.logger.Printf("Problem upserting data: %v", err)
-					return err
-				}
-				bot.logger.Printf("Upserted account: %v\n", account)
-				return nil
-			}
-			err := backoff.Retry(operation, backoff.NewExponentialBackOff())
-			if err != nil {
-				bot.logger.Panicf("Problem upserting data: %v", err)
-			}
+	bot.logger.Info("Applied events", logging.Fields{"modifiedAccounts": numberOfModifiedAccounts, "accounts": numberOfAccounts})
+	bot.metrics.AccountsTracked.Set(float64(numberOfAccounts))
+	for tokenSymbol := range bot.tokens {
+		var lag uint64
+		if scanned := bot.state.ScannedBlockByToken[tokenSymbol]; scanned < head {
+			lag = head - scanned
 		}
+		bot.metrics.HeadBlockLag.Set(float64(lag), tokenSymbol)
 	}
+	bot.snapshotMarkets(ctx)
+	bot.reconcileBorrows(ctx)
+	err = bot.coordinator.Rebalance(ctx)
+	if err != nil {
+		bot.logger.Warn("Failed to rebalance shards", logging.Fields{logging.ErrorField: err})
+	}
+	bot.checkAccounts(ctx)
+	status <- 0
+}
+
+// CheckShard checks the liquidity of the stored accounts of the bot's shard without changing any state.
+func (bot *AccountsBot) CheckShard(ctx context.Context, statusChannel chan int) {
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Checking shard", nil)
+	err := bot.initializeAccounts(ctx)
+	if err != nil {
+		bot.logger.Error("Failed to initialize accounts", logging.Fields{logging.ErrorField: err})
+		statusChannel <- 1
+		return
+	}
+	bot.checkAccounts(ctx)
+	statusChannel <- 0
+}
+
+// checkAccounts gets the liquidity of the accounts owned by the bot's shard.
+func (bot *AccountsBot) checkAccounts(ctx context.Context) {
+	shard, err := bot.coordinator.GetShard(ctx)
+	if err != nil {
+		bot.logger.Error("Failed to get shard", logging.Fields{logging.ErrorField: err})
+		return
+	}
+	err = bot.comptrollerService.RefreshPausedActions(ctx)
+	if err != nil {
+		bot.logger.Warn("Failed to refresh paused actions", logging.Fields{logging.ErrorField: err})
+	}
+	numberOfCheckedAccounts := 0
+	numberOfAccountsInShortfall := 0
+	totalShortfall := big.NewInt(0)
 	for _, account := range bot.accounts {
+		if !shard.Owns(account.ShardKey) {
+			continue
+		}
 		totalBorrows := big.NewInt(0)
 		for _, tokenBorrows := range account.Borrows {
 			totalBorrows = totalBorrows.Add(totalBorrows, tokenBorrows)
@@ -132,8 +266,15 @@ func (bot *AccountsBot) Work(ctx context.Context, status chan int) {
 			bot.logger.Panicf("Account %v has totalBorrows = %v.\n", account, totalBorrows)
 		}
 		bot.liquidateAccount(account)
+		numberOfCheckedAccounts++
+		if account.Shortfall.Cmp(common.Big0) > 0 {
+			numberOfAccountsInShortfall++
+			totalShortfall.Add(totalShortfall, account.Shortfall)
+		}
 	}
-	status <- 0
+	bot.metrics.AccountsInShortfall.Set(float64(numberOfAccountsInShortfall))
+	bot.metrics.TotalShortfall.Set(metrics.ToFloat(totalShortfall))
+	bot.logger.Info("Checked accounts", logging.Fields{"checked": numberOfCheckedAccounts, "accounts": len(bot.accounts), "shardVersion": shard.Version})
 }
 
 func (bot *AccountsBot) liquidateAccount(account *models.Account) {
@@ -141,17 +282,43 @@ func (bot *AccountsBot) liquidateAccount(account *models.Account) {
 	if account.Liquidity.Cmp(common.Big0) == 0 && account.Shortfall.Cmp(common.Big0) == 0 {
 
 	}
+	if account.Shortfall.Cmp(common.Big0) > 0 {
+		// the borrowed markets are repaid and the entered collateral markets are seized.
+		seizableMarkets, err := liquidation.GetSeizableMarkets(nil, account, bot.tokenAddresses, bot.comptrollerService)
+		if err != nil {
+			bot.logger.Warn("Problem getting seizable markets", logging.Fields{logging.AddressField: account.Address, logging.ErrorField: err})
+			return
+		}
+		if len(seizableMarkets) == 0 {
+			bot.logger.Info("Skipping liquidation candidate without seizable collateral", logging.Fields{
+				logging.AddressField: account.Address,
+				"markets":            account.Markets,
+				"pausedActions":      bot.comptrollerService.GetPausedActions(""),
+			})
+			return
+		}
+		bot.logger.Info("Liquidation candidate", logging.Fields{
+			logging.AddressField: account.Address,
+			"shortfall":          account.Shortfall,
+			"projectedBorrows":   bot.interestAccrual.ProjectAccountBorrows(account),
+			"seizableMarkets":    seizableMarkets,
+		})
+	}
 }
 
 func (bot *AccountsBot) getAccountLiquidity(account *models.Account) {
-	bot.logger.Printf("Getting liquidity for account: %v", account)
+	bot.logger.Debug("Getting liquidity", logging.Fields{logging.AddressField: account.Address})
 	errorCode, liquidity, shortfall, err := bot.comptrollerService.GetAccountLiquidity(nil, common.HexToAddress(account.Address))
 	if err != nil || errorCode.Cmp(big.NewInt(0)) > 0 {
 		bot.logger.Panicf("Problem getting account liquidity: %v, errorCode = %v", err, errorCode)
 	}
 	account.Liquidity = liquidity
 	account.Shortfall = shortfall
-	bot.logger.Printf("Liquidity for account: %v", account)
+	bot.logger.Debug("Got liquidity", logging.Fields{
+		logging.AddressField: account.Address,
+		"liquidity":          liquidity,
+		"shortfall":          shortfall,
+	})
 }
 
 // String returns string representation of the bot.
@@ -168,14 +335,15 @@ func (bot AccountsBot) String() string {
 func (bot *AccountsBot) insertState(ctx context.Context, state *BotState) {
 	// create the initial bot record.
 	state.ShardKey = bson.NewObjectId().Hex()
-	state.BotType = "AccountsBot"
+	state.BotType = BotType
+	state.InstanceID = models.DefaultInstanceID
 	state.LastWakeTime = time.Now()
 	// An operation that may fail.
 	operation := func() error {
-		bot.logger.Printf("Inserting AccountsBot state: %v\n", state)
+		bot.logger.Debug("Inserting state", logging.Fields{"shardKey": state.ShardKey})
 		err := bot.botsService.CreateBotState(ctx, state)
 		if err != nil {
-			bot.logger.Printf("Problem inserting data: %v", err)
+			bot.logger.Warn("Problem inserting state", logging.Fields{logging.ErrorField: err})
 			return err
 		}
 		return nil
@@ -184,27 +352,31 @@ func (bot *AccountsBot) insertState(ctx context.Context, state *BotState) {
 	if err != nil {
 		bot.logger.Panicf("Problem inserting data: %v", err)
 	}
-	bot.logger.Printf("Inserted AccountsBot: %v\n", state)
+	bot.logger.Info("Inserted state", logging.Fields{"shardKey": state.ShardKey})
 }
 
 func (bot *AccountsBot) initializeState(ctx context.Context) {
 	// restore state for accounts bot.
 	// query the db for bot with bottype == AccountsBot.
 	state := &BotState{}
-	err := bot.botsService.GetBotState(ctx, state)
-	if err != nil {
-		bot.logger.Printf("Could not find existing bot state: %v\n", err)
+	err := bot.botsService.GetBotState(ctx, BotType, models.DefaultInstanceID, state)
+	if err == models.ErrBotStateNotFound {
+		bot.logger.Info("Could not find existing state", logging.Fields{logging.ErrorField: err})
 		bot.insertState(ctx, state)
+	} else if err != nil {
+		bot.logger.Panicf("Error finding bot state: %v", err)
 	} else {
 		state.LastWakeTime = time.Now()
-		updateQuery := bson.M{"shardkey": state.ShardKey}
 		change := bson.M{"$set": bson.M{"lastwaketime": state.LastWakeTime}}
 		// An operation that may fail.
 		operation := func() error {
-			bot.logger.Printf("Updating AccountsBot: %v\n", state)
-			err = bot.botsService.UpdateBotState(ctx, updateQuery, change)
+			bot.logger.Debug("Updating state", logging.Fields{"shardKey": state.ShardKey, "version": state.Version})
+			err = bot.botsService.UpdateBotState(ctx, state, change)
+			if err == models.ErrBotStateConflict {
+				return backoff.Permanent(err)
+			}
 			if err != nil {
-				bot.logger.Printf("Error updating record: %v", err)
+				bot.logger.Warn("Problem updating state", logging.Fields{logging.ErrorField: err})
 				return err
 			}
 			return nil
@@ -213,63 +385,248 @@ func (bot *AccountsBot) initializeState(ctx context.Context) {
 		if err != nil {
 			bot.logger.Panicf("Error updating record: %v", err)
 		}
-		bot.logger.Printf("Updated AccountsBot: %v\n", state)
+		bot.logger.Info("Updated state", logging.Fields{"shardKey": state.ShardKey, "version": state.Version})
 	}
 	bot.state = state
-	bot.logger.Printf("Initialized state for %v\n", bot)
+	bot.logger.Info("Initialized state", logging.Fields{"shardKey": bot.state.ShardKey, "version": bot.state.Version})
 }
 
-func (bot *AccountsBot) initializeAccounts(ctx context.Context) {
+func (bot *AccountsBot) initializeAccounts(ctx context.Context) error {
 	// restore accounts from db.
-	bot.logger.Printf("Initializing accounts for %v.\n", bot)
+	bot.logger.Debug("Initializing accounts", nil)
 	bot.accounts = make(map[string]*models.Account)
 	accounts := []*models.Account{}
 	err := bot.accountsService.GetAccounts(ctx, &accounts)
 	if err != nil {
-		bot.logger.Fatalf("Error finding accounts: %v\n", err)
+		return err
 	}
 	for _, account := range accounts {
 		bot.accounts[account.Address] = account
 	}
-	bot.logger.Printf("Initialized %v accounts for %v.\n", len(bot.accounts), bot)
+	bot.metrics.AccountsTracked.Set(float64(len(bot.accounts)))
+	bot.logger.Info("Initialized accounts", logging.Fields{"accounts": len(bot.accounts)})
+	return nil
 }
 
-func (bot *AccountsBot) filterBorrowEvents(tokenSymbol string, tokenName string, token contracts.Token) contracts.TokenBorrowIterator {
-	bot.logger.Printf("Processing accounts for token %v (%v) at block # %v @ %v\n", tokenName, tokenSymbol, bot.state.LastBorrowBlockByToken[tokenSymbol], bot.tokenAddresses[tokenSymbol].Hex())
-	// alternatively, +1 => exclude the last borrow block.
-	filterOptions := &bind.FilterOpts{Start: bot.state.LastBorrowBlockByToken[tokenSymbol], End: nil, Context: nil}
-	var iter contracts.TokenBorrowIterator
-	var err error
+// backfillTokenEvents applies the Borrow events of all tokens up to the head block in chunks,
+// saving the modified accounts and the scanned block after each chunk.
+func (bot *AccountsBot) backfillTokenEvents(ctx context.Context, head uint64, modifiedAccounts map[string]*models.Account) error {
+	start := bot.getBackfillStartBlock()
+	bot.logger.Info("Processing token events", logging.Fields{"markets": len(bot.tokens), logging.BlockField: start})
+	chunkAccounts := map[string]*models.Account{}
+	query := func(opts *bind.FilterOpts) error {
+		tokenLogs, err := bot.fetchTokenLogs(opts)
+		if err != nil {
+			return err
+		}
+		// only Borrow events are applied, so only they are looked up.
+		borrowLogs := []*contracts.TokenLog{}
+		ids := []bson.ObjectId{}
+		for _, tokenLog := range tokenLogs {
+			if _, ok := tokenLog.Event.(contracts.TokenBorrow); !ok || tokenLog.EventName != contracts.BorrowEventName {
+				continue
+			}
+			borrowLogs = append(borrowLogs, tokenLog)
+			ids = append(ids, models.GetEventID(tokenLog.Event.GetTxHash().Hex(), tokenLog.Event.GetLogIndex()))
+		}
+		appliedEvents := bot.getAppliedEvents(ctx, ids)
+		chunkAccounts = map[string]*models.Account{}
+		bot.parseAccountBorrowBalancesFromTokenLogs(ctx, borrowLogs, appliedEvents, chunkAccounts)
+		return nil
+	}
+	checkpoint := func(end uint64) error {
+		// the accounts are stored before their events are marked applied, so a restart in between reapplies them.
+		bot.saveAccounts(ctx, chunkAccounts)
+		bot.appendAccountEvents(ctx)
+		bot.markEventsApplied(ctx)
+		for address, account := range chunkAccounts {
+			modifiedAccounts[address] = account
+		}
+		bot.saveBorrowCheckpoint(ctx, end)
+		return nil
+	}
+	return bot.chunker.Filter(ctx, start, head, query, checkpoint)
+}
+
+// migrateBorrowCheckpoints converts the legacy checkpoints into scanned blocks.
+func (bot *AccountsBot) migrateBorrowCheckpoints() {
+	for tokenSymbol, block := range bot.state.LastBorrowBlockByToken {
+		if _, ok := bot.state.ScannedBlockByToken[tokenSymbol]; ok || block == 0 {
+			continue
+		}
+		// the legacy scans ran to the head, so the checkpointed block is complete.
+		bot.state.ScannedBlockByToken[tokenSymbol] = block
+	}
+	bot.state.LastBorrowBlockByToken = nil
+}
+
+// getBackfillStartBlock returns the first block not scanned for every token.
+func (bot *AccountsBot) getBackfillStartBlock() uint64 {
+	var start uint64
+	first := true
+	for tokenSymbol := range bot.tokens {
+		var tokenStart uint64
+		if scanned, ok := bot.state.ScannedBlockByToken[tokenSymbol]; ok {
+			tokenStart = scanned + 1
+		}
+		if first || tokenStart < start {
+			start = tokenStart
+			first = false
+		}
+	}
+	return start
+}
+
+func (bot *AccountsBot) fetchTokenLogs(filterOptions *bind.FilterOpts) ([]*contracts.TokenLog, error) {
+	var tokenLogs []*contracts.TokenLog
 	// An operation that may fail.
 	operation := func() error {
-		iter, err = token.FilterBorrowEvents(filterOptions)
+		var err error
+		tokenLogs, err = bot.logFetcher.FetchLogs(filterOptions)
+		if err != nil {
+			bot.logger.Warn("Failed to fetch token events", logging.Fields{logging.ErrorField: err})
+			if backfill.IsRangeError(err) {
+				// the chunker retries with a smaller range.
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	return tokenLogs, err
+}
+
+// getHeadBlock returns the number of the latest block.
+func (bot *AccountsBot) getHeadBlock(ctx context.Context) (uint64, error) {
+	var head uint64
+	operation := func() error {
+		header, err := bot.headerReader.HeaderByNumber(ctx, nil)
 		if err != nil {
-			bot.logger.Printf("Failed to FilterBorrowEvents for token %v: %v", tokenSymbol, err)
+			bot.logger.Warn("Failed to get head block", logging.Fields{logging.ErrorField: err})
 			return err
 		}
+		head = header.Number.Uint64()
 		return nil
 	}
-	err = backoff.Retry(operation, backoff.NewExponentialBackOff())
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	return head, err
+}
+
+// saveAccounts upserts the modified accounts.
+func (bot *AccountsBot) saveAccounts(ctx context.Context, accounts map[string]*models.Account) {
+	// TODO: go routine per account w/ bounded parallelism?
+	for _, account := range accounts {
+		if account == nil {
+			// TODO: enable deleting from cosmos db.
+			continue
+		}
+		// TODO: move backoff logic into accounts service.
+		operation := func() error {
+			bot.logger.Debug("Upserting account", logging.Fields{logging.AddressField: account.Address})
+			err := bot.accountsService.UpsertAccount(ctx, account)
+			if err != nil {
+				bot.logger.Warn("Problem upserting account", logging.Fields{logging.AddressField: account.Address, logging.ErrorField: err})
+				return err
+			}
+			bot.logger.Debug("Upserted account", logging.Fields{logging.AddressField: account.Address})
+			return nil
+		}
+		err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+		if err != nil {
+			bot.logger.Panicf("Problem upserting data: %v", err)
+		}
+	}
+}
+
+// saveBorrowCheckpoint stores the scanned block and the last applied Borrow event of every token.
+func (bot *AccountsBot) saveBorrowCheckpoint(ctx context.Context, block uint64) {
+	fields := bson.M{}
+	for tokenSymbol := range bot.tokens {
+		if scanned, ok := bot.state.ScannedBlockByToken[tokenSymbol]; !ok || scanned < block {
+			bot.state.ScannedBlockByToken[tokenSymbol] = block
+		}
+		fields["scannedblockbytoken."+tokenSymbol] = bot.state.ScannedBlockByToken[tokenSymbol]
+		if cursor, ok := bot.state.BorrowCursorByToken[tokenSymbol]; ok {
+			fields["borrowcursorbytoken."+tokenSymbol] = cursor
+		}
+	}
+	change := bson.M{"$set": fields}
+	operation := func() error {
+		err := bot.botsService.UpdateBotState(ctx, bot.state, change)
+		if err == models.ErrBotStateConflict {
+			// another instance wrote the state, so retrying would overwrite its progress.
+			return backoff.Permanent(err)
+		}
+		if err != nil {
+			bot.logger.Warn("Problem updating state", logging.Fields{logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		bot.logger.Panicf("Error updating record: %v", err)
+	}
+	bot.logger.Info("Checkpointed token events", logging.Fields{"markets": len(bot.tokens), logging.BlockField: block})
+}
+
+// saveMembershipCheckpoint stores the scanned block and the last applied market membership event.
+func (bot *AccountsBot) saveMembershipCheckpoint(ctx context.Context, block uint64) {
+	if bot.state.LastMembershipBlock < block {
+		bot.state.LastMembershipBlock = block
+	}
+	change := bson.M{
+		"$set": bson.M{
+			"lastmembershipblock": bot.state.LastMembershipBlock,
+			"membershipcursor":    bot.state.MembershipCursor,
+		},
+	}
+	operation := func() error {
+		err := bot.botsService.UpdateBotState(ctx, bot.state, change)
+		if err == models.ErrBotStateConflict {
+			// another instance wrote the state, so retrying would overwrite its progress.
+			return backoff.Permanent(err)
+		}
+		if err != nil {
+			bot.logger.Warn("Problem updating state", logging.Fields{logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
 	if err != nil {
-		bot.logger.Fatalf("Failed to FilterBorrowEvents for token %v: %v", tokenSymbol, err)
+		bot.logger.Panicf("Error updating record: %v", err)
 	}
-	return iter
+	bot.logger.Info("Checkpointed market membership", logging.Fields{logging.BlockField: block})
 }
 
 // Sleep saves the bot's state and lets it rest.
 func (bot *AccountsBot) Sleep(ctx context.Context, statusChannel chan int) {
-	bot.logger.Printf("%v sleeping...\n", bot)
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Sleeping", nil)
 	bot.state.LastSleepTime = time.Now()
-	updateQuery := bson.M{"shardkey": bot.state.ShardKey}
-	change := bson.M{"$set": bson.M{"lastsleeptime": bot.state.LastSleepTime, "lastborrowblockbytoken": bot.state.LastBorrowBlockByToken}}
-	bot.logger.Printf("Updating AccountsBot: %v\n", bot.state)
+	change := bson.M{
+		"$set": bson.M{
+			"lastsleeptime":       bot.state.LastSleepTime,
+			"scannedblockbytoken": bot.state.ScannedBlockByToken,
+			"borrowcursorbytoken": bot.state.BorrowCursorByToken,
+			"lastmembershipblock": bot.state.LastMembershipBlock,
+			"membershipcursor":    bot.state.MembershipCursor,
+		},
+		"$unset": bson.M{"lastborrowblockbytoken": ""},
+	}
+	bot.logger.Debug("Updating state", logging.Fields{"shardKey": bot.state.ShardKey, "version": bot.state.Version})
 	operation := func() error {
-		err := bot.botsService.UpdateBotState(ctx, updateQuery, change)
+		err := bot.botsService.UpdateBotState(ctx, bot.state, change)
+		if err == models.ErrBotStateConflict {
+			return backoff.Permanent(err)
+		}
 		if err != nil {
-			bot.logger.Printf("Error updating record: %T %v", err, err)
+			bot.logger.Warn("Problem updating state", logging.Fields{logging.ErrorField: err})
 			return err
 		}
-		bot.logger.Printf("Updated AccountsBot: %v\n", bot.state)
+		bot.logger.Info("Updated state", logging.Fields{"shardKey": bot.state.ShardKey, "version": bot.state.Version})
 		return nil
 	}
 	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
@@ -279,48 +636,407 @@ func (bot *AccountsBot) Sleep(ctx context.Context, statusChannel chan int) {
 	statusChannel <- 0
 }
 
-func (bot *AccountsBot) parseAccountBorrowBalancesFromBorrowEvents(iter contracts.TokenBorrowIterator, tokenSymbol string, modifiedAccounts map[string]*models.Account) uint64 {
-	bot.logger.Printf("Parsing accounts...\n")
-	var lastBlock uint64 = 0
-	for i := 0; iter.Next(); i++ {
-		borrowEvent := iter.GetEvent()
-		if borrowEvent != nil {
-			lastBlock = borrowEvent.GetBlockNumber()
-			addressHex := borrowEvent.GetBorrower().Hex()
-			borrows := borrowEvent.GetAccountBorrows()
-			account, ok := bot.accounts[addressHex]
-			if !ok && borrows.Cmp(big.NewInt(0)) == 1 {
-				account = &models.Account{
-					ID:       bson.NewObjectId(),
-					ShardKey: addressHex,
-					Address:  addressHex,
-					Borrows:  make(map[string]*big.Int),
-				}
-				account.Borrows[tokenSymbol] = borrows
-				bot.accounts[account.Address] = account
-				modifiedAccounts[account.Address] = account
-				bot.logger.Printf("Added account: %#v. Borrowed %#v (%#v)\n", account.Address, account.Borrows[tokenSymbol], tokenSymbol)
-			} else if ok && borrows.Cmp(big.NewInt(0)) == 1 {
-				account.Borrows[tokenSymbol] = borrows
-				modifiedAccounts[account.Address] = account
-				bot.logger.Printf("Updated account: %#v. Borrowed %#v (%#v)\n", account.Address, account.Borrows[tokenSymbol], tokenSymbol)
-			} else if ok && borrows.Cmp(big.NewInt(0)) < 1 {
-				account.Borrows[tokenSymbol] = borrows
-				// check if all token borrows for the account are 0.
-				accountEmpty := true
-				for _, value := range account.Borrows {
-					if value.Cmp(big.NewInt(0)) == 1 {
-						accountEmpty = false
-					}
+// parseAccountBorrowBalancesFromTokenLogs applies the Borrow events not yet applied.
+func (bot *AccountsBot) parseAccountBorrowBalancesFromTokenLogs(ctx context.Context, tokenLogs []*contracts.TokenLog, appliedEvents map[bson.ObjectId]bool, modifiedAccounts map[string]*models.Account) {
+	bot.logger.Debug("Parsing accounts", nil)
+	for _, tokenLog := range tokenLogs {
+		borrowEvent, ok := tokenLog.Event.(contracts.TokenBorrow)
+		if !ok || tokenLog.EventName != contracts.BorrowEventName {
+			continue
+		}
+		tokenSymbol := tokenLog.TokenSymbol
+		if bot.isBorrowEventApplied(tokenSymbol, borrowEvent) || appliedEvents[models.GetEventID(borrowEvent.GetTxHash().Hex(), borrowEvent.GetLogIndex())] {
+			continue
+		}
+		bot.state.BorrowCursorByToken[tokenSymbol] = &backfill.Cursor{
+			BlockNumber: borrowEvent.GetBlockNumber(),
+			LogIndex:    borrowEvent.GetLogIndex(),
+		}
+		bot.eventTotalBorrows[tokenSymbol] = &reconciliation.EventTotalBorrows{
+			TotalBorrows: borrowEvent.GetTotalBorrows(),
+			BlockNumber:  borrowEvent.GetBlockNumber(),
+		}
+		addressHex := borrowEvent.GetBorrower().Hex()
+		borrows := borrowEvent.GetAccountBorrows()
+		account, ok := bot.accounts[addressHex]
+		if !ok && borrows.Cmp(big.NewInt(0)) == 1 {
+			account = &models.Account{
+				ID:       bson.NewObjectId(),
+				ShardKey: addressHex,
+				Address:  addressHex,
+				Borrows:  make(map[string]*big.Int),
+				Markets:  bot.getAssetsIn(borrowEvent.GetBorrower()),
+			}
+			account.Borrows[tokenSymbol] = borrows
+			bot.setBorrowIndex(ctx, account, tokenSymbol, borrowEvent)
+			bot.accounts[account.Address] = account
+			modifiedAccounts[account.Address] = account
+			bot.recordBorrowEvent(borrowEvent, account.Address, tokenSymbol, nil)
+			bot.logBorrowEvent("Added account", account.Address, tokenSymbol, borrowEvent)
+		} else if ok && borrows.Cmp(big.NewInt(0)) == 1 {
+			bot.recordBorrowEvent(borrowEvent, account.Address, tokenSymbol, account.Borrows[tokenSymbol])
+			account.Borrows[tokenSymbol] = borrows
+			bot.setBorrowIndex(ctx, account, tokenSymbol, borrowEvent)
+			modifiedAccounts[account.Address] = account
+			bot.logBorrowEvent("Updated account", account.Address, tokenSymbol, borrowEvent)
+		} else if ok && borrows.Cmp(big.NewInt(0)) < 1 {
+			bot.recordBorrowEvent(borrowEvent, account.Address, tokenSymbol, account.Borrows[tokenSymbol])
+			account.Borrows[tokenSymbol] = borrows
+			bot.setBorrowIndex(ctx, account, tokenSymbol, borrowEvent)
+			// check if all token borrows for the account are 0.
+			accountEmpty := true
+			for _, value := range account.Borrows {
+				if value.Cmp(big.NewInt(0)) == 1 {
+					accountEmpty = false
 				}
-				if accountEmpty {
-					delete(bot.accounts, addressHex)
-					// TODO: enable deleting from cosmos db.
-					modifiedAccounts[account.Address] = nil
-					bot.logger.Printf("Deleted account: %#v. Balance: %#v (%#v)\n", account.Address, borrows, tokenSymbol)
+			}
+			if accountEmpty {
+				delete(bot.accounts, addressHex)
+				// TODO: enable deleting from cosmos db.
+				modifiedAccounts[account.Address] = nil
+				bot.logBorrowEvent("Deleted account", account.Address, tokenSymbol, borrowEvent)
+			}
+		}
+	}
+}
+
+// logBorrowEvent writes a debug message about the account changed by the Borrow event.
+func (bot *AccountsBot) logBorrowEvent(msg string, address string, tokenSymbol string, borrowEvent contracts.TokenBorrow) {
+	bot.logger.Debug(msg, logging.Fields{
+		logging.AddressField: address,
+		logging.TokenField:   tokenSymbol,
+		logging.BlockField:   borrowEvent.GetBlockNumber(),
+		"borrows":            borrowEvent.GetAccountBorrows(),
+	})
+}
+
+// isBorrowEventApplied returns whether the Borrow event is in a scanned block or not after the token's cursor.
+func (bot *AccountsBot) isBorrowEventApplied(tokenSymbol string, borrowEvent contracts.TokenBorrow) bool {
+	if scanned, ok := bot.state.ScannedBlockByToken[tokenSymbol]; ok && borrowEvent.GetBlockNumber() <= scanned {
+		return true
+	}
+	return !bot.state.BorrowCursorByToken[tokenSymbol].IsBefore(borrowEvent.GetBlockNumber(), borrowEvent.GetLogIndex())
+}
+
+// backfillMarketMembership applies the MarketEntered and MarketExited events up to the head block in chunks,
+// saving the modified accounts and the scanned block after each chunk.
+func (bot *AccountsBot) backfillMarketMembership(ctx context.Context, head uint64, modifiedAccounts map[string]*models.Account) error {
+	// the last scanned block is scanned again, the cursor skipping the events already applied.
+	start := bot.state.LastMembershipBlock
+	bot.logger.Info("Processing market membership", logging.Fields{logging.BlockField: start})
+	chunkAccounts := map[string]*models.Account{}
+	query := func(opts *bind.FilterOpts) error {
+		events, err := bot.fetchMarketMembershipEvents(opts)
+		if err != nil {
+			return err
+		}
+		chunkAccounts = map[string]*models.Account{}
+		bot.parseMarketMembership(ctx, events, chunkAccounts)
+		return nil
+	}
+	checkpoint := func(end uint64) error {
+		bot.saveAccounts(ctx, chunkAccounts)
+		bot.appendAccountEvents(ctx)
+		bot.markEventsApplied(ctx)
+		for address, account := range chunkAccounts {
+			modifiedAccounts[address] = account
+		}
+		bot.saveMembershipCheckpoint(ctx, end)
+		return nil
+	}
+	return bot.chunker.Filter(ctx, start, head, query, checkpoint)
+}
+
+// fetchMarketMembershipEvents returns the MarketEntered and MarketExited events of the block range in order.
+func (bot *AccountsBot) fetchMarketMembershipEvents(filterOptions *bind.FilterOpts) ([]contracts.MarketMembership, error) {
+	events := []contracts.MarketMembership{}
+	filters := []func(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error){
+		bot.comptrollerService.FilterMarketEntered,
+		bot.comptrollerService.FilterMarketExited,
+	}
+	for _, filter := range filters {
+		var iter contracts.MarketMembershipIterator
+		// An operation that may fail.
+		operation := func() error {
+			var err error
+			iter, err = filter(filterOptions)
+			if err != nil {
+				bot.logger.Warn("Failed to filter market membership events", logging.Fields{logging.ErrorField: err})
+				if backfill.IsRangeError(err) {
+					// the chunker retries with a smaller range.
+					return backoff.Permanent(err)
 				}
+				return err
 			}
+			return nil
+		}
+		err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+		if err != nil {
+			return nil, err
+		}
+		for iter.Next() {
+			events = append(events, iter.GetEvent())
+		}
+		err = iter.Error()
+		iter.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].GetBlockNumber() != events[j].GetBlockNumber() {
+			return events[i].GetBlockNumber() < events[j].GetBlockNumber()
+		}
+		return events[i].GetLogIndex() < events[j].GetLogIndex()
+	})
+	return events, nil
+}
+
+// parseMarketMembership applies the MarketEntered and MarketExited events of known accounts in order.
+func (bot *AccountsBot) parseMarketMembership(ctx context.Context, events []contracts.MarketMembership, modifiedAccounts map[string]*models.Account) {
+	ids := []bson.ObjectId{}
+	for _, event := range events {
+		ids = append(ids, models.GetEventID(event.GetTxHash().Hex(), event.GetLogIndex()))
+	}
+	appliedEvents := bot.getAppliedEvents(ctx, ids)
+	for i, event := range events {
+		if !bot.state.MembershipCursor.IsBefore(event.GetBlockNumber(), event.GetLogIndex()) || appliedEvents[ids[i]] {
+			// applied by an earlier scan of the block.
+			continue
+		}
+		bot.state.MembershipCursor = &backfill.Cursor{
+			BlockNumber: event.GetBlockNumber(),
+			LogIndex:    event.GetLogIndex(),
+		}
+		account, ok := bot.accounts[event.GetAccount().Hex()]
+		if !ok {
+			// accounts without borrows get their markets from the Comptroller once they borrow.
+			continue
 		}
+		market := contracts.GetTokenSymbol(bot.tokenAddresses, event.GetCToken())
+		accountEvent := &models.AccountEvent{
+			ID:          models.GetEventID(event.GetTxHash().Hex(), event.GetLogIndex()),
+			Address:     account.Address,
+			EventType:   models.AccountEventMarketExited,
+			TokenSymbol: market,
+			OldValue:    fmt.Sprint(account.HasEnteredMarket(market)),
+			NewValue:    fmt.Sprint(event.IsEntered()),
+			BlockNumber: event.GetBlockNumber(),
+			TxHash:      event.GetTxHash().Hex(),
+			LogIndex:    event.GetLogIndex(),
+		}
+		if event.IsEntered() {
+			accountEvent.EventType = models.AccountEventMarketEntered
+		}
+		bot.accountEvents = append(bot.accountEvents, accountEvent)
+		bot.recordAppliedEvent(accountEvent.EventType, market, event)
+		fields := logging.Fields{
+			logging.AddressField: account.Address,
+			logging.TokenField:   market,
+			logging.BlockField:   event.GetBlockNumber(),
+		}
+		if event.IsEntered() {
+			account.EnterMarket(market)
+			bot.logger.Debug("Entered market", fields)
+		} else {
+			account.ExitMarket(market)
+			bot.logger.Debug("Exited market", fields)
+		}
+		modifiedAccounts[account.Address] = account
+	}
+}
+
+// getAssetsIn returns the markets the account entered according to the Comptroller.
+func (bot *AccountsBot) getAssetsIn(address common.Address) []string {
+	assetsIn, err := bot.comptrollerService.GetAssetsIn(nil, address)
+	if err != nil {
+		bot.logger.Panicf("Problem getting assets in: %v", err)
+	}
+	markets := []string{}
+	for _, asset := range assetsIn {
+		markets = append(markets, contracts.GetTokenSymbol(bot.tokenAddresses, asset))
+	}
+	sort.Strings(markets)
+	return markets
+}
+
+// setBorrowIndex stores the market's borrow index as of the Borrow event alongside the account's borrow balance.
+func (bot *AccountsBot) setBorrowIndex(ctx context.Context, account *models.Account, tokenSymbol string, borrowEvent contracts.TokenBorrow) {
+	if account.BorrowIndexes == nil {
+		account.BorrowIndexes = make(map[string]*big.Int)
+	}
+	borrowIndex, err := bot.interestAccrual.GetBorrowIndexAt(ctx, tokenSymbol, borrowEvent.GetBlockNumber(), borrowEvent.GetLogIndex())
+	if err != nil {
+		bot.logger.Warn("Failed to get borrow index", logging.Fields{
+			logging.AddressField: account.Address,
+			logging.TokenField:   tokenSymbol,
+			logging.BlockField:   borrowEvent.GetBlockNumber(),
+			logging.ErrorField:   err,
+		})
+		delete(account.BorrowIndexes, tokenSymbol)
+		return
+	}
+	account.BorrowIndexes[tokenSymbol] = borrowIndex
+}
+
+// snapshotMarkets stores the current state of each market.
+func (bot *AccountsBot) snapshotMarkets(ctx context.Context) {
+	numberOfMarkets := 0
+	for tokenSymbol, token := range bot.tokens {
+		market, err := models.ReadMarket(&bind.CallOpts{Context: ctx}, tokenSymbol, bot.tokenAddresses[tokenSymbol], token)
+		if err != nil {
+			bot.logger.Warn("Failed to read market", logging.Fields{logging.TokenField: tokenSymbol, logging.ErrorField: err})
+			continue
+		}
+		operation := func() error {
+			err := bot.marketsService.UpsertMarket(ctx, market)
+			if err != nil {
+				bot.logger.Warn("Problem upserting market", logging.Fields{logging.TokenField: tokenSymbol, logging.ErrorField: err})
+				return err
+			}
+			return nil
+		}
+		err = backoff.Retry(operation, backoff.NewExponentialBackOff())
+		if err != nil {
+			bot.logger.Panicf("Problem upserting market %v: %v", tokenSymbol, err)
+		}
+		numberOfMarkets++
+	}
+	bot.logger.Info("Snapshotted markets", logging.Fields{"markets": numberOfMarkets})
+}
+
+// reconcileBorrows flags the markets whose stored borrows drifted from the reported total borrows.
+func (bot *AccountsBot) reconcileBorrows(ctx context.Context) {
+	reports, err := bot.reconciler.Reconcile(ctx, bot.accounts, bot.eventTotalBorrows)
+	if err != nil {
+		bot.logger.Warn("Failed to reconcile borrows", logging.Fields{logging.ErrorField: err})
+		return
+	}
+	numberOfDriftedMarkets := 0
+	for _, report := range reports {
+		if report.Drifted {
+			numberOfDriftedMarkets++
+			bot.logger.Warn("Borrows drift", logging.Fields{
+				logging.TokenField:    report.TokenSymbol,
+				logging.BlockField:    report.EventBlockNumber,
+				"storedBorrows":       report.StoredBorrows,
+				"eventTotalBorrows":   report.EventTotalBorrows,
+				"eventDriftBps":       report.EventDriftBps,
+				"onChainTotalBorrows": report.OnChainTotalBorrows,
+				"onChainDriftBps":     report.OnChainDriftBps,
+			})
+		}
+	}
+	bot.logger.Info("Reconciled markets", logging.Fields{"markets": len(reports), "drifted": numberOfDriftedMarkets})
+}
+
+// recordBorrowEvent records the change of the account's borrow balance by the Borrow event.
+func (bot *AccountsBot) recordBorrowEvent(borrowEvent contracts.TokenBorrow, address string, tokenSymbol string, oldBorrows *big.Int) {
+	if oldBorrows == nil {
+		oldBorrows = big.NewInt(0)
+	}
+	bot.recordAppliedEvent(models.AccountEventBorrow, tokenSymbol, borrowEvent)
+	bot.accountEvents = append(bot.accountEvents, &models.AccountEvent{
+		ID:          models.GetEventID(borrowEvent.GetTxHash().Hex(), borrowEvent.GetLogIndex()),
+		Address:     address,
+		EventType:   models.AccountEventBorrow,
+		TokenSymbol: tokenSymbol,
+		OldValue:    oldBorrows.String(),
+		NewValue:    borrowEvent.GetAccountBorrows().String(),
+		BlockNumber: borrowEvent.GetBlockNumber(),
+		TxHash:      borrowEvent.GetTxHash().Hex(),
+		LogIndex:    borrowEvent.GetLogIndex(),
+	})
+}
+
+// appendAccountEvents stores the changes applied to accounts during work.
+func (bot *AccountsBot) appendAccountEvents(ctx context.Context) {
+	if len(bot.accountEvents) == 0 {
+		return
+	}
+	recordedTime := time.Now()
+	for _, accountEvent := range bot.accountEvents {
+		accountEvent.RecordedTime = recordedTime
+	}
+	operation := func() error {
+		err := bot.accountEventsService.AppendAccountEvents(ctx, bot.accountEvents)
+		if err != nil {
+			bot.logger.Warn("Problem appending account events", logging.Fields{logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		bot.logger.Panicf("Problem appending account events: %v", err)
+	}
+	bot.logger.Info("Appended account events", logging.Fields{"events": len(bot.accountEvents)})
+	bot.accountEvents = []*models.AccountEvent{}
+}
+
+// recordAppliedEvent records the chain event whose changes are applied to accounts.
+func (bot *AccountsBot) recordAppliedEvent(eventType string, tokenSymbol string, event contracts.TokenEvent) {
+	bot.metrics.EventsProcessed.Add(1, tokenSymbol, eventType)
+	bot.appliedEvents = append(bot.appliedEvents, &models.AppliedEvent{
+		EventType:   eventType,
+		TokenSymbol: tokenSymbol,
+		BlockNumber: event.GetBlockNumber(),
+		TxHash:      event.GetTxHash().Hex(),
+		LogIndex:    event.GetLogIndex(),
+	})
+}
+
+// getAppliedEvents returns which of the events are applied, looking them up in batches
+// so a chunk with many events does not become a single large cross-partition query.
+func (bot *AccountsBot) getAppliedEvents(ctx context.Context, ids []bson.ObjectId) map[bson.ObjectId]bool {
+	appliedEvents := make(map[bson.ObjectId]bool)
+	for start := 0; start < len(ids); start += appliedEventsBatchSize {
+		end := start + appliedEventsBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var batch map[bson.ObjectId]bool
+		operation := func() error {
+			var err error
+			batch, err = bot.appliedEventsService.GetAppliedEvents(ctx, ids[start:end])
+			if err != nil {
+				bot.logger.Warn("Problem getting applied events", logging.Fields{logging.ErrorField: err})
+				return err
+			}
+			return nil
+		}
+		err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+		if err != nil {
+			bot.logger.Panicf("Problem getting applied events: %v", err)
+		}
+		for id, applied := range batch {
+			appliedEvents[id] = applied
+		}
+	}
+	return appliedEvents
+}
+
+// markEventsApplied stores the chain events applied since the last call.
+func (bot *AccountsBot) markEventsApplied(ctx context.Context) {
+	if len(bot.appliedEvents) == 0 {
+		return
+	}
+	appliedTime := time.Now()
+	for _, appliedEvent := range bot.appliedEvents {
+		appliedEvent.AppliedTime = appliedTime
+	}
+	operation := func() error {
+		err := bot.appliedEventsService.MarkEventsApplied(ctx, bot.appliedEvents)
+		if err != nil {
+			bot.logger.Warn("Problem marking events applied", logging.Fields{logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		bot.logger.Panicf("Problem marking events applied: %v", err)
 	}
-	return lastBlock
+	bot.logger.Info("Marked events applied", logging.Fields{"events": len(bot.appliedEvents)})
+	bot.appliedEvents = []*models.AppliedEvent{}
 }
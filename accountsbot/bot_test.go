@@ -3,22 +3,30 @@ package accountsbot
 import (
 	"bytes"
 	"context"
-	"log"
+	"math/big"
+	"reflect"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/globalsign/mgo/bson"
+	"github.com/l3a0/carbon/backfill"
 	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/metrics"
 	"github.com/l3a0/carbon/models"
+	"github.com/l3a0/carbon/reconciliation"
+	"github.com/l3a0/carbon/sharding"
 )
 
 func TestAccountsBot_Wake(t *testing.T) {
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
-	// logger := log.New(os.Stderr, "", 0)
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+	// logger := logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat)
 	cosmosClient := models.NewCosmosService(
-		// log.New(os.Stderr, "CosmosClient | ", 0),
+		// logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "CosmosClient"),
 		logger,
 		models.CosmosConfiguration{
 			SubscriptionID:    "6951e94d-0947-4c5e-b865-f864609da246",
@@ -34,7 +42,7 @@ func TestAccountsBot_Wake(t *testing.T) {
 	}
 	defer session.Close()
 	documentDbCollectionFactory := models.NewCosmosCollectionFactory(
-		// log.New(os.Stderr, "DocumentDbCollectionFactory | ", 0),
+		// logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "DocumentDbCollectionFactory"),
 		logger,
 		cosmosClient,
 		session)
@@ -78,24 +86,48 @@ func TestAccountsBot_Wake(t *testing.T) {
 		Contracts: fakeContracts,
 	}
 	accountsService := models.NewCosmosAccountsService(
-		// log.New(os.Stderr, "CosmosAccountsService | ", 0),
+		// logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "CosmosAccountsService"),
 		logger,
 		documentDbCollectionFactory,
 		accountsCollectionName)
 	botsService := models.NewCosmosBotsService(
-		// log.New(os.Stderr, "CosmosBotsService | ", 0),
+		// logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "CosmosBotsService"),
 		logger,
 		documentDbCollectionFactory,
 		botsCollectionName)
 	comptrollerService := &models.MockComptroller{}
+	accountEventsService := &models.MockAccountEventsService{}
+	interestAccrual := &models.MockInterestAccrual{}
+	marketsService := &models.MockMarketsService{}
+	reconciler := reconciliation.NewBorrowsReconciler(tokensProvider, interestAccrual, 100)
+	headerReader := &models.MockHeaderReader{}
+	chunker := backfill.NewAdaptiveChunker(logger, 10, 1, 100)
+	appliedEventsService := &models.MockAppliedEventsService{}
+	coordinator := sharding.NewShardCoordinator(logger, &models.MockBotsService{}, BotType, "worker", time.Minute, sharding.DefaultReplicas, nil)
+	logFetcher := &contracts.MockLogFetcher{
+		Logs: []*contracts.TokenLog{
+			{TokenSymbol: contracts.CBATSymbol, EventName: contracts.BorrowEventName, Event: borrowEvents[0]},
+			{TokenSymbol: contracts.CUSDCSymbol, EventName: contracts.BorrowEventName, Event: borrowEvents[0]},
+		},
+	}
 	type fields struct {
-		botsCollection     models.Collection
-		accountsCollection models.Collection
-		tokensProvider     contracts.TokensProvider
-		logger             *log.Logger
-		accountsService    models.AccountsService
-		botsService        models.BotsService
-		comptrollerService models.ComptrollerService
+		botsCollection       models.Collection
+		accountsCollection   models.Collection
+		tokensProvider       contracts.TokensProvider
+		logger               logging.Logger
+		accountsService      models.AccountsService
+		botsService          models.BotsService
+		comptrollerService   models.ComptrollerService
+		accountEventsService models.AccountEventsService
+		interestAccrual      models.InterestAccrual
+		marketsService       models.MarketsService
+		reconciler           reconciliation.Reconciler
+		headerReader         models.HeaderReader
+		chunker              backfill.Chunker
+		logFetcher           contracts.LogFetcher
+		appliedEventsService models.AppliedEventsService
+		coordinator          sharding.Coordinator
+		metrics              *metrics.Metrics
 	}
 	type args struct {
 		statusChannel chan int
@@ -108,13 +140,23 @@ func TestAccountsBot_Wake(t *testing.T) {
 		{
 			name: "Should wake, work, and sleep.",
 			fields: fields{
-				botsCollection:     botsCollection,
-				accountsCollection: accountsCollection,
-				tokensProvider:     tokensProvider,
-				logger:             logger,
-				accountsService:    accountsService,
-				botsService:        botsService,
-				comptrollerService: comptrollerService,
+				botsCollection:       botsCollection,
+				accountsCollection:   accountsCollection,
+				tokensProvider:       tokensProvider,
+				logger:               logger,
+				accountsService:      accountsService,
+				botsService:          botsService,
+				comptrollerService:   comptrollerService,
+				accountEventsService: accountEventsService,
+				interestAccrual:      interestAccrual,
+				marketsService:       marketsService,
+				reconciler:           reconciler,
+				headerReader:         headerReader,
+				chunker:              chunker,
+				logFetcher:           logFetcher,
+				appliedEventsService: appliedEventsService,
+				coordinator:          coordinator,
+				metrics:              metrics.NewMetrics(metrics.NewRegistry()),
 			},
 			args: args{
 				statusChannel: make(chan int),
@@ -128,7 +170,17 @@ func TestAccountsBot_Wake(t *testing.T) {
 				tt.fields.logger,
 				tt.fields.accountsService,
 				tt.fields.botsService,
-				tt.fields.comptrollerService)
+				tt.fields.comptrollerService,
+				tt.fields.accountEventsService,
+				tt.fields.interestAccrual,
+				tt.fields.marketsService,
+				tt.fields.reconciler,
+				tt.fields.headerReader,
+				tt.fields.chunker,
+				tt.fields.logFetcher,
+				tt.fields.appliedEventsService,
+				tt.fields.coordinator,
+				tt.fields.metrics)
 			// Act
 			go bot.Wake(ctx, tt.args.statusChannel)
 			status := <-tt.args.statusChannel
@@ -152,189 +204,202 @@ func TestAccountsBot_Wake(t *testing.T) {
 			}
 			// Assert
 			output, _ := buf.ReadString('\n')
-			if output != "Collection not found: mock-bots.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Collection not found: mock-bots.\\n")
+			if output != "Collection not found collection=mock-bots\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Collection not found collection=mock-bots\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Creating collection: mock-bots.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Creating collection: mock-bots.\\n")
+			if output != "Creating collection collection=mock-bots\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Creating collection collection=mock-bots\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Created collection: mock-bots.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Created collection: mock-bots.\\n")
+			if output != "Created collection collection=mock-bots\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Created collection collection=mock-bots\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Collection not found: mock-accounts.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Collection not found: mock-accounts.\\n")
+			if output != "Collection not found collection=mock-accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Collection not found collection=mock-accounts\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Creating collection: mock-accounts.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Creating collection: mock-accounts.\\n")
+			if output != "Creating collection collection=mock-accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Creating collection collection=mock-accounts\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Created collection: mock-accounts.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Created collection: mock-accounts.\\n")
+			if output != "Created collection collection=mock-accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Created collection collection=mock-accounts\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "AccountsBot{<nil>} waking...\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "AccountsBot{<nil>} waking...\\n")
+			if output != "Waking\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Waking\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Collection found: mock-bots.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Collection found: mock-bots.\\n")
+			if output != "Collection found collection=mock-bots\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Collection found collection=mock-bots\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Could not find existing bot state: not found\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Could not find existing bot state: not found\\n")
+			if output != "Could not find existing state error=\"not found\"\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Could not find existing state error=\"not found\"\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re := regexp.MustCompile(`Inserting AccountsBot state: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}`)
+			re := regexp.MustCompile(`^Inserting state shardKey=[a-f\d]{24}\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\n') = %v, want %v", output, `Inserting AccountsBot state: {"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":null}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Inserting state shardKey=[a-f\d]{24}`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Creating Bot State: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}`)
+			re = regexp.MustCompile(`^Creating bot state botType=AccountsBot shardKey=[a-f\d]{24}\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\n') = %v, want %v", output, `Creating Bot State: {"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":null}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Creating bot state botType=AccountsBot shardKey=[a-f\d]{24}`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Created Bot State: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}`)
+			re = regexp.MustCompile(`^Created bot state botType=AccountsBot shardKey=[a-f\d]{24}\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\n') = %v, want %v", output, `Created Bot State: {"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":null}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Created bot state botType=AccountsBot shardKey=[a-f\d]{24}`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Inserted AccountsBot: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}`)
+			re = regexp.MustCompile(`^Inserted state shardKey=[a-f\d]{24}\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\n') = %v, want %v", output, `Inserted AccountsBot: {"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":null}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Inserted state shardKey=[a-f\d]{24}`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Initialized state for AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}}`)
+			re = regexp.MustCompile(`^Initialized state shardKey=[a-f\d]{24} version=\d+\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\n') = %v, want %v", output, `Initialized state for AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{}}}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Initialized state shardKey=[a-f\d]{24} version=\d+`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Initializing accounts for AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\n') = %v, want %v", output, `Initializing accounts for AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":null}}`)
+			if output != "Initializing accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Initializing accounts\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			if output != "Collection found: mock-accounts.\n" {
-				t.Errorf("output, _ := buf.ReadString('\\n') = %v, want %v", output, "Collection found: mock-accounts.\\n")
+			if output != "Collection found collection=mock-accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Collection found collection=mock-accounts\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Initialized 0 accounts for AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Initialized 0 accounts for AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":null}}`)
+			if output != "Initialized accounts accounts=0\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Initialized accounts accounts=0\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}} working...`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":null}} working...`)
+			if output != "Working\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Working\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Processing accounts for token MockToken \([A-Z]+\) at block # 0 @ 0x0000000000000000000000000000000000000000`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Processing accounts for token MockToken (*) at block # 0 @ 0x0000000000000000000000000000000000000000`)
+			if output != "Processing token events block=0 markets=2\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Processing token events block=0 markets=2\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Parsing accounts...`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Parsing accounts...`)
+			if output != "Parsing accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Parsing accounts\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Added account: "0x0000000000000000000000000000000000000000". Borrowed 1 \("[A-Z]+"\)`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Added account: "0x0000000000000000000000000000000000000000". Borrowed 1 ("*")`)
+			if output != "Added account address=0x0000000000000000000000000000000000000000 block=0 borrows=1 token=CBAT\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Added account address=0x0000000000000000000000000000000000000000 block=0 borrows=1 token=CBAT\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Processing accounts for token MockToken \([A-Z]+\) at block # 0 @ 0x0000000000000000000000000000000000000000`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Processing accounts for token MockToken (*) at block # 0 @ 0x0000000000000000000000000000000000000000`)
+			if output != "Updated account address=0x0000000000000000000000000000000000000000 block=0 borrows=1 token=CUSDC\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Updated account address=0x0000000000000000000000000000000000000000 block=0 borrows=1 token=CUSDC\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Parsing accounts...`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Parsing accounts...`)
+			if output != "Upserting account address=0x0000000000000000000000000000000000000000\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Upserting account address=0x0000000000000000000000000000000000000000\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Updated account: "0x0000000000000000000000000000000000000000". Borrowed 1 \("[A-Z]+"\)`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updated account: "0x0000000000000000000000000000000000000000". Borrowed 1 ("*")`)
+			if output != "Upserted account address=0x0000000000000000000000000000000000000000\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Upserted account address=0x0000000000000000000000000000000000000000\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`numberOfModifiedAccounts: 1`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `numberOfModifiedAccounts: 1`)
+			if output != "Appended account events events=2\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Appended account events events=2\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`numberOfAccounts: 1`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `numberOfAccounts: 1`)
+			if output != "Marked events applied events=2\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Marked events applied events=2\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Upserting account: &{ObjectIdHex\("[a-f\d]{24}"\) 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map\[CBAT:1 CUSDC:1\] <nil> <nil>}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Upserting account: &{ObjectIdHex("*") 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map[CBAT:1 CUSDC:1] <nil> <nil>}`)
+			if output != "Checkpointed token events block=0 markets=2\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Checkpointed token events block=0 markets=2\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Upserted account: &{ObjectIdHex\("[a-f\d]{24}"\) 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map\[CBAT:1 CUSDC:1\] <nil> <nil>}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Upserted account: &{ObjectIdHex("*") 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map[CBAT:1 CUSDC:1] <nil> <nil>}`)
+			if output != "Processing market membership block=0\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Processing market membership block=0\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Getting liquidity for account: &{ObjectIdHex\("[a-f\d]{24}"\) 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map\[CBAT:1 CUSDC:1\] <nil> <nil>}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Getting liquidity for account: &{ObjectIdHex("5e8a5b7b3fdf131e907eefb5") 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map[CBAT:1 CUSDC:1] <nil> <nil>}`)
+			if output != "Checkpointed market membership block=0\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Checkpointed market membership block=0\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Liquidity for account: &{ObjectIdHex\("[a-f\d]{24}"\) 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map\[CBAT:1 CUSDC:1\] 1 0}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Liquidity for account: &{ObjectIdHex("5e8a5b7b3fdf131e907eefb5") 0x0000000000000000000000000000000000000000 0x0000000000000000000000000000000000000000 map[CBAT:1 CUSDC:1] 1 0}`)
+			if output != "Applied events accounts=1 modifiedAccounts=1\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Applied events accounts=1 modifiedAccounts=1\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}} sleeping...`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}} sleeping...`)
+			if output != "Snapshotted markets markets=2\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Snapshotted markets markets=2\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Updating AccountsBot: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updating AccountsBot: {"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
+			if output != "Borrows drift block=0 eventDriftBps=10000 eventTotalBorrows=0 onChainDriftBps=10000 onChainTotalBorrows=0 storedBorrows=1 token=CBAT\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Borrows drift block=0 eventDriftBps=10000 eventTotalBorrows=0 onChainDriftBps=10000 onChainTotalBorrows=0 storedBorrows=1 token=CBAT\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Updated AccountsBot: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updated AccountsBot: {"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
+			if output != "Borrows drift block=0 eventDriftBps=10000 eventTotalBorrows=0 onChainDriftBps=10000 onChainTotalBorrows=0 storedBorrows=1 token=CUSDC\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Borrows drift block=0 eventDriftBps=10000 eventTotalBorrows=0 onChainDriftBps=10000 onChainTotalBorrows=0 storedBorrows=1 token=CUSDC\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}} waking...`)
-			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}} waking...`)
+			if output != "Reconciled markets drifted=2 markets=2\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Reconciled markets drifted=2 markets=2\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			if output != "Rebalanced shards botType=AccountsBot version=1 workers=1\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Rebalanced shards botType=AccountsBot version=1 workers=1\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			if output != "Getting liquidity address=0x0000000000000000000000000000000000000000\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Getting liquidity address=0x0000000000000000000000000000000000000000\\n")
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Updating AccountsBot: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
+			if output != "Got liquidity address=0x0000000000000000000000000000000000000000 liquidity=1 shortfall=0\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Got liquidity address=0x0000000000000000000000000000000000000000 liquidity=1 shortfall=0\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			if output != "Checked accounts accounts=1 checked=1 shardVersion=1\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Checked accounts accounts=1 checked=1 shardVersion=1\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			if output != "Sleeping\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Sleeping\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			re = regexp.MustCompile(`^Updating state shardKey=[a-f\d]{24} version=\d+\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updating state shardKey=[a-f\d]{24} version=\d+`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Updated AccountsBot: {"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
+			re = regexp.MustCompile(`^Updated state shardKey=[a-f\d]{24} version=\d+\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updated state shardKey=[a-f\d]{24} version=\d+`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Initialized state for AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}}`)
+			if output != "Waking\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Waking\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			re = regexp.MustCompile(`^Updating state shardKey=[a-f\d]{24} version=\d+\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Initialized state for AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updating state shardKey=[a-f\d]{24} version=\d+`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Initializing accounts for AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}}`)
+			re = regexp.MustCompile(`^Updated state shardKey=[a-f\d]{24} version=\d+\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Initializing accounts for AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Updated state shardKey=[a-f\d]{24} version=\d+`)
 			}
 			output, _ = buf.ReadString('\n')
-			re = regexp.MustCompile(`Initialized 1 accounts for AccountsBot{{"ShardKey":"[a-f\d]{24}","BotType":"AccountsBot","LastWakeTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastSleepTime":"(-?(?:[1-9][0-9]*)?[0-9]{4})-(1[0-2]|0[1-9])-(3[01]|0[1-9]|[12][0-9])T(2[0-3]|[01][0-9]):([0-5][0-9]):([0-5][0-9])(.[0-9]+)?(Z)?([+-][0-2]\d:[0-5]\d)?","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}}`)
+			re = regexp.MustCompile(`^Initialized state shardKey=[a-f\d]{24} version=\d+\n$`)
 			if !re.MatchString(output) {
-				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Initialized 1 accounts for AccountsBot{{"ShardKey":"*","BotType":"AccountsBot","LastWakeTime":"*","LastSleepTime":"*","LastBorrowBlockByToken":{"CBAT":0,"CUSDC":0}}}`)
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, `Initialized state shardKey=[a-f\d]{24} version=\d+`)
+			}
+			output, _ = buf.ReadString('\n')
+			if output != "Initializing accounts\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Initializing accounts\\n")
+			}
+			output, _ = buf.ReadString('\n')
+			if output != "Initialized accounts accounts=1\n" {
+				t.Errorf("output, _ = buf.ReadString('\\n') = %v, want %v", output, "Initialized accounts accounts=1\\n")
 			}
 			err = cosmosClient.DeleteSQLContainer(ctx, botsCollectionName)
 			if err != nil {
@@ -347,3 +412,46 @@ func TestAccountsBot_Wake(t *testing.T) {
 		})
 	}
 }
+
+type batchingAppliedEventsService struct {
+	models.MockAppliedEventsService
+	batchSizes []int
+}
+
+func (service *batchingAppliedEventsService) GetAppliedEvents(ctx context.Context, ids []bson.ObjectId) (map[bson.ObjectId]bool, error) {
+	service.batchSizes = append(service.batchSizes, len(ids))
+	return service.MockAppliedEventsService.GetAppliedEvents(ctx, ids)
+}
+
+func TestAccountsBot_getAppliedEvents(t *testing.T) {
+	// Arrange
+	ids := []bson.ObjectId{}
+	appliedEvents := []*models.AppliedEvent{}
+	for i := 0; i < 2*appliedEventsBatchSize+1; i++ {
+		txHash := common.BigToHash(big.NewInt(int64(i))).Hex()
+		ids = append(ids, models.GetEventID(txHash, 0))
+		if i%2 == 0 {
+			appliedEvents = append(appliedEvents, &models.AppliedEvent{TxHash: txHash})
+		}
+	}
+	service := &batchingAppliedEventsService{}
+	if err := service.MarkEventsApplied(context.Background(), appliedEvents); err != nil {
+		t.Fatalf("service.MarkEventsApplied() error = %v", err)
+	}
+	bot := &AccountsBot{appliedEventsService: service}
+	// Act
+	got := bot.getAppliedEvents(context.Background(), ids)
+	// Assert
+	if len(got) != len(appliedEvents) {
+		t.Errorf("len(got) = %v, want %v", len(got), len(appliedEvents))
+	}
+	for i, id := range ids {
+		if got[id] != (i%2 == 0) {
+			t.Errorf("got[%v] = %v, want %v", i, got[id], i%2 == 0)
+		}
+	}
+	wantBatchSizes := []int{appliedEventsBatchSize, appliedEventsBatchSize, 1}
+	if !reflect.DeepEqual(service.batchSizes, wantBatchSizes) {
+		t.Errorf("service.batchSizes = %v, want %v", service.batchSizes, wantBatchSizes)
+	}
+}
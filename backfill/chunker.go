@@ -0,0 +1,116 @@
+package backfill
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+// rangeErrors are fragments of the errors nodes return for log queries spanning too many blocks or results.
+var rangeErrors = []string{
+	"more than",
+	"too many",
+	"too large",
+	"limit exceeded",
+	"response size",
+	"block range",
+	"timeout",
+	"timed out",
+	"deadline exceeded",
+}
+
+// Chunker splits a block range into chunks sized to what the node accepts.
+type Chunker interface {
+	Filter(ctx context.Context, start uint64, end uint64, query func(opts *bind.FilterOpts) error, checkpoint func(end uint64) error) error
+	GetRangeSize() uint64
+}
+
+// AdaptiveChunker halves the range of a chunk the node rejects and doubles it after a chunk succeeds.
+type AdaptiveChunker struct {
+	logger       logging.Logger
+	rangeSize    uint64
+	minRangeSize uint64
+	maxRangeSize uint64
+}
+
+// NewAdaptiveChunker creates a new Chunker.
+func NewAdaptiveChunker(logger logging.Logger, initialRangeSize uint64, minRangeSize uint64, maxRangeSize uint64) Chunker {
+	if minRangeSize == 0 {
+		minRangeSize = 1
+	}
+	if maxRangeSize < minRangeSize {
+		maxRangeSize = minRangeSize
+	}
+	return &AdaptiveChunker{
+		logger:       logger,
+		rangeSize:    clamp(initialRangeSize, minRangeSize, maxRangeSize),
+		minRangeSize: minRangeSize,
+		maxRangeSize: maxRangeSize,
+	}
+}
+
+// IsRangeError returns whether the node rejected a log query for its range or number of results.
+func IsRangeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, rangeError := range rangeErrors {
+		if strings.Contains(message, rangeError) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter queries the inclusive block range chunk by chunk, calling checkpoint after each chunk is queried.
+// A chunk is retried with a smaller range when the query returns a range error.
+func (chunker *AdaptiveChunker) Filter(ctx context.Context, start uint64, end uint64, query func(opts *bind.FilterOpts) error, checkpoint func(end uint64) error) error {
+	for from := start; from <= end; {
+		to := end
+		if end-from >= chunker.rangeSize {
+			to = from + chunker.rangeSize - 1
+		}
+		err := query(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
+		if err != nil {
+			if !IsRangeError(err) || chunker.rangeSize <= chunker.minRangeSize {
+				return err
+			}
+			chunker.rangeSize = clamp(chunker.rangeSize/2, chunker.minRangeSize, chunker.maxRangeSize)
+			chunker.logger.Warn("Shrinking range after error", logging.Fields{
+				logging.BlockField: from,
+				"rangeSize":        chunker.rangeSize,
+				logging.ErrorField: err,
+			})
+			continue
+		}
+		err = checkpoint(to)
+		if err != nil {
+			return err
+		}
+		chunker.rangeSize = clamp(chunker.rangeSize*2, chunker.minRangeSize, chunker.maxRangeSize)
+		if to == end {
+			break
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// GetRangeSize returns the number of blocks of the next chunk.
+func (chunker *AdaptiveChunker) GetRangeSize() uint64 {
+	return chunker.rangeSize
+}
+
+func clamp(value uint64, min uint64, max uint64) uint64 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package backfill
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+func TestAdaptiveChunker_Filter(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name            string
+		start           uint64
+		end             uint64
+		maxResultRange  uint64
+		queryErr        error
+		wantCheckpoints []uint64
+		wantRangeSize   uint64
+		wantErr         bool
+	}{
+		{
+			name:            "Should grow range on success.",
+			start:           0,
+			end:             99,
+			maxResultRange:  100,
+			wantCheckpoints: []uint64{9, 29, 69, 99},
+			wantRangeSize:   64,
+		},
+		{
+			name:            "Should shrink range on too many results.",
+			start:           10,
+			end:             29,
+			maxResultRange:  5,
+			wantCheckpoints: []uint64{14, 19, 24, 29},
+			wantRangeSize:   20,
+		},
+		{
+			name:            "Should return range error at minimum range.",
+			start:           0,
+			end:             10,
+			maxResultRange:  1,
+			wantCheckpoints: []uint64{},
+			wantRangeSize:   2,
+			wantErr:         true,
+		},
+		{
+			name:            "Should return other errors.",
+			start:           0,
+			end:             10,
+			maxResultRange:  100,
+			queryErr:        errors.New("connection refused"),
+			wantCheckpoints: []uint64{},
+			wantRangeSize:   10,
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			chunker := NewAdaptiveChunker(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), 10, 2, 64)
+			checkpoints := []uint64{}
+			query := func(opts *bind.FilterOpts) error {
+				if tt.queryErr != nil {
+					return tt.queryErr
+				}
+				if *opts.End-opts.Start+1 > tt.maxResultRange {
+					return errors.New("query returned more than 10000 results")
+				}
+				return nil
+			}
+			checkpoint := func(end uint64) error {
+				checkpoints = append(checkpoints, end)
+				return nil
+			}
+			// Act
+			err := chunker.Filter(context.Background(), tt.start, tt.end, query, checkpoint)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("chunker.Filter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(checkpoints, tt.wantCheckpoints) {
+				t.Errorf("checkpoints = %v, want %v", checkpoints, tt.wantCheckpoints)
+			}
+			if chunker.GetRangeSize() != tt.wantRangeSize {
+				t.Errorf("chunker.GetRangeSize() = %v, want %v", chunker.GetRangeSize(), tt.wantRangeSize)
+			}
+		})
+	}
+}
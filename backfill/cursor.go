@@ -0,0 +1,19 @@
+package backfill
+
+// Cursor is the position of the last event applied from the chain.
+type Cursor struct {
+	BlockNumber uint64
+	LogIndex    uint
+}
+
+// IsBefore returns whether the event at the block and log index comes after the cursor.
+// A nil cursor is before every event.
+func (cursor *Cursor) IsBefore(blockNumber uint64, logIndex uint) bool {
+	if cursor == nil {
+		return true
+	}
+	if blockNumber != cursor.BlockNumber {
+		return blockNumber > cursor.BlockNumber
+	}
+	return logIndex > cursor.LogIndex
+}
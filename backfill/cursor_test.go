@@ -0,0 +1,67 @@
+package backfill
+
+import "testing"
+
+func TestCursor_IsBefore(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name        string
+		cursor      *Cursor
+		blockNumber uint64
+		logIndex    uint
+		want        bool
+	}{
+		{
+			name:        "Should be before every event when nil.",
+			cursor:      nil,
+			blockNumber: 0,
+			logIndex:    0,
+			want:        true,
+		},
+		{
+			name:        "Should not be before the applied event.",
+			cursor:      &Cursor{BlockNumber: 10, LogIndex: 2},
+			blockNumber: 10,
+			logIndex:    2,
+			want:        false,
+		},
+		{
+			name:        "Should not be before earlier events of the block.",
+			cursor:      &Cursor{BlockNumber: 10, LogIndex: 2},
+			blockNumber: 10,
+			logIndex:    1,
+			want:        false,
+		},
+		{
+			name:        "Should be before later events of the block.",
+			cursor:      &Cursor{BlockNumber: 10, LogIndex: 2},
+			blockNumber: 10,
+			logIndex:    3,
+			want:        true,
+		},
+		{
+			name:        "Should be before events of later blocks.",
+			cursor:      &Cursor{BlockNumber: 10, LogIndex: 2},
+			blockNumber: 11,
+			logIndex:    0,
+			want:        true,
+		},
+		{
+			name:        "Should not be before events of earlier blocks.",
+			cursor:      &Cursor{BlockNumber: 10, LogIndex: 2},
+			blockNumber: 9,
+			logIndex:    5,
+			want:        false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got := tt.cursor.IsBefore(tt.blockNumber, tt.logIndex)
+			// Assert
+			if got != tt.want {
+				t.Errorf("cursor.IsBefore() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
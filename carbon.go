@@ -2,25 +2,125 @@ package main
 
 import (
 	"context"
-	"log"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
 
 	"github.com/l3a0/carbon/accountsbot"
+	"github.com/l3a0/carbon/backfill"
 	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/inventory"
+	"github.com/l3a0/carbon/liquidation"
+	"github.com/l3a0/carbon/liquidatorbot"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/metrics"
 	"github.com/l3a0/carbon/models"
+	"github.com/l3a0/carbon/reconciliation"
+	"github.com/l3a0/carbon/resync"
+	"github.com/l3a0/carbon/sharding"
+	"github.com/l3a0/carbon/signer"
+	"github.com/l3a0/carbon/supervisor"
+	"github.com/l3a0/carbon/transactions"
 )
 
 func main() {
-	ethClient, err := ethclient.Dial("/home/l3a0/.ethereum/geth.ipc")
-	// ethClient, err := ethclient.Dial("https://mainnet.infura.io")
+	endpoint := flag.String("endpoint", "/home/l3a0/.ethereum/geth.ipc", "Ethereum node endpoint")
+	networkName := flag.String("network", "", "expected network name, e.g. mainnet (defaults to the connected chain's network)")
+	networksFile := flag.String("networks", "", "JSON file with additional networks, e.g. testnets and dev chains")
+	dryRun := flag.Bool("dry-run", false, "report resync differences without rewriting accounts")
+	driftThreshold := flag.Uint64("drift-threshold", 100, "basis points of drift between stored and reported total borrows to flag")
+	blockRange := flag.Uint64("block-range", 10000, "initial number of blocks per event query")
+	maxBlockRange := flag.Uint64("max-block-range", 100000, "maximum number of blocks per event query")
+	instanceID := flag.String("instance-id", defaultInstanceID(), "ID of this instance in leader election")
+	leaseDuration := flag.Duration("lease-duration", 5*time.Minute, "duration of the leader lease, renewed while working")
+	interval := flag.Duration("interval", 0, "time between work cycles (0 runs a single cycle)")
+	heartbeatTimeout := flag.Duration("heartbeat-timeout", 15*time.Minute, "time without a heartbeat before a worker loses its shard (should exceed -interval)")
+	keystorePath := flag.String("keystore", "", "keystore file of the liquidation account (enables the liquidator)")
+	passphraseFile := flag.String("passphrase-file", "", "file containing the keystore passphrase (defaults to $CARBON_PASSPHRASE)")
+	externalSigner := flag.String("external-signer", "", "JSON-RPC endpoint of an external signer of the liquidation account (enables the liquidator)")
+	maxGasPrice := flag.Int64("max-gas-price", 500, "maximum gas price of liquidations in gwei")
+	logLevelName := flag.String("log-level", "info", "minimum level of log messages: debug, info, warn or error")
+	logFormatName := flag.String("log-format", "json", "encoding of log messages: json or text")
+	httpAddress := flag.String("http-address", "", "address serving the health of the bots at /health and metrics at /metrics, e.g. :8080")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %v [flags] [run | account <address> <block> | resync <block> [address...]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	logLevel, err := logging.ParseLevel(*logLevelName)
+	if err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	logFormat, err := logging.ParseFormat(*logFormatName)
+	if err != nil {
+		fmt.Fprintln(flag.CommandLine.Output(), err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	logger := logging.NewLogger(os.Stderr, logLevel, logFormat).With(logging.Fields{"instance": *instanceID})
+	client, err := ethclient.Dial(*endpoint)
+	// client, err := ethclient.Dial("https://mainnet.infura.io")
 	if err != nil {
-		log.Fatal(err)
+		logger.Fatalf("%v", err)
+	}
+	metricsRegistry := metrics.NewRegistry()
+	carbonMetrics := metrics.NewMetrics(metricsRegistry)
+	ethClient := metrics.NewInstrumentedEthClient(client, carbonMetrics)
+	logger.Info("Connected to the node", nil)
+	networkRegistry := contracts.NewNetworkRegistry()
+	if *networksFile != "" {
+		err = networkRegistry.LoadFile(*networksFile)
+		if err != nil {
+			logger.Fatalf("%v", err)
+		}
+	}
+	network, err := networkRegistry.SelectNetwork(context.Background(), ethClient, *networkName)
+	if err != nil {
+		logger.Fatalf("Refusing to start: %v", err)
+	}
+	logger.Info("Connected to network", logging.Fields{"network": network.Name, "chainID": network.ChainID})
+	tokenContracts, err := contracts.NewTokenContracts(
+		ethClient,
+		logging.Component(logger, "TokenFactory"),
+		network,
+		contracts.NewToken)
+	if err != nil {
+		logger.Panicf("%v", err)
+	}
+	comptrollerService, err := models.NewComptrollerService(
+		logging.Component(logger, "ComptrollerService"),
+		ethClient,
+		network.ComptrollerAddress,
+		carbonMetrics.PausedActions)
+	if err != nil {
+		logger.Panicf("%v", err)
+	}
+	ctx := context.Background()
+	accountsHistory := models.NewArchiveAccountsHistory(
+		logging.Component(logger, "AccountsHistory"),
+		tokenContracts,
+		comptrollerService)
+	switch flag.Arg(0) {
+	case "account":
+		runAccountCommand(ctx, logger, accountsHistory, flag.Args()[1:])
+		return
+	case "", "run", "resync":
+	default:
+		flag.Usage()
+		os.Exit(2)
 	}
-	log.Printf("we have a connection\n")
 	cosmosClient := models.NewCosmosService(
-		log.New(os.Stderr, "CosmosClient | ", log.LstdFlags),
+		logging.Component(logger, "CosmosClient"),
 		models.CosmosConfiguration{
 			SubscriptionID:    "6951e94d-0947-4c5e-b865-f864609da246",
 			CloudName:         "AzurePublicCloud",
@@ -28,48 +128,308 @@ func main() {
 			AccountName:       "bao-blockchain",
 		})
 	cosmosClient.Connect()
-	ctx := context.Background()
 	session, err := cosmosClient.GetSession(ctx)
 	if err != nil {
-		log.Panicf("cannot get mongoDB session: %v", err)
+		logger.Panicf("cannot get mongoDB session: %v", err)
 	}
 	defer session.Close()
-	documentDbCollectionFactory := models.NewCosmosCollectionFactory(
-		log.New(os.Stderr, "DocumentDbCollectionFactory | ", log.LstdFlags),
-		cosmosClient,
-		session)
+	documentDbCollectionFactory := metrics.NewInstrumentedCollectionFactory(
+		models.NewCosmosCollectionFactory(
+			logging.Component(logger, "DocumentDbCollectionFactory"),
+			cosmosClient,
+			session),
+		carbonMetrics)
 	var accountsBot accountsbot.Bot
-	tokenContracts, err := contracts.NewTokenContracts(
-		ethClient,
-		log.New(os.Stderr, "TokenFactory | ", log.LstdFlags),
-		contracts.NewToken)
-	if err != nil {
-		log.Panic(err)
-	}
 	botsCollectionName := "bots"
 	accountsCollectionName := "accounts"
-	accountsService := models.NewCosmosAccountsService(
-		log.New(os.Stderr, "CosmosAccountsService | ", log.LstdFlags),
-		documentDbCollectionFactory,
-		accountsCollectionName)
+	accountEventsCollectionName := "account_events"
+	marketsCollectionName := "markets"
+	appliedEventsCollectionName := "applied_events"
+	transactionsCollectionName := "transactions"
+	accountsService := models.NewArchiveAccountsService(
+		models.NewCosmosAccountsService(
+			logging.Component(logger, "CosmosAccountsService"),
+			documentDbCollectionFactory,
+			accountsCollectionName),
+		accountsHistory)
 	botsService := models.NewCosmosBotsService(
-		log.New(os.Stderr, "DocumentDbCollectionFactory | ", log.LstdFlags),
+		logging.Component(logger, "DocumentDbCollectionFactory"),
 		documentDbCollectionFactory,
 		botsCollectionName)
-	comptrollerService := models.NewComptrollerService(
-		log.New(os.Stderr, "ComptrollerService | ", log.LstdFlags),
+	accountEventsService := models.NewCosmosAccountEventsService(
+		logging.Component(logger, "CosmosAccountEventsService"),
+		documentDbCollectionFactory,
+		accountEventsCollectionName)
+	appliedEventsService := models.NewCosmosAppliedEventsService(
+		logging.Component(logger, "CosmosAppliedEventsService"),
+		documentDbCollectionFactory,
+		appliedEventsCollectionName)
+	interestAccrual := models.NewInterestAccrual(
+		logging.Component(logger, "InterestAccrual"),
+		tokenContracts,
 		ethClient)
+	marketsService := models.NewCosmosMarketsService(
+		logging.Component(logger, "CosmosMarketsService"),
+		documentDbCollectionFactory,
+		marketsCollectionName)
+	if flag.Arg(0) == "resync" {
+		resyncer := resync.NewAccountsResyncer(
+			logging.Component(logger, "Resyncer"),
+			accountsService,
+			accountsHistory,
+			interestAccrual)
+		runResyncCommand(ctx, logger, resyncer, flag.Args()[1:], *dryRun)
+		return
+	}
+	reconciler := reconciliation.NewBorrowsReconciler(tokenContracts, interestAccrual, *driftThreshold)
+	logFetcher, err := contracts.NewTokenLogFetcher(ethClient, tokenContracts)
+	if err != nil {
+		logger.Fatalf("Failed to create log fetcher: %v", err)
+	}
 	accountsBot = accountsbot.NewAccountsBot(
 		tokenContracts,
-		log.New(os.Stderr, "AccountsBot | ", log.LstdFlags),
+		logging.Component(logger, "AccountsBot"),
 		accountsService,
 		botsService,
-		comptrollerService)
-	status := make(chan int)
-	go accountsBot.Wake(ctx, status)
-	log.Printf("Wake status: %v\n", <-status)
-	go accountsBot.Work(ctx, status)
-	log.Printf("Work status: %v\n", <-status)
-	go accountsBot.Sleep(ctx, status)
-	log.Printf("Sleep status: %v\n", <-status)
+		comptrollerService,
+		accountEventsService,
+		interestAccrual,
+		marketsService,
+		reconciler,
+		ethClient,
+		backfill.NewAdaptiveChunker(logging.Component(logger, "Chunker"), *blockRange, 1, *maxBlockRange),
+		logFetcher,
+		appliedEventsService,
+		sharding.NewShardCoordinator(
+			logging.Component(logger, "ShardCoordinator"),
+			botsService,
+			accountsbot.BotType,
+			*instanceID,
+			*heartbeatTimeout,
+			sharding.DefaultReplicas,
+			nil),
+		carbonMetrics)
+	botSupervisor := supervisor.NewBotSupervisor(logging.Component(logger, "Supervisor"), 0, nil)
+	botSupervisor.Register(accountsbot.BotType, accountsBot)
+	bots := []accountsbot.Bot{accountsBot}
+	if *keystorePath != "" || *externalSigner != "" {
+		liquidationSigner, err := signer.NewSigner(
+			logging.Component(logger, "Signer"),
+			signer.Configuration{
+				KeystorePath:     *keystorePath,
+				PassphraseFile:   *passphraseFile,
+				PassphraseEnv:    "CARBON_PASSPHRASE",
+				ExternalEndpoint: *externalSigner,
+				ChainID:          new(big.Int).SetUint64(network.ChainID),
+			})
+		if err != nil {
+			logger.Fatalf("Failed to create signer: %v", err)
+		}
+		txManager := transactions.NewTxManager(
+			logging.Component(logger, "TxManager"),
+			ethClient,
+			liquidationSigner.GetTransactOpts(),
+			transactions.NewNodeGasPricer(ethClient, 100, 10, new(big.Int).Mul(big.NewInt(*maxGasPrice), big.NewInt(1000000000))),
+			models.NewCosmosTransactionsService(
+				logging.Component(logger, "CosmosTransactionsService"),
+				documentDbCollectionFactory,
+				transactionsCollectionName),
+			transactions.Configuration{
+				ReplaceAfter:    2 * time.Minute,
+				MaxReplacements: 5,
+			})
+		liquidatorBot := liquidatorbot.NewLiquidatorBot(
+			tokenContracts,
+			logging.Component(logger, "LiquidatorBot"),
+			accountsService,
+			botsService,
+			comptrollerService,
+			liquidation.NewRepaySizer(
+				logging.Component(logger, "RepaySizer"),
+				tokenContracts,
+				comptrollerService,
+				ethClient),
+			inventory.NewTokenInventory(
+				logging.Component(logger, "Inventory"),
+				tokenContracts,
+				ethClient,
+				func(address common.Address) (contracts.UnderlyingToken, error) {
+					return contracts.NewUnderlyingToken(address, ethClient)
+				},
+				txManager,
+				liquidationSigner.GetAddress(),
+				false),
+			txManager)
+		botSupervisor.Register(liquidatorbot.BotType, liquidatorBot)
+		bots = append(bots, liquidatorBot)
+	}
+	elector := models.NewLeaseLeaderElector(
+		logging.Component(logger, "LeaderElector"),
+		models.NewCosmosLeaseService(
+			logging.Component(logger, "CosmosLeaseService"),
+			documentDbCollectionFactory,
+			botsCollectionName),
+		accountsbot.BotType,
+		*instanceID,
+		*leaseDuration,
+		nil)
+	if *httpAddress != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/health", botSupervisor)
+		mux.Handle("/metrics", metricsRegistry)
+		go func() {
+			logger.Error("HTTP server stopped", logging.Fields{logging.ErrorField: http.ListenAndServe(*httpAddress, mux)})
+		}()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		<-interrupts
+		logger.Info("Shutting down", nil)
+		cancel()
+	}()
+	for {
+		runLeaderCycle(ctx, logger, botSupervisor, bots, elector, *leaseDuration)
+		if *interval == 0 || ctx.Err() != nil {
+			// a later run may start on another instance.
+			err = elector.ResignLeadership(context.Background())
+			if err != nil {
+				logger.Warn("Failed to resign leadership", logging.Fields{logging.ErrorField: err})
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(*interval):
+		}
+	}
+}
+
+// defaultInstanceID identifies the instance by host and process.
+func defaultInstanceID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%v-%v", hostname, os.Getpid())
+}
+
+// runLeaderCycle runs a cycle of the supervised bots if this instance is the leader, renewing the lease meanwhile.
+// Other instances only check the accounts of their shard.
+func runLeaderCycle(ctx context.Context, logger logging.Logger, botSupervisor supervisor.Supervisor, bots []accountsbot.Bot, elector models.LeaderElector, leaseDuration time.Duration) {
+	leader, err := elector.AcquireLeadership(ctx)
+	if err != nil {
+		logger.Warn("Failed to acquire leadership", logging.Fields{logging.ErrorField: err})
+		return
+	}
+	if !leader {
+		logger.Info("Standing by as a shard worker", logging.Fields{"owner": elector.GetOwnerID()})
+		for _, bot := range bots {
+			if worker, ok := bot.(accountsbot.ShardWorker); ok {
+				status := make(chan int)
+				go worker.CheckShard(ctx, status)
+				logger.Info("Checked shard", logging.Fields{"status": <-status})
+			}
+		}
+		return
+	}
+	cycleCtx, cancelCycle := context.WithCancel(ctx)
+	defer cancelCycle()
+	go renewLeadership(cycleCtx, logger, elector, leaseDuration, cancelCycle)
+	botSupervisor.Run(cycleCtx)
+	for _, health := range botSupervisor.GetHealth() {
+		logger.Info("Bot health", logging.Fields{"bot": health.Name, "status": health.Status})
+	}
+}
+
+// renewLeadership renews the lease until the context is done, cancelling the cycle once another instance
+// holds the lease or the lease would expire before the next renewal. Failed renewals are retried until then.
+func renewLeadership(ctx context.Context, logger logging.Logger, elector models.LeaderElector, leaseDuration time.Duration, cancelCycle context.CancelFunc) {
+	interval := leaseDuration / 3
+	expiry := time.Now().Add(leaseDuration)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed := time.Now()
+			leader, err := elector.AcquireLeadership(ctx)
+			if err == nil && leader {
+				expiry = renewed.Add(leaseDuration)
+				continue
+			}
+			if err == nil {
+				// another instance holds the lease and may be working, so this one must stop writing.
+				logger.Error("Lost leadership while working", nil)
+				cancelCycle()
+				return
+			}
+			if time.Now().Add(interval).After(expiry) {
+				logger.Error("Failed to renew leadership before the lease expiry", logging.Fields{logging.ErrorField: err})
+				cancelCycle()
+				return
+			}
+			logger.Warn("Failed to renew leadership", logging.Fields{logging.ErrorField: err})
+		}
+	}
+}
+
+// runAccountCommand prints an account's state as of a block.
+func runAccountCommand(ctx context.Context, logger logging.Logger, accountsHistory models.AccountsHistory, args []string) {
+	if len(args) != 2 || !common.IsHexAddress(args[0]) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	blockNumber, err := strconv.ParseUint(args[1], 10, 64)
+	if err != nil {
+		logger.Fatalf("Invalid block number %v: %v", args[1], err)
+	}
+	account, err := accountsHistory.GetAccountAt(ctx, common.HexToAddress(args[0]), blockNumber)
+	if err != nil {
+		logger.Fatalf("Failed to get account %v at block # %v: %v", args[0], blockNumber, err)
+	}
+	fmt.Printf("Account:    %v\n", account.Address)
+	fmt.Printf("Block:      %v\n", blockNumber)
+	fmt.Printf("Borrows:    %v\n", account.Borrows)
+	fmt.Printf("Collateral: %v\n", account.Collateral)
+	fmt.Printf("Markets:    %v\n", account.Markets)
+	fmt.Printf("Liquidity:  %v\n", account.Liquidity)
+	fmt.Printf("Shortfall:  %v\n", account.Shortfall)
+}
+
+// runResyncCommand rebuilds the stored accounts, or the listed accounts, from the chain at a block.
+func runResyncCommand(ctx context.Context, logger logging.Logger, resyncer resync.Resyncer, args []string, dryRun bool) {
+	if len(args) < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	blockNumber, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		logger.Fatalf("Invalid block number %v: %v", args[0], err)
+	}
+	addresses := []common.Address{}
+	for _, arg := range args[1:] {
+		if !common.IsHexAddress(arg) {
+			logger.Fatalf("Invalid address %v", arg)
+		}
+		addresses = append(addresses, common.HexToAddress(arg))
+	}
+	reports, err := resyncer.Resync(ctx, addresses, blockNumber, dryRun)
+	for _, report := range reports {
+		if len(report.Differences) == 0 {
+			continue
+		}
+		fmt.Printf("Account %v (rewritten: %v):\n", report.Address, report.Rewritten)
+		for _, difference := range report.Differences {
+			fmt.Printf("  %v\n", difference)
+		}
+	}
+	fmt.Printf("Resynced %v accounts at block # %v\n", len(reports), blockNumber)
+	if err != nil {
+		logger.Fatalf("Failed to resync accounts: %v", err)
+	}
 }
@@ -1,11 +1,11 @@
 package contracts
 
 import (
-	"log"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // GetBorrower returns the borrower.
@@ -33,18 +33,291 @@ func (b *CBATBorrow) GetBlockNumber() uint64 {
 	return b.Raw.BlockNumber
 }
 
+// GetTxHash returns the hash of the transaction emitting the event.
+func (b *CBATBorrow) GetTxHash() common.Hash {
+	return b.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (b *CBATBorrow) GetLogIndex() uint {
+	return b.Raw.Index
+}
+
+// GetBorrowIndex returns the borrow index after the accrual.
+func (a *CBATAccrueInterest) GetBorrowIndex() *big.Int {
+	return a.BorrowIndex
+}
+
+// GetTotalBorrows returns the contract borrow amount after the accrual.
+func (a *CBATAccrueInterest) GetTotalBorrows() *big.Int {
+	return a.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (a *CBATAccrueInterest) GetBlockNumber() uint64 {
+	return a.Raw.BlockNumber
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (a *CBATAccrueInterest) GetLogIndex() uint {
+	return a.Raw.Index
+}
+
 // FilterBorrowEvents returns the borrow events.
 func (b *CBATFilterer) FilterBorrowEvents(opts *bind.FilterOpts) (TokenBorrowIterator, error) {
 	iter, err := b.FilterBorrow(opts)
-
 	if err != nil {
-		log.Fatalf("Failed to call FilterBorrow: %#v", err)
+		return nil, err
 	}
-
-	return iter, err
+	return iter, nil
 }
 
 // GetEvent returns the Event containing the contract specifics and raw log.
 func (b *CBATBorrowIterator) GetEvent() TokenBorrow {
 	return b.Event
 }
+
+// FilterAccrueInterestEvents returns the AccrueInterest events.
+func (b *CBATFilterer) FilterAccrueInterestEvents(opts *bind.FilterOpts) (TokenAccrueInterestIterator, error) {
+	iter, err := b.FilterAccrueInterest(opts)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *CBATAccrueInterestIterator) GetEvent() TokenAccrueInterest {
+	return i.Event
+}
+
+// GetPayer returns the payer.
+func (r *CBATRepayBorrow) GetPayer() common.Address {
+	return r.Payer
+}
+
+// GetBorrower returns the borrower.
+func (r *CBATRepayBorrow) GetBorrower() common.Address {
+	return r.Borrower
+}
+
+// GetRepayAmount returns the repay amount.
+func (r *CBATRepayBorrow) GetRepayAmount() *big.Int {
+	return r.RepayAmount
+}
+
+// GetAccountBorrows returns the account borrow amount after the repayment.
+func (r *CBATRepayBorrow) GetAccountBorrows() *big.Int {
+	return r.AccountBorrows
+}
+
+// GetTotalBorrows returns the contract borrow amount after the repayment.
+func (r *CBATRepayBorrow) GetTotalBorrows() *big.Int {
+	return r.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (r *CBATRepayBorrow) GetBlockNumber() uint64 {
+	return r.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (r *CBATRepayBorrow) GetTxHash() common.Hash {
+	return r.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (r *CBATRepayBorrow) GetLogIndex() uint {
+	return r.Raw.Index
+}
+
+// GetLiquidator returns the liquidator.
+func (l *CBATLiquidateBorrow) GetLiquidator() common.Address {
+	return l.Liquidator
+}
+
+// GetBorrower returns the borrower.
+func (l *CBATLiquidateBorrow) GetBorrower() common.Address {
+	return l.Borrower
+}
+
+// GetRepayAmount returns the amount repaid by the liquidator.
+func (l *CBATLiquidateBorrow) GetRepayAmount() *big.Int {
+	return l.RepayAmount
+}
+
+// GetCTokenCollateral returns the address of the seized collateral market.
+func (l *CBATLiquidateBorrow) GetCTokenCollateral() common.Address {
+	return l.CTokenCollateral
+}
+
+// GetSeizeTokens returns the amount of collateral cTokens seized.
+func (l *CBATLiquidateBorrow) GetSeizeTokens() *big.Int {
+	return l.SeizeTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (l *CBATLiquidateBorrow) GetBlockNumber() uint64 {
+	return l.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (l *CBATLiquidateBorrow) GetTxHash() common.Hash {
+	return l.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (l *CBATLiquidateBorrow) GetLogIndex() uint {
+	return l.Raw.Index
+}
+
+// GetMinter returns the minter.
+func (m *CBATMint) GetMinter() common.Address {
+	return m.Minter
+}
+
+// GetMintAmount returns the underlying amount supplied.
+func (m *CBATMint) GetMintAmount() *big.Int {
+	return m.MintAmount
+}
+
+// GetMintTokens returns the amount of cTokens minted.
+func (m *CBATMint) GetMintTokens() *big.Int {
+	return m.MintTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (m *CBATMint) GetBlockNumber() uint64 {
+	return m.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (m *CBATMint) GetTxHash() common.Hash {
+	return m.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (m *CBATMint) GetLogIndex() uint {
+	return m.Raw.Index
+}
+
+// GetRedeemer returns the redeemer.
+func (r *CBATRedeem) GetRedeemer() common.Address {
+	return r.Redeemer
+}
+
+// GetRedeemAmount returns the underlying amount redeemed.
+func (r *CBATRedeem) GetRedeemAmount() *big.Int {
+	return r.RedeemAmount
+}
+
+// GetRedeemTokens returns the amount of cTokens redeemed.
+func (r *CBATRedeem) GetRedeemTokens() *big.Int {
+	return r.RedeemTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (r *CBATRedeem) GetBlockNumber() uint64 {
+	return r.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (r *CBATRedeem) GetTxHash() common.Hash {
+	return r.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (r *CBATRedeem) GetLogIndex() uint {
+	return r.Raw.Index
+}
+
+// GetFrom returns the sender.
+func (t *CBATTransfer) GetFrom() common.Address {
+	return t.From
+}
+
+// GetTo returns the recipient.
+func (t *CBATTransfer) GetTo() common.Address {
+	return t.To
+}
+
+// GetAmount returns the amount of cTokens transferred.
+func (t *CBATTransfer) GetAmount() *big.Int {
+	return t.Amount
+}
+
+// GetBlockNumber returns the block number of the event.
+func (t *CBATTransfer) GetBlockNumber() uint64 {
+	return t.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (t *CBATTransfer) GetTxHash() common.Hash {
+	return t.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (t *CBATTransfer) GetLogIndex() uint {
+	return t.Raw.Index
+}
+
+// ParseBorrowEvent parses a Borrow event from the log.
+func (b *CBATFilterer) ParseBorrowEvent(log types.Log) (TokenBorrow, error) {
+	event, err := b.ParseBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	// the generated parser leaves the raw log unset, unlike the iterators.
+	event.Raw = log
+	return event, nil
+}
+
+// ParseRepayBorrowEvent parses a RepayBorrow event from the log.
+func (b *CBATFilterer) ParseRepayBorrowEvent(log types.Log) (TokenRepayBorrow, error) {
+	event, err := b.ParseRepayBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseLiquidateBorrowEvent parses a LiquidateBorrow event from the log.
+func (b *CBATFilterer) ParseLiquidateBorrowEvent(log types.Log) (TokenLiquidateBorrow, error) {
+	event, err := b.ParseLiquidateBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseMintEvent parses a Mint event from the log.
+func (b *CBATFilterer) ParseMintEvent(log types.Log) (TokenMint, error) {
+	event, err := b.ParseMint(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseRedeemEvent parses a Redeem event from the log.
+func (b *CBATFilterer) ParseRedeemEvent(log types.Log) (TokenRedeem, error) {
+	event, err := b.ParseRedeem(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseTransferEvent parses a Transfer event from the log.
+func (b *CBATFilterer) ParseTransferEvent(log types.Log) (TokenTransfer, error) {
+	event, err := b.ParseTransfer(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -1,11 +1,11 @@
 package contracts
 
 import (
-	"log"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // GetBorrower returns the borrower.
@@ -33,18 +33,291 @@ func (b *CDAIBorrow) GetBlockNumber() uint64 {
 	return b.Raw.BlockNumber
 }
 
+// GetTxHash returns the hash of the transaction emitting the event.
+func (b *CDAIBorrow) GetTxHash() common.Hash {
+	return b.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (b *CDAIBorrow) GetLogIndex() uint {
+	return b.Raw.Index
+}
+
+// GetBorrowIndex returns the borrow index after the accrual.
+func (a *CDAIAccrueInterest) GetBorrowIndex() *big.Int {
+	return a.BorrowIndex
+}
+
+// GetTotalBorrows returns the contract borrow amount after the accrual.
+func (a *CDAIAccrueInterest) GetTotalBorrows() *big.Int {
+	return a.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (a *CDAIAccrueInterest) GetBlockNumber() uint64 {
+	return a.Raw.BlockNumber
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (a *CDAIAccrueInterest) GetLogIndex() uint {
+	return a.Raw.Index
+}
+
 // FilterBorrowEvents returns the borrow events.
 func (b *CDAIFilterer) FilterBorrowEvents(opts *bind.FilterOpts) (TokenBorrowIterator, error) {
 	iter, err := b.FilterBorrow(opts)
-
 	if err != nil {
-		log.Fatalf("Failed to call FilterBorrow: %#v", err)
+		return nil, err
 	}
-
-	return iter, err
+	return iter, nil
 }
 
 // GetEvent returns the Event containing the contract specifics and raw log.
 func (b *CDAIBorrowIterator) GetEvent() TokenBorrow {
 	return b.Event
 }
+
+// FilterAccrueInterestEvents returns the AccrueInterest events.
+func (b *CDAIFilterer) FilterAccrueInterestEvents(opts *bind.FilterOpts) (TokenAccrueInterestIterator, error) {
+	iter, err := b.FilterAccrueInterest(opts)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *CDAIAccrueInterestIterator) GetEvent() TokenAccrueInterest {
+	return i.Event
+}
+
+// GetPayer returns the payer.
+func (r *CDAIRepayBorrow) GetPayer() common.Address {
+	return r.Payer
+}
+
+// GetBorrower returns the borrower.
+func (r *CDAIRepayBorrow) GetBorrower() common.Address {
+	return r.Borrower
+}
+
+// GetRepayAmount returns the repay amount.
+func (r *CDAIRepayBorrow) GetRepayAmount() *big.Int {
+	return r.RepayAmount
+}
+
+// GetAccountBorrows returns the account borrow amount after the repayment.
+func (r *CDAIRepayBorrow) GetAccountBorrows() *big.Int {
+	return r.AccountBorrows
+}
+
+// GetTotalBorrows returns the contract borrow amount after the repayment.
+func (r *CDAIRepayBorrow) GetTotalBorrows() *big.Int {
+	return r.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (r *CDAIRepayBorrow) GetBlockNumber() uint64 {
+	return r.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (r *CDAIRepayBorrow) GetTxHash() common.Hash {
+	return r.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (r *CDAIRepayBorrow) GetLogIndex() uint {
+	return r.Raw.Index
+}
+
+// GetLiquidator returns the liquidator.
+func (l *CDAILiquidateBorrow) GetLiquidator() common.Address {
+	return l.Liquidator
+}
+
+// GetBorrower returns the borrower.
+func (l *CDAILiquidateBorrow) GetBorrower() common.Address {
+	return l.Borrower
+}
+
+// GetRepayAmount returns the amount repaid by the liquidator.
+func (l *CDAILiquidateBorrow) GetRepayAmount() *big.Int {
+	return l.RepayAmount
+}
+
+// GetCTokenCollateral returns the address of the seized collateral market.
+func (l *CDAILiquidateBorrow) GetCTokenCollateral() common.Address {
+	return l.CTokenCollateral
+}
+
+// GetSeizeTokens returns the amount of collateral cTokens seized.
+func (l *CDAILiquidateBorrow) GetSeizeTokens() *big.Int {
+	return l.SeizeTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (l *CDAILiquidateBorrow) GetBlockNumber() uint64 {
+	return l.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (l *CDAILiquidateBorrow) GetTxHash() common.Hash {
+	return l.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (l *CDAILiquidateBorrow) GetLogIndex() uint {
+	return l.Raw.Index
+}
+
+// GetMinter returns the minter.
+func (m *CDAIMint) GetMinter() common.Address {
+	return m.Minter
+}
+
+// GetMintAmount returns the underlying amount supplied.
+func (m *CDAIMint) GetMintAmount() *big.Int {
+	return m.MintAmount
+}
+
+// GetMintTokens returns the amount of cTokens minted.
+func (m *CDAIMint) GetMintTokens() *big.Int {
+	return m.MintTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (m *CDAIMint) GetBlockNumber() uint64 {
+	return m.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (m *CDAIMint) GetTxHash() common.Hash {
+	return m.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (m *CDAIMint) GetLogIndex() uint {
+	return m.Raw.Index
+}
+
+// GetRedeemer returns the redeemer.
+func (r *CDAIRedeem) GetRedeemer() common.Address {
+	return r.Redeemer
+}
+
+// GetRedeemAmount returns the underlying amount redeemed.
+func (r *CDAIRedeem) GetRedeemAmount() *big.Int {
+	return r.RedeemAmount
+}
+
+// GetRedeemTokens returns the amount of cTokens redeemed.
+func (r *CDAIRedeem) GetRedeemTokens() *big.Int {
+	return r.RedeemTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (r *CDAIRedeem) GetBlockNumber() uint64 {
+	return r.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (r *CDAIRedeem) GetTxHash() common.Hash {
+	return r.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (r *CDAIRedeem) GetLogIndex() uint {
+	return r.Raw.Index
+}
+
+// GetFrom returns the sender.
+func (t *CDAITransfer) GetFrom() common.Address {
+	return t.From
+}
+
+// GetTo returns the recipient.
+func (t *CDAITransfer) GetTo() common.Address {
+	return t.To
+}
+
+// GetAmount returns the amount of cTokens transferred.
+func (t *CDAITransfer) GetAmount() *big.Int {
+	return t.Amount
+}
+
+// GetBlockNumber returns the block number of the event.
+func (t *CDAITransfer) GetBlockNumber() uint64 {
+	return t.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (t *CDAITransfer) GetTxHash() common.Hash {
+	return t.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (t *CDAITransfer) GetLogIndex() uint {
+	return t.Raw.Index
+}
+
+// ParseBorrowEvent parses a Borrow event from the log.
+func (b *CDAIFilterer) ParseBorrowEvent(log types.Log) (TokenBorrow, error) {
+	event, err := b.ParseBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	// the generated parser leaves the raw log unset, unlike the iterators.
+	event.Raw = log
+	return event, nil
+}
+
+// ParseRepayBorrowEvent parses a RepayBorrow event from the log.
+func (b *CDAIFilterer) ParseRepayBorrowEvent(log types.Log) (TokenRepayBorrow, error) {
+	event, err := b.ParseRepayBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseLiquidateBorrowEvent parses a LiquidateBorrow event from the log.
+func (b *CDAIFilterer) ParseLiquidateBorrowEvent(log types.Log) (TokenLiquidateBorrow, error) {
+	event, err := b.ParseLiquidateBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseMintEvent parses a Mint event from the log.
+func (b *CDAIFilterer) ParseMintEvent(log types.Log) (TokenMint, error) {
+	event, err := b.ParseMint(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseRedeemEvent parses a Redeem event from the log.
+func (b *CDAIFilterer) ParseRedeemEvent(log types.Log) (TokenRedeem, error) {
+	event, err := b.ParseRedeem(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseTransferEvent parses a Transfer event from the log.
+func (b *CDAIFilterer) ParseTransferEvent(log types.Log) (TokenTransfer, error) {
+	event, err := b.ParseTransfer(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
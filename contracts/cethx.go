@@ -1,11 +1,11 @@
 package contracts
 
 import (
-	"log"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 )
 
 // GetBorrower returns the borrower.
@@ -33,18 +33,291 @@ func (b *CETHBorrow) GetBlockNumber() uint64 {
 	return b.Raw.BlockNumber
 }
 
+// GetTxHash returns the hash of the transaction emitting the event.
+func (b *CETHBorrow) GetTxHash() common.Hash {
+	return b.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (b *CETHBorrow) GetLogIndex() uint {
+	return b.Raw.Index
+}
+
+// GetBorrowIndex returns the borrow index after the accrual.
+func (a *CETHAccrueInterest) GetBorrowIndex() *big.Int {
+	return a.BorrowIndex
+}
+
+// GetTotalBorrows returns the contract borrow amount after the accrual.
+func (a *CETHAccrueInterest) GetTotalBorrows() *big.Int {
+	return a.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (a *CETHAccrueInterest) GetBlockNumber() uint64 {
+	return a.Raw.BlockNumber
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (a *CETHAccrueInterest) GetLogIndex() uint {
+	return a.Raw.Index
+}
+
 // FilterBorrowEvents returns the borrow events.
 func (b *CETHFilterer) FilterBorrowEvents(opts *bind.FilterOpts) (TokenBorrowIterator, error) {
 	iter, err := b.FilterBorrow(opts)
-
 	if err != nil {
-		log.Fatalf("Failed to call FilterBorrow: %#v", err)
+		return nil, err
 	}
-
-	return iter, err
+	return iter, nil
 }
 
 // GetEvent returns the Event containing the contract specifics and raw log.
 func (b *CETHBorrowIterator) GetEvent() TokenBorrow {
 	return b.Event
 }
+
+// FilterAccrueInterestEvents returns the AccrueInterest events.
+func (b *CETHFilterer) FilterAccrueInterestEvents(opts *bind.FilterOpts) (TokenAccrueInterestIterator, error) {
+	iter, err := b.FilterAccrueInterest(opts)
+	if err != nil {
+		return nil, err
+	}
+	return iter, nil
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *CETHAccrueInterestIterator) GetEvent() TokenAccrueInterest {
+	return i.Event
+}
+
+// GetPayer returns the payer.
+func (r *CETHRepayBorrow) GetPayer() common.Address {
+	return r.Payer
+}
+
+// GetBorrower returns the borrower.
+func (r *CETHRepayBorrow) GetBorrower() common.Address {
+	return r.Borrower
+}
+
+// GetRepayAmount returns the repay amount.
+func (r *CETHRepayBorrow) GetRepayAmount() *big.Int {
+	return r.RepayAmount
+}
+
+// GetAccountBorrows returns the account borrow amount after the repayment.
+func (r *CETHRepayBorrow) GetAccountBorrows() *big.Int {
+	return r.AccountBorrows
+}
+
+// GetTotalBorrows returns the contract borrow amount after the repayment.
+func (r *CETHRepayBorrow) GetTotalBorrows() *big.Int {
+	return r.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (r *CETHRepayBorrow) GetBlockNumber() uint64 {
+	return r.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (r *CETHRepayBorrow) GetTxHash() common.Hash {
+	return r.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (r *CETHRepayBorrow) GetLogIndex() uint {
+	return r.Raw.Index
+}
+
+// GetLiquidator returns the liquidator.
+func (l *CETHLiquidateBorrow) GetLiquidator() common.Address {
+	return l.Liquidator
+}
+
+// GetBorrower returns the borrower.
+func (l *CETHLiquidateBorrow) GetBorrower() common.Address {
+	return l.Borrower
+}
+
+// GetRepayAmount returns the amount repaid by the liquidator.
+func (l *CETHLiquidateBorrow) GetRepayAmount() *big.Int {
+	return l.RepayAmount
+}
+
+// GetCTokenCollateral returns the address of the seized collateral market.
+func (l *CETHLiquidateBorrow) GetCTokenCollateral() common.Address {
+	return l.CTokenCollateral
+}
+
+// GetSeizeTokens returns the amount of collateral cTokens seized.
+func (l *CETHLiquidateBorrow) GetSeizeTokens() *big.Int {
+	return l.SeizeTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (l *CETHLiquidateBorrow) GetBlockNumber() uint64 {
+	return l.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (l *CETHLiquidateBorrow) GetTxHash() common.Hash {
+	return l.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (l *CETHLiquidateBorrow) GetLogIndex() uint {
+	return l.Raw.Index
+}
+
+// GetMinter returns the minter.
+func (m *CETHMint) GetMinter() common.Address {
+	return m.Minter
+}
+
+// GetMintAmount returns the underlying amount supplied.
+func (m *CETHMint) GetMintAmount() *big.Int {
+	return m.MintAmount
+}
+
+// GetMintTokens returns the amount of cTokens minted.
+func (m *CETHMint) GetMintTokens() *big.Int {
+	return m.MintTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (m *CETHMint) GetBlockNumber() uint64 {
+	return m.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (m *CETHMint) GetTxHash() common.Hash {
+	return m.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (m *CETHMint) GetLogIndex() uint {
+	return m.Raw.Index
+}
+
+// GetRedeemer returns the redeemer.
+func (r *CETHRedeem) GetRedeemer() common.Address {
+	return r.Redeemer
+}
+
+// GetRedeemAmount returns the underlying amount redeemed.
+func (r *CETHRedeem) GetRedeemAmount() *big.Int {
+	return r.RedeemAmount
+}
+
+// GetRedeemTokens returns the amount of cTokens redeemed.
+func (r *CETHRedeem) GetRedeemTokens() *big.Int {
+	return r.RedeemTokens
+}
+
+// GetBlockNumber returns the block number of the event.
+func (r *CETHRedeem) GetBlockNumber() uint64 {
+	return r.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (r *CETHRedeem) GetTxHash() common.Hash {
+	return r.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (r *CETHRedeem) GetLogIndex() uint {
+	return r.Raw.Index
+}
+
+// GetFrom returns the sender.
+func (t *CETHTransfer) GetFrom() common.Address {
+	return t.From
+}
+
+// GetTo returns the recipient.
+func (t *CETHTransfer) GetTo() common.Address {
+	return t.To
+}
+
+// GetAmount returns the amount of cTokens transferred.
+func (t *CETHTransfer) GetAmount() *big.Int {
+	return t.Amount
+}
+
+// GetBlockNumber returns the block number of the event.
+func (t *CETHTransfer) GetBlockNumber() uint64 {
+	return t.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (t *CETHTransfer) GetTxHash() common.Hash {
+	return t.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (t *CETHTransfer) GetLogIndex() uint {
+	return t.Raw.Index
+}
+
+// ParseBorrowEvent parses a Borrow event from the log.
+func (b *CETHFilterer) ParseBorrowEvent(log types.Log) (TokenBorrow, error) {
+	event, err := b.ParseBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	// the generated parser leaves the raw log unset, unlike the iterators.
+	event.Raw = log
+	return event, nil
+}
+
+// ParseRepayBorrowEvent parses a RepayBorrow event from the log.
+func (b *CETHFilterer) ParseRepayBorrowEvent(log types.Log) (TokenRepayBorrow, error) {
+	event, err := b.ParseRepayBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseLiquidateBorrowEvent parses a LiquidateBorrow event from the log.
+func (b *CETHFilterer) ParseLiquidateBorrowEvent(log types.Log) (TokenLiquidateBorrow, error) {
+	event, err := b.ParseLiquidateBorrow(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseMintEvent parses a Mint event from the log.
+func (b *CETHFilterer) ParseMintEvent(log types.Log) (TokenMint, error) {
+	event, err := b.ParseMint(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseRedeemEvent parses a Redeem event from the log.
+func (b *CETHFilterer) ParseRedeemEvent(log types.Log) (TokenRedeem, error) {
+	event, err := b.ParseRedeem(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+
+// ParseTransferEvent parses a Transfer event from the log.
+func (b *CETHFilterer) ParseTransferEvent(log types.Log) (TokenTransfer, error) {
+	event, err := b.ParseTransfer(log)
+	if err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
@@ -0,0 +1,269 @@
+package contracts
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// EventIterator provides a mechanism to iterate over contract events whose contents are not needed.
+type EventIterator interface {
+	Next() bool
+	Error() error
+	Close() error
+}
+
+// MockEventIterator is used for testing.
+type MockEventIterator struct {
+	Events int
+	Index  int
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found.
+func (i *MockEventIterator) Next() bool {
+	i.Index++
+	return !(i.Index > i.Events)
+}
+
+// Error returns any retrieval or parsing error occurred during filtering.
+func (i *MockEventIterator) Error() error {
+	return nil
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources.
+func (i *MockEventIterator) Close() error {
+	return nil
+}
+
+// ActionPaused represents an ActionPaused event.
+type ActionPaused interface {
+	GetAction() string
+	GetPauseState() bool
+	GetBlockNumber() uint64
+}
+
+// ActionPausedIterator provides a mechanism to iterate over the Comptroller's ActionPaused events.
+type ActionPausedIterator interface {
+	EventIterator
+	GetEvent() ActionPaused
+}
+
+// MarketMembership represents a MarketEntered or MarketExited event.
+type MarketMembership interface {
+	GetCToken() common.Address
+	GetAccount() common.Address
+	IsEntered() bool
+	GetBlockNumber() uint64
+	GetTxHash() common.Hash
+	GetLogIndex() uint
+}
+
+// MarketMembershipIterator provides a mechanism to iterate over the Comptroller's MarketEntered or MarketExited events.
+type MarketMembershipIterator interface {
+	EventIterator
+	GetEvent() MarketMembership
+}
+
+// MockMarketMembership is used for testing.
+type MockMarketMembership struct {
+	CToken      common.Address
+	Account     common.Address
+	Entered     bool
+	BlockNumber uint64
+	TxHash      common.Hash
+	LogIndex    uint
+}
+
+// MockMarketMembershipIterator provides a mechanism to iterate over MarketEntered or MarketExited events.
+type MockMarketMembershipIterator struct {
+	MockEventIterator
+	MarketMembershipEvents []MarketMembership
+}
+
+// MockActionPaused is used for testing.
+type MockActionPaused struct {
+	Action      string
+	PauseState  bool
+	BlockNumber uint64
+}
+
+// MockActionPausedIterator provides a mechanism to iterate over ActionPaused events.
+type MockActionPausedIterator struct {
+	MockEventIterator
+	ActionPausedEvents []ActionPaused
+}
+
+// GetAction returns the paused action.
+func (e *ComptrollerActionPaused) GetAction() string {
+	return e.Action
+}
+
+// GetPauseState returns whether the action is paused.
+func (e *ComptrollerActionPaused) GetPauseState() bool {
+	return e.PauseState
+}
+
+// GetBlockNumber returns the block number of the event.
+func (e *ComptrollerActionPaused) GetBlockNumber() uint64 {
+	return e.Raw.BlockNumber
+}
+
+// FilterActionPausedEvents returns the ActionPaused events.
+func (f *ComptrollerFilterer) FilterActionPausedEvents(opts *bind.FilterOpts) (ActionPausedIterator, error) {
+	return f.FilterActionPaused(opts)
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *ComptrollerActionPausedIterator) GetEvent() ActionPaused {
+	return i.Event
+}
+
+// GetAction returns the paused action.
+func (e *MockActionPaused) GetAction() string {
+	return e.Action
+}
+
+// GetPauseState returns whether the action is paused.
+func (e *MockActionPaused) GetPauseState() bool {
+	return e.PauseState
+}
+
+// GetBlockNumber returns the block number of the event.
+func (e *MockActionPaused) GetBlockNumber() uint64 {
+	return e.BlockNumber
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found.
+func (i *MockActionPausedIterator) Next() bool {
+	i.Index++
+	return !(i.Index > len(i.ActionPausedEvents))
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *MockActionPausedIterator) GetEvent() ActionPaused {
+	return i.ActionPausedEvents[i.Index-1]
+}
+
+// GetCToken returns the entered market.
+func (e *ComptrollerMarketEntered) GetCToken() common.Address {
+	return e.CToken
+}
+
+// GetAccount returns the account entering the market.
+func (e *ComptrollerMarketEntered) GetAccount() common.Address {
+	return e.Account
+}
+
+// IsEntered returns true as the market was entered.
+func (e *ComptrollerMarketEntered) IsEntered() bool {
+	return true
+}
+
+// GetBlockNumber returns the block number of the event.
+func (e *ComptrollerMarketEntered) GetBlockNumber() uint64 {
+	return e.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (e *ComptrollerMarketEntered) GetTxHash() common.Hash {
+	return e.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (e *ComptrollerMarketEntered) GetLogIndex() uint {
+	return e.Raw.Index
+}
+
+// GetCToken returns the exited market.
+func (e *ComptrollerMarketExited) GetCToken() common.Address {
+	return e.CToken
+}
+
+// GetAccount returns the account exiting the market.
+func (e *ComptrollerMarketExited) GetAccount() common.Address {
+	return e.Account
+}
+
+// IsEntered returns false as the market was exited.
+func (e *ComptrollerMarketExited) IsEntered() bool {
+	return false
+}
+
+// GetBlockNumber returns the block number of the event.
+func (e *ComptrollerMarketExited) GetBlockNumber() uint64 {
+	return e.Raw.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (e *ComptrollerMarketExited) GetTxHash() common.Hash {
+	return e.Raw.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (e *ComptrollerMarketExited) GetLogIndex() uint {
+	return e.Raw.Index
+}
+
+// FilterMarketEnteredEvents returns the MarketEntered events.
+func (f *ComptrollerFilterer) FilterMarketEnteredEvents(opts *bind.FilterOpts) (MarketMembershipIterator, error) {
+	return f.FilterMarketEntered(opts)
+}
+
+// FilterMarketExitedEvents returns the MarketExited events.
+func (f *ComptrollerFilterer) FilterMarketExitedEvents(opts *bind.FilterOpts) (MarketMembershipIterator, error) {
+	return f.FilterMarketExited(opts)
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *ComptrollerMarketEnteredIterator) GetEvent() MarketMembership {
+	return i.Event
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *ComptrollerMarketExitedIterator) GetEvent() MarketMembership {
+	return i.Event
+}
+
+// GetCToken returns the market.
+func (e *MockMarketMembership) GetCToken() common.Address {
+	return e.CToken
+}
+
+// GetAccount returns the account.
+func (e *MockMarketMembership) GetAccount() common.Address {
+	return e.Account
+}
+
+// IsEntered returns whether the market was entered.
+func (e *MockMarketMembership) IsEntered() bool {
+	return e.Entered
+}
+
+// GetBlockNumber returns the block number of the event.
+func (e *MockMarketMembership) GetBlockNumber() uint64 {
+	return e.BlockNumber
+}
+
+// GetTxHash returns the hash of the transaction emitting the event.
+func (e *MockMarketMembership) GetTxHash() common.Hash {
+	return e.TxHash
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (e *MockMarketMembership) GetLogIndex() uint {
+	return e.LogIndex
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found.
+func (i *MockMarketMembershipIterator) Next() bool {
+	i.Index++
+	return !(i.Index > len(i.MarketMembershipEvents))
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *MockMarketMembershipIterator) GetEvent() MarketMembership {
+	return i.MarketMembershipEvents[i.Index-1]
+}
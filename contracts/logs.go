@@ -0,0 +1,187 @@
+package contracts
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// BorrowEventName is the name of the Borrow event.
+	BorrowEventName = "Borrow"
+
+	// RepayBorrowEventName is the name of the RepayBorrow event.
+	RepayBorrowEventName = "RepayBorrow"
+
+	// LiquidateBorrowEventName is the name of the LiquidateBorrow event.
+	LiquidateBorrowEventName = "LiquidateBorrow"
+
+	// MintEventName is the name of the Mint event.
+	MintEventName = "Mint"
+
+	// RedeemEventName is the name of the Redeem event.
+	RedeemEventName = "Redeem"
+
+	// TransferEventName is the name of the Transfer event.
+	TransferEventName = "Transfer"
+)
+
+// tokenEventNames are the events fetched by a TokenLogFetcher.
+var tokenEventNames = []string{
+	BorrowEventName,
+	RepayBorrowEventName,
+	LiquidateBorrowEventName,
+	MintEventName,
+	RedeemEventName,
+	TransferEventName,
+}
+
+// LogFilterer queries logs, e.g. ethclient.Client.
+type LogFilterer interface {
+	FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// TokenLog is an event of a token contract.
+type TokenLog struct {
+	TokenSymbol string
+	EventName   string
+	Event       TokenEvent
+}
+
+// LogFetcher fetches the events of all token contracts.
+type LogFetcher interface {
+	FetchLogs(opts *bind.FilterOpts) ([]*TokenLog, error)
+}
+
+// TokenLogFetcher fetches the events of all token contracts with a single query per block range.
+type TokenLogFetcher struct {
+	filterer   LogFilterer
+	tokens     map[string]Token
+	symbols    map[common.Address]string
+	addresses  []common.Address
+	eventNames map[common.Hash]string
+	topics     []common.Hash
+}
+
+// MockLogFetcher is used for testing.
+type MockLogFetcher struct {
+	Logs []*TokenLog
+}
+
+// NewTokenLogFetcher creates a new LogFetcher for the provided tokens.
+func NewTokenLogFetcher(filterer LogFilterer, tokensProvider TokensProvider) (LogFetcher, error) {
+	// every market emits the CToken events, so their topics are the same for all tokens.
+	parsed, err := abi.JSON(strings.NewReader(CBATABI))
+	if err != nil {
+		return nil, err
+	}
+	fetcher := &TokenLogFetcher{
+		filterer:   filterer,
+		tokens:     tokensProvider.GetTokens(),
+		symbols:    make(map[common.Address]string),
+		eventNames: make(map[common.Hash]string),
+	}
+	for tokenSymbol, address := range tokensProvider.GetAddresses() {
+		if _, ok := fetcher.tokens[tokenSymbol]; !ok {
+			continue
+		}
+		fetcher.symbols[address] = tokenSymbol
+		fetcher.addresses = append(fetcher.addresses, address)
+	}
+	sort.Slice(fetcher.addresses, func(i, j int) bool {
+		return fetcher.addresses[i].Hex() < fetcher.addresses[j].Hex()
+	})
+	for _, eventName := range tokenEventNames {
+		event, ok := parsed.Events[eventName]
+		if !ok {
+			return nil, fmt.Errorf("event %v not found in the token ABI", eventName)
+		}
+		fetcher.eventNames[event.ID()] = eventName
+		fetcher.topics = append(fetcher.topics, event.ID())
+	}
+	return fetcher, nil
+}
+
+// FetchLogs returns the events of all tokens in the block range, in the order the node returns them.
+func (fetcher *TokenLogFetcher) FetchLogs(opts *bind.FilterOpts) ([]*TokenLog, error) {
+	query := ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(opts.Start),
+		Addresses: fetcher.addresses,
+		Topics:    [][]common.Hash{fetcher.topics},
+	}
+	if opts.End != nil {
+		query.ToBlock = new(big.Int).SetUint64(*opts.End)
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	logs, err := fetcher.filterer.FilterLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	tokenLogs := []*TokenLog{}
+	for _, rawLog := range logs {
+		if rawLog.Removed || len(rawLog.Topics) == 0 {
+			continue
+		}
+		tokenSymbol, ok := fetcher.symbols[rawLog.Address]
+		if !ok {
+			continue
+		}
+		eventName, ok := fetcher.eventNames[rawLog.Topics[0]]
+		if !ok {
+			continue
+		}
+		event, err := parseTokenLog(fetcher.tokens[tokenSymbol], eventName, rawLog)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %v %v event at block # %v: %v", tokenSymbol, eventName, rawLog.BlockNumber, err)
+		}
+		tokenLogs = append(tokenLogs, &TokenLog{
+			TokenSymbol: tokenSymbol,
+			EventName:   eventName,
+			Event:       event,
+		})
+	}
+	return tokenLogs, nil
+}
+
+// parseTokenLog dispatches the log to the token's parser for the event.
+func parseTokenLog(token Token, eventName string, rawLog types.Log) (TokenEvent, error) {
+	switch eventName {
+	case BorrowEventName:
+		return token.ParseBorrowEvent(rawLog)
+	case RepayBorrowEventName:
+		return token.ParseRepayBorrowEvent(rawLog)
+	case LiquidateBorrowEventName:
+		return token.ParseLiquidateBorrowEvent(rawLog)
+	case MintEventName:
+		return token.ParseMintEvent(rawLog)
+	case RedeemEventName:
+		return token.ParseRedeemEvent(rawLog)
+	case TransferEventName:
+		return token.ParseTransferEvent(rawLog)
+	}
+	return nil, fmt.Errorf("unknown event %v", eventName)
+}
+
+// FetchLogs returns the logs in the block range.
+func (fetcher *MockLogFetcher) FetchLogs(opts *bind.FilterOpts) ([]*TokenLog, error) {
+	tokenLogs := []*TokenLog{}
+	for _, tokenLog := range fetcher.Logs {
+		blockNumber := tokenLog.Event.GetBlockNumber()
+		if blockNumber < opts.Start || (opts.End != nil && blockNumber > *opts.End) {
+			continue
+		}
+		tokenLogs = append(tokenLogs, tokenLog)
+	}
+	return tokenLogs, nil
+}
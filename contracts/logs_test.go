@@ -0,0 +1,114 @@
+package contracts
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+type mockLogFilterer struct {
+	logs    []types.Log
+	queries []ethereum.FilterQuery
+}
+
+func (filterer *mockLogFilterer) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	filterer.queries = append(filterer.queries, query)
+	return filterer.logs, nil
+}
+
+func TestTokenLogFetcher_FetchLogs(t *testing.T) {
+	// Arrange
+	parsed, err := abi.JSON(strings.NewReader(CBATABI))
+	if err != nil {
+		t.Fatalf("abi.JSON() error = %v", err)
+	}
+	cBAT := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	cUSDC := common.HexToAddress("0x39aa39c021dfbae8fac545936693ac917d5e7563")
+	unknown := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	borrower := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	borrowData, _ := parsed.Events[BorrowEventName].Inputs.NonIndexed().Pack(borrower, big.NewInt(10), big.NewInt(15), big.NewInt(100))
+	repayData, _ := parsed.Events[RepayBorrowEventName].Inputs.NonIndexed().Pack(borrower, borrower, big.NewInt(5), big.NewInt(10), big.NewInt(95))
+	transferData, _ := parsed.Events[TransferEventName].Inputs.NonIndexed().Pack(big.NewInt(7))
+	borrowTopic := parsed.Events[BorrowEventName].ID()
+	repayTopic := parsed.Events[RepayBorrowEventName].ID()
+	transferTopic := parsed.Events[TransferEventName].ID()
+	approvalTopic := parsed.Events["Approval"].ID()
+	filterer := &mockLogFilterer{
+		logs: []types.Log{
+			{Address: cBAT, Topics: []common.Hash{borrowTopic}, Data: borrowData, BlockNumber: 10, Index: 0},
+			{Address: cUSDC, Topics: []common.Hash{transferTopic, borrower.Hash(), cUSDC.Hash()}, Data: transferData, BlockNumber: 10, Index: 1},
+			{Address: unknown, Topics: []common.Hash{borrowTopic}, Data: borrowData, BlockNumber: 11, Index: 0},
+			{Address: cBAT, Topics: []common.Hash{approvalTopic}, BlockNumber: 11, Index: 1},
+			{Address: cBAT, Topics: []common.Hash{repayTopic}, Data: repayData, BlockNumber: 11, Index: 2, Removed: true},
+			{Address: cBAT, Topics: []common.Hash{repayTopic}, Data: repayData, BlockNumber: 12, Index: 0},
+		},
+	}
+	cBATToken, _ := NewCBAT(cBAT, nil)
+	cUSDCToken, _ := NewCUSDC(cUSDC, nil)
+	tokensProvider := &MockTokenContracts{
+		Contracts: map[string]Token{CBATSymbol: cBATToken, CUSDCSymbol: cUSDCToken},
+		Addresses: map[string]common.Address{CBATSymbol: cBAT, CUSDCSymbol: cUSDC},
+	}
+	fetcher, err := NewTokenLogFetcher(filterer, tokensProvider)
+	if err != nil {
+		t.Fatalf("NewTokenLogFetcher() error = %v", err)
+	}
+	end := uint64(20)
+	// Act
+	tokenLogs, err := fetcher.FetchLogs(&bind.FilterOpts{Start: 10, End: &end})
+	// Assert
+	if err != nil {
+		t.Fatalf("fetcher.FetchLogs() error = %v", err)
+	}
+	if len(filterer.queries) != 1 {
+		t.Fatalf("len(filterer.queries) = %v, want %v", len(filterer.queries), 1)
+	}
+	query := filterer.queries[0]
+	if query.FromBlock.Uint64() != 10 || query.ToBlock.Uint64() != 20 {
+		t.Errorf("query range = [%v, %v], want [%v, %v]", query.FromBlock, query.ToBlock, 10, 20)
+	}
+	if !reflect.DeepEqual(query.Addresses, []common.Address{cUSDC, cBAT}) {
+		t.Errorf("query.Addresses = %v, want %v", query.Addresses, []common.Address{cUSDC, cBAT})
+	}
+	if len(query.Topics) != 1 || len(query.Topics[0]) != len(tokenEventNames) {
+		t.Errorf("query.Topics = %v, want %v topics in the first position", query.Topics, len(tokenEventNames))
+	}
+	wantLogs := []struct {
+		tokenSymbol string
+		eventName   string
+		blockNumber uint64
+	}{
+		{CBATSymbol, BorrowEventName, 10},
+		{CUSDCSymbol, TransferEventName, 10},
+		{CBATSymbol, RepayBorrowEventName, 12},
+	}
+	if len(tokenLogs) != len(wantLogs) {
+		t.Fatalf("len(tokenLogs) = %v, want %v", len(tokenLogs), len(wantLogs))
+	}
+	for i, want := range wantLogs {
+		tokenLog := tokenLogs[i]
+		if tokenLog.TokenSymbol != want.tokenSymbol || tokenLog.EventName != want.eventName || tokenLog.Event.GetBlockNumber() != want.blockNumber {
+			t.Errorf("tokenLogs[%v] = %v %v at block # %v, want %v %v at block # %v", i, tokenLog.TokenSymbol, tokenLog.EventName, tokenLog.Event.GetBlockNumber(), want.tokenSymbol, want.eventName, want.blockNumber)
+		}
+	}
+	borrow := tokenLogs[0].Event.(TokenBorrow)
+	if borrow.GetBorrower() != borrower || borrow.GetAccountBorrows().Cmp(big.NewInt(15)) != 0 {
+		t.Errorf("borrow = %v %v, want %v %v", borrow.GetBorrower().Hex(), borrow.GetAccountBorrows(), borrower.Hex(), 15)
+	}
+	transfer := tokenLogs[1].Event.(TokenTransfer)
+	if transfer.GetFrom() != borrower || transfer.GetTo() != cUSDC || transfer.GetAmount().Cmp(big.NewInt(7)) != 0 {
+		t.Errorf("transfer = %v %v %v, want %v %v %v", transfer.GetFrom().Hex(), transfer.GetTo().Hex(), transfer.GetAmount(), borrower.Hex(), cUSDC.Hex(), 7)
+	}
+	repay := tokenLogs[2].Event.(TokenRepayBorrow)
+	if repay.GetAccountBorrows().Cmp(big.NewInt(10)) != 0 {
+		t.Errorf("repay.GetAccountBorrows() = %v, want %v", repay.GetAccountBorrows(), 10)
+	}
+}
@@ -0,0 +1,146 @@
+package contracts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Network contains the addresses of a Compound deployment.
+type Network struct {
+	Name               string                    `json:"name"`
+	ChainID            uint64                    `json:"chainId"`
+	ComptrollerAddress common.Address            `json:"comptroller"`
+	TokenAddresses     map[string]common.Address `json:"tokens"`
+}
+
+// ChainIDReader reads the chain ID of the connected node, e.g. ethclient.Client.
+type ChainIDReader interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// NetworkRegistry supplies the deployments by chain ID.
+type NetworkRegistry struct {
+	networks map[uint64]*Network
+}
+
+// Mainnet is the Compound deployment on Ethereum mainnet.
+var Mainnet = &Network{
+	Name:               "mainnet",
+	ChainID:            1,
+	ComptrollerAddress: common.HexToAddress("0x3d9819210a31b4961b30ef54be2aed79b9c9cd3b"),
+	TokenAddresses:     tokenAddresses,
+}
+
+// NewNetworkRegistry creates a new NetworkRegistry containing Mainnet.
+// Testnet and local dev chain deployments are not built in: Compound redeploys its testnet contracts
+// and a dev chain's addresses depend on its own deployment, so they are registered from a file with LoadFile.
+func NewNetworkRegistry() *NetworkRegistry {
+	registry := &NetworkRegistry{networks: make(map[uint64]*Network)}
+	registry.networks[Mainnet.ChainID] = Mainnet
+	return registry
+}
+
+// Register adds the network to the registry.
+func (registry *NetworkRegistry) Register(network *Network) error {
+	if network.Name == "" {
+		return fmt.Errorf("network with chain ID %v has no name", network.ChainID)
+	}
+	if network.ComptrollerAddress == (common.Address{}) {
+		return fmt.Errorf("network %v has no comptroller address", network.Name)
+	}
+	if len(network.TokenAddresses) == 0 {
+		return fmt.Errorf("network %v has no tokens", network.Name)
+	}
+	for tokenSymbol, address := range network.TokenAddresses {
+		if !isTokenSymbol(tokenSymbol) {
+			return fmt.Errorf("network %v has unknown token %v (known: %v)", network.Name, tokenSymbol, TokenSymbols)
+		}
+		if address == (common.Address{}) {
+			return fmt.Errorf("network %v has no address for token %v", network.Name, tokenSymbol)
+		}
+	}
+	if existing, ok := registry.networks[network.ChainID]; ok {
+		return fmt.Errorf("network %v has the same chain ID %v as %v", network.Name, network.ChainID, existing.Name)
+	}
+	for _, existing := range registry.networks {
+		if existing.Name == network.Name {
+			return fmt.Errorf("network %v is already registered with chain ID %v", network.Name, existing.ChainID)
+		}
+	}
+	registry.networks[network.ChainID] = network
+	return nil
+}
+
+// LoadFile registers the networks of a JSON file, e.g. testnet or local dev chain deployments:
+//	[{"name": "dev", "chainId": 1337, "comptroller": "0x...", "tokens": {"CETH": "0x..."}}]
+func (registry *NetworkRegistry) LoadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	networks := []*Network{}
+	err = json.Unmarshal(data, &networks)
+	if err != nil {
+		return fmt.Errorf("failed to parse networks file %v: %v", path, err)
+	}
+	for _, network := range networks {
+		err = registry.Register(network)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetNetwork returns the network with the chain ID.
+func (registry *NetworkRegistry) GetNetwork(chainID *big.Int) (*Network, error) {
+	if !chainID.IsUint64() {
+		return nil, fmt.Errorf("invalid chain ID %v", chainID)
+	}
+	network, ok := registry.networks[chainID.Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("no network registered for chain ID %v (known: %v)", chainID, registry.getNames())
+	}
+	return network, nil
+}
+
+// SelectNetwork returns the network of the connected chain.
+// When name is set, it refuses to select a network other than the named one.
+func (registry *NetworkRegistry) SelectNetwork(ctx context.Context, chainIDReader ChainIDReader, name string) (*Network, error) {
+	chainID, err := chainIDReader.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chain ID: %v", err)
+	}
+	network, err := registry.GetNetwork(chainID)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" && network.Name != name {
+		return nil, fmt.Errorf("configured network %v does not match connected chain %v (chain ID %v)", name, network.Name, chainID)
+	}
+	return network, nil
+}
+
+func (registry *NetworkRegistry) getNames() []string {
+	names := []string{}
+	for _, network := range registry.networks {
+		names = append(names, network.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isTokenSymbol(tokenSymbol string) bool {
+	for _, known := range TokenSymbols {
+		if known == tokenSymbol {
+			return true
+		}
+	}
+	return false
+}
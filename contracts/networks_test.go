@@ -0,0 +1,150 @@
+package contracts
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type mockChainIDReader struct {
+	chainID *big.Int
+}
+
+func (reader *mockChainIDReader) ChainID(ctx context.Context) (*big.Int, error) {
+	return reader.chainID, nil
+}
+
+func TestNetworkRegistry_SelectNetwork(t *testing.T) {
+	// Arrange
+	directory, err := ioutil.TempDir("", "networks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	networksFile := filepath.Join(directory, "networks.json")
+	ioutil.WriteFile(networksFile, []byte(`[{"name": "dev", "chainId": 1337, "comptroller": "0x000000000000000000000000000000000000c0de", "tokens": {"CETH": "0x000000000000000000000000000000000000cE7a"}}]`), 0600)
+	registry := NewNetworkRegistry()
+	err = registry.LoadFile(networksFile)
+	if err != nil {
+		t.Fatalf("registry.LoadFile() error = %v", err)
+	}
+	tests := []struct {
+		name            string
+		chainID         *big.Int
+		networkName     string
+		wantName        string
+		wantComptroller common.Address
+		wantErr         bool
+	}{
+		{
+			name:            "Should select mainnet by chain ID.",
+			chainID:         big.NewInt(1),
+			networkName:     "",
+			wantName:        "mainnet",
+			wantComptroller: common.HexToAddress("0x3d9819210a31b4961b30ef54be2aed79b9c9cd3b"),
+			wantErr:         false,
+		},
+		{
+			name:            "Should select configured dev chain.",
+			chainID:         big.NewInt(1337),
+			networkName:     "dev",
+			wantName:        "dev",
+			wantComptroller: common.HexToAddress("0x000000000000000000000000000000000000c0de"),
+			wantErr:         false,
+		},
+		{
+			name:        "Should refuse network not matching the chain.",
+			chainID:     big.NewInt(1337),
+			networkName: "mainnet",
+			wantErr:     true,
+		},
+		{
+			name:        "Should refuse unknown chain.",
+			chainID:     big.NewInt(3),
+			networkName: "",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := registry.SelectNetwork(context.Background(), &mockChainIDReader{chainID: tt.chainID}, tt.networkName)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("registry.SelectNetwork() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("got.Name = %v, want %v", got.Name, tt.wantName)
+			}
+			if got.ComptrollerAddress != tt.wantComptroller {
+				t.Errorf("got.ComptrollerAddress = %v, want %v", got.ComptrollerAddress.Hex(), tt.wantComptroller.Hex())
+			}
+		})
+	}
+}
+
+func TestNetworkRegistry_LoadFile(t *testing.T) {
+	// Arrange
+	directory, err := ioutil.TempDir("", "networks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	tests := []struct {
+		name     string
+		networks string
+		wantErr  bool
+	}{
+		{
+			name:     "Should register known tokens.",
+			networks: `[{"name": "dev", "chainId": 1337, "comptroller": "0x000000000000000000000000000000000000c0de", "tokens": {"CETH": "0x000000000000000000000000000000000000cE7a"}}]`,
+			wantErr:  false,
+		},
+		{
+			name:     "Should refuse unknown token.",
+			networks: `[{"name": "dev", "chainId": 1337, "comptroller": "0x000000000000000000000000000000000000c0de", "tokens": {"CUSDT": "0x000000000000000000000000000000000000cE7a"}}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "Should refuse missing token address.",
+			networks: `[{"name": "dev", "chainId": 1337, "comptroller": "0x000000000000000000000000000000000000c0de", "tokens": {"CETH": "0x0000000000000000000000000000000000000000"}}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "Should refuse malformed token address.",
+			networks: `[{"name": "dev", "chainId": 1337, "comptroller": "0x000000000000000000000000000000000000c0de", "tokens": {"CETH": "0xcE7a"}}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "Should refuse missing comptroller.",
+			networks: `[{"name": "dev", "chainId": 1337, "tokens": {"CETH": "0x000000000000000000000000000000000000cE7a"}}]`,
+			wantErr:  true,
+		},
+		{
+			name:     "Should refuse network without tokens.",
+			networks: `[{"name": "dev", "chainId": 1337, "comptroller": "0x000000000000000000000000000000000000c0de"}]`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			networksFile := filepath.Join(directory, "networks.json")
+			ioutil.WriteFile(networksFile, []byte(tt.networks), 0600)
+			registry := NewNetworkRegistry()
+			// Act
+			err := registry.LoadFile(networksFile)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("registry.LoadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,12 +1,15 @@
 package contracts
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/logging"
 )
 
 const (
@@ -35,10 +38,40 @@ const (
 	CZRXSymbol = "CZRX"
 )
 
+// TokenSymbols are the symbols of the tokens supported by NewToken.
+var TokenSymbols = []string{CBATSymbol, CDAISymbol, CETHSymbol, CREPSymbol, CSAISymbol, CUSDCSymbol, CWBTCSymbol, CZRXSymbol}
+
 // Token represents a token contract.
 type Token interface {
 	Name(opts *bind.CallOpts) (string, error)
 	FilterBorrowEvents(opts *bind.FilterOpts) (TokenBorrowIterator, error)
+	BorrowBalanceStored(opts *bind.CallOpts, account common.Address) (*big.Int, error)
+	GetAccountSnapshot(opts *bind.CallOpts, account common.Address) (*big.Int, *big.Int, *big.Int, *big.Int, error)
+	BorrowIndex(opts *bind.CallOpts) (*big.Int, error)
+	AccrualBlockNumber(opts *bind.CallOpts) (*big.Int, error)
+	BorrowRatePerBlock(opts *bind.CallOpts) (*big.Int, error)
+	FilterAccrueInterestEvents(opts *bind.FilterOpts) (TokenAccrueInterestIterator, error)
+	TotalBorrows(opts *bind.CallOpts) (*big.Int, error)
+	TotalSupply(opts *bind.CallOpts) (*big.Int, error)
+	TotalReserves(opts *bind.CallOpts) (*big.Int, error)
+	GetCash(opts *bind.CallOpts) (*big.Int, error)
+	ExchangeRateStored(opts *bind.CallOpts) (*big.Int, error)
+	SupplyRatePerBlock(opts *bind.CallOpts) (*big.Int, error)
+	ReserveFactorMantissa(opts *bind.CallOpts) (*big.Int, error)
+	InterestRateModel(opts *bind.CallOpts) (common.Address, error)
+	ParseBorrowEvent(log types.Log) (TokenBorrow, error)
+	ParseRepayBorrowEvent(log types.Log) (TokenRepayBorrow, error)
+	ParseLiquidateBorrowEvent(log types.Log) (TokenLiquidateBorrow, error)
+	ParseMintEvent(log types.Log) (TokenMint, error)
+	ParseRedeemEvent(log types.Log) (TokenRedeem, error)
+	ParseTransferEvent(log types.Log) (TokenTransfer, error)
+}
+
+// TokenEvent represents an event of a token contract.
+type TokenEvent interface {
+	GetBlockNumber() uint64
+	GetTxHash() common.Hash
+	GetLogIndex() uint
 }
 
 // TokenBorrow represents a borrow event.
@@ -48,6 +81,66 @@ type TokenBorrow interface {
 	GetAccountBorrows() *big.Int
 	GetTotalBorrows() *big.Int
 	GetBlockNumber() uint64
+	GetTxHash() common.Hash
+	GetLogIndex() uint
+}
+
+// TokenRepayBorrow represents a RepayBorrow event.
+type TokenRepayBorrow interface {
+	TokenEvent
+	GetPayer() common.Address
+	GetBorrower() common.Address
+	GetRepayAmount() *big.Int
+	GetAccountBorrows() *big.Int
+	GetTotalBorrows() *big.Int
+}
+
+// TokenLiquidateBorrow represents a LiquidateBorrow event.
+type TokenLiquidateBorrow interface {
+	TokenEvent
+	GetLiquidator() common.Address
+	GetBorrower() common.Address
+	GetRepayAmount() *big.Int
+	GetCTokenCollateral() common.Address
+	GetSeizeTokens() *big.Int
+}
+
+// TokenMint represents a Mint event.
+type TokenMint interface {
+	TokenEvent
+	GetMinter() common.Address
+	GetMintAmount() *big.Int
+	GetMintTokens() *big.Int
+}
+
+// TokenRedeem represents a Redeem event.
+type TokenRedeem interface {
+	TokenEvent
+	GetRedeemer() common.Address
+	GetRedeemAmount() *big.Int
+	GetRedeemTokens() *big.Int
+}
+
+// TokenTransfer represents a Transfer event.
+type TokenTransfer interface {
+	TokenEvent
+	GetFrom() common.Address
+	GetTo() common.Address
+	GetAmount() *big.Int
+}
+
+// TokenAccrueInterest represents an AccrueInterest event.
+type TokenAccrueInterest interface {
+	GetBorrowIndex() *big.Int
+	GetTotalBorrows() *big.Int
+	GetBlockNumber() uint64
+	GetLogIndex() uint
+}
+
+// TokenAccrueInterestIterator provides a mechanism to iterate over a token's AccrueInterest events.
+type TokenAccrueInterestIterator interface {
+	Next() bool
+	GetEvent() TokenAccrueInterest
 }
 
 // TokenBorrowIterator provides a mechanism to iterate over a token's Borrow events.
@@ -56,21 +149,95 @@ type TokenBorrowIterator interface {
 	GetEvent() TokenBorrow
 }
 
+// CErc20Token represents a token contract whose underlying asset is an ERC20 token.
+// CETH is the only market whose underlying asset is Ether.
+type CErc20Token interface {
+	Token
+	Underlying(opts *bind.CallOpts) (common.Address, error)
+}
+
+// CErc20Liquidator represents a token contract whose borrows are repaid with its ERC20 underlying asset when liquidating.
+type CErc20Liquidator interface {
+	LiquidateBorrow(opts *bind.TransactOpts, borrower common.Address, repayAmount *big.Int, cTokenCollateral common.Address) (*types.Transaction, error)
+}
+
+// CEtherLiquidator represents a token contract whose borrows are repaid with the Ether sent when liquidating.
+type CEtherLiquidator interface {
+	LiquidateBorrow(opts *bind.TransactOpts, borrower common.Address, cTokenCollateral common.Address) (*types.Transaction, error)
+}
+
+// UnderlyingToken represents the ERC20 token underlying a token contract.
+type UnderlyingToken interface {
+	BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error)
+	Allowance(opts *bind.CallOpts, owner common.Address, spender common.Address) (*big.Int, error)
+	Approve(opts *bind.TransactOpts, spender common.Address, amount *big.Int) (*types.Transaction, error)
+}
+
 // TokensProvider provides a mechanism to initialize token contracts.
 type TokensProvider interface {
 	GetTokens() map[string]Token
 	GetAddresses() map[string]common.Address
 }
 
+// Backend is the node connection of the contracts, e.g. ethclient.Client.
+type Backend interface {
+	bind.ContractBackend
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
 // TokenContracts maintains token contract state.
 type TokenContracts struct {
-	ethClient *ethclient.Client
+	ethClient Backend
 	tokens    map[string]Token
+	addresses map[string]common.Address
 }
 
 // MockToken is used for testing.
 type MockToken struct {
 	TokenBorrowIterator TokenBorrowIterator
+	BorrowBalances      map[common.Address]*big.Int
+	CTokenBalances      map[common.Address]*big.Int
+	// BorrowIndexMantissa defaults to 1.
+	BorrowIndexMantissa         *big.Int
+	AccrualBlock                uint64
+	BorrowRateMantissa          *big.Int
+	TokenAccrueInterestIterator TokenAccrueInterestIterator
+	Market                      MockTokenMarket
+	// LogEvents are the events parsed from logs, by log index.
+	LogEvents map[uint]TokenEvent
+	// Liquidations are the liquidations sent to the token.
+	Liquidations []MockLiquidation
+}
+
+// MockLiquidation is a liquidation sent to a MockToken.
+type MockLiquidation struct {
+	Borrower         common.Address
+	RepayAmount      *big.Int
+	CTokenCollateral common.Address
+}
+
+// MockTokenMarket is the market state of a MockToken. Unset amounts are 0 and the exchange rate defaults to 1.
+type MockTokenMarket struct {
+	TotalBorrows      *big.Int
+	TotalSupply       *big.Int
+	TotalReserves     *big.Int
+	Cash              *big.Int
+	ExchangeRate      *big.Int
+	SupplyRate        *big.Int
+	ReserveFactor     *big.Int
+	InterestRateModel common.Address
+}
+
+// MockCErc20Token is used for testing.
+type MockCErc20Token struct {
+	MockToken
+	UnderlyingAddress common.Address
+}
+
+// MockUnderlyingToken is used for testing.
+type MockUnderlyingToken struct {
+	Balances   map[common.Address]*big.Int
+	Allowances map[common.Address]map[common.Address]*big.Int
 }
 
 // MockTokenContracts maintains token contract state.
@@ -85,7 +252,21 @@ type MockTokenBorrowIterator struct {
 	Index        int
 }
 
-// TokenAddresses contains Compound Token addresses.
+// MockTokenAccrueInterest is used for testing.
+type MockTokenAccrueInterest struct {
+	BorrowIndex  *big.Int
+	TotalBorrows *big.Int
+	BlockNumber  uint64
+	LogIndex     uint
+}
+
+// MockTokenAccrueInterestIterator provides a mechanism to iterate over a token's AccrueInterest events.
+type MockTokenAccrueInterestIterator struct {
+	AccrueInterestEvents []TokenAccrueInterest
+	Index                int
+}
+
+// tokenAddresses contains the Compound Token addresses on mainnet.
 var tokenAddresses = map[string]common.Address{
 	CBATSymbol:  common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e"),
 	CDAISymbol:  common.HexToAddress("0x5d3a536e4d6dbd6114cc1ead35777bab948e3643"),
@@ -98,42 +279,59 @@ var tokenAddresses = map[string]common.Address{
 }
 
 // NewToken creates a new token contract.
-func NewToken(tokenSymbol string, ethClient *ethclient.Client, logger *log.Logger) (Token, error) {
+func NewToken(tokenSymbol string, address common.Address, ethClient Backend, logger logging.Logger) (Token, error) {
 	var token Token
 	var err error
 
 	switch tokenSymbol {
 	case CBATSymbol:
-		token, err = NewCBAT(tokenAddresses[CBATSymbol], ethClient)
+		token, err = NewCBAT(address, ethClient)
 	case CDAISymbol:
-		token, err = NewCDAI(tokenAddresses[CDAISymbol], ethClient)
+		token, err = NewCDAI(address, ethClient)
 	case CETHSymbol:
-		token, err = NewCETH(tokenAddresses[CETHSymbol], ethClient)
+		token, err = NewCETH(address, ethClient)
 	case CREPSymbol:
-		token, err = NewCREP(tokenAddresses[CREPSymbol], ethClient)
+		token, err = NewCREP(address, ethClient)
 	case CSAISymbol:
-		token, err = NewCSAI(tokenAddresses[CSAISymbol], ethClient)
+		token, err = NewCSAI(address, ethClient)
 	case CUSDCSymbol:
-		token, err = NewCUSDC(tokenAddresses[CUSDCSymbol], ethClient)
+		token, err = NewCUSDC(address, ethClient)
 	case CWBTCSymbol:
-		token, err = NewCWBTC(tokenAddresses[CWBTCSymbol], ethClient)
+		token, err = NewCWBTC(address, ethClient)
 	case CZRXSymbol:
-		token, err = NewCZRX(tokenAddresses[CZRXSymbol], ethClient)
+		token, err = NewCZRX(address, ethClient)
+	default:
+		err = fmt.Errorf("unknown token %v", tokenSymbol)
 	}
 
 	if err != nil {
-		logger.Printf("Failed to instantiate %#v Token contract: %#v", tokenSymbol, err)
+		logger.Error("Failed to instantiate token contract", logging.Fields{logging.TokenField: tokenSymbol, logging.ErrorField: err})
 	}
 
 	return token, err
 }
 
-// NewTokenContracts creates a new TokenContracts.
-func NewTokenContracts(ethClient *ethclient.Client, logger *log.Logger, tokenFactory func(string, *ethclient.Client, *log.Logger) (Token, error)) (TokensProvider, error) {
+// GetTokenSymbol returns the symbol of the token at the address, or the address if the token is unknown.
+func GetTokenSymbol(tokenAddresses map[string]common.Address, address common.Address) string {
+	for tokenSymbol, tokenAddress := range tokenAddresses {
+		if tokenAddress == address {
+			return tokenSymbol
+		}
+	}
+	return address.Hex()
+}
+
+// NewUnderlyingToken creates a new underlying token contract.
+func NewUnderlyingToken(address common.Address, backend bind.ContractBackend) (UnderlyingToken, error) {
+	return NewERC20(address, backend)
+}
+
+// NewTokenContracts creates a new TokenContracts for the network's tokens.
+func NewTokenContracts(ethClient Backend, logger logging.Logger, network *Network, tokenFactory func(string, common.Address, Backend, logging.Logger) (Token, error)) (TokensProvider, error) {
 	tokens := make(map[string]Token)
-	tokenContracts := &TokenContracts{ethClient: ethClient, tokens: tokens}
-	for tokenSymbol := range tokenAddresses {
-		token, err := tokenFactory(tokenSymbol, ethClient, logger)
+	tokenContracts := &TokenContracts{ethClient: ethClient, tokens: tokens, addresses: network.TokenAddresses}
+	for tokenSymbol, address := range network.TokenAddresses {
+		token, err := tokenFactory(tokenSymbol, address, ethClient, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -143,10 +341,10 @@ func NewTokenContracts(ethClient *ethclient.Client, logger *log.Logger, tokenFac
 				logger.Fatalf("Failed to retrieve %#v token name: %#v", tokenSymbol, err)
 			}
 			tokens[tokenSymbol] = token
-			logger.Printf("Initialized token %#v (%#v)\n", name, tokenSymbol)
+			logger.Info("Initialized token", logging.Fields{logging.TokenField: tokenSymbol, "name": name})
 		}
 	}
-	logger.Printf("Initialized tokens collection: %#v\n", tokens)
+	logger.Info("Initialized tokens", logging.Fields{"tokens": len(tokens)})
 	return tokenContracts, nil
 }
 
@@ -157,7 +355,7 @@ func (c *TokenContracts) GetTokens() map[string]Token {
 
 // GetAddresses returns token contract addresses.
 func (c *TokenContracts) GetAddresses() map[string]common.Address {
-	return tokenAddresses
+	return c.addresses
 }
 
 // GetTokens returns token contracts.
@@ -180,6 +378,197 @@ func (t *MockToken) FilterBorrowEvents(opts *bind.FilterOpts) (TokenBorrowIterat
 	return t.TokenBorrowIterator, nil
 }
 
+// BorrowBalanceStored returns the account's borrow balance as of the last accrual.
+func (t *MockToken) BorrowBalanceStored(opts *bind.CallOpts, account common.Address) (*big.Int, error) {
+	balance, ok := t.BorrowBalances[account]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	return balance, nil
+}
+
+// GetAccountSnapshot returns the error code, token balance, borrow balance and exchange rate mantissa of the account.
+// The exchange rate is always 1.
+func (t *MockToken) GetAccountSnapshot(opts *bind.CallOpts, account common.Address) (*big.Int, *big.Int, *big.Int, *big.Int, error) {
+	tokenBalance, ok := t.CTokenBalances[account]
+	if !ok {
+		tokenBalance = big.NewInt(0)
+	}
+	borrowBalance, _ := t.BorrowBalanceStored(opts, account)
+	return big.NewInt(0), tokenBalance, borrowBalance, big.NewInt(1000000000000000000), nil
+}
+
+// BorrowIndex returns the borrow index mantissa as of the last accrual.
+func (t *MockToken) BorrowIndex(opts *bind.CallOpts) (*big.Int, error) {
+	if t.BorrowIndexMantissa == nil {
+		return big.NewInt(1000000000000000000), nil
+	}
+	return t.BorrowIndexMantissa, nil
+}
+
+// AccrualBlockNumber returns the block number of the last accrual.
+func (t *MockToken) AccrualBlockNumber(opts *bind.CallOpts) (*big.Int, error) {
+	return new(big.Int).SetUint64(t.AccrualBlock), nil
+}
+
+// BorrowRatePerBlock returns the borrow interest rate mantissa per block.
+func (t *MockToken) BorrowRatePerBlock(opts *bind.CallOpts) (*big.Int, error) {
+	if t.BorrowRateMantissa == nil {
+		return big.NewInt(0), nil
+	}
+	return t.BorrowRateMantissa, nil
+}
+
+// FilterAccrueInterestEvents returns the AccrueInterest events.
+func (t *MockToken) FilterAccrueInterestEvents(opts *bind.FilterOpts) (TokenAccrueInterestIterator, error) {
+	if t.TokenAccrueInterestIterator == nil {
+		return &MockTokenAccrueInterestIterator{}, nil
+	}
+	return t.TokenAccrueInterestIterator, nil
+}
+
+// TotalBorrows returns the total borrows of the market as of the last accrual.
+func (t *MockToken) TotalBorrows(opts *bind.CallOpts) (*big.Int, error) {
+	return mockAmount(t.Market.TotalBorrows), nil
+}
+
+// TotalSupply returns the total supply of cTokens.
+func (t *MockToken) TotalSupply(opts *bind.CallOpts) (*big.Int, error) {
+	return mockAmount(t.Market.TotalSupply), nil
+}
+
+// TotalReserves returns the total reserves of the market as of the last accrual.
+func (t *MockToken) TotalReserves(opts *bind.CallOpts) (*big.Int, error) {
+	return mockAmount(t.Market.TotalReserves), nil
+}
+
+// GetCash returns the underlying balance of the market.
+func (t *MockToken) GetCash(opts *bind.CallOpts) (*big.Int, error) {
+	return mockAmount(t.Market.Cash), nil
+}
+
+// ExchangeRateStored returns the exchange rate mantissa as of the last accrual.
+func (t *MockToken) ExchangeRateStored(opts *bind.CallOpts) (*big.Int, error) {
+	if t.Market.ExchangeRate == nil {
+		return big.NewInt(1000000000000000000), nil
+	}
+	return t.Market.ExchangeRate, nil
+}
+
+// SupplyRatePerBlock returns the supply interest rate mantissa per block.
+func (t *MockToken) SupplyRatePerBlock(opts *bind.CallOpts) (*big.Int, error) {
+	return mockAmount(t.Market.SupplyRate), nil
+}
+
+// ReserveFactorMantissa returns the fraction of interest set aside for reserves.
+func (t *MockToken) ReserveFactorMantissa(opts *bind.CallOpts) (*big.Int, error) {
+	return mockAmount(t.Market.ReserveFactor), nil
+}
+
+// InterestRateModel returns the address of the interest rate model.
+func (t *MockToken) InterestRateModel(opts *bind.CallOpts) (common.Address, error) {
+	return t.Market.InterestRateModel, nil
+}
+
+// LiquidateBorrow records the liquidation and returns its transaction.
+func (t *MockToken) LiquidateBorrow(opts *bind.TransactOpts, borrower common.Address, repayAmount *big.Int, cTokenCollateral common.Address) (*types.Transaction, error) {
+	t.Liquidations = append(t.Liquidations, MockLiquidation{
+		Borrower:         borrower,
+		RepayAmount:      repayAmount,
+		CTokenCollateral: cTokenCollateral,
+	})
+	return types.NewTransaction(opts.Nonce.Uint64(), cTokenCollateral, nil, 0, opts.GasPrice, nil), nil
+}
+
+// ParseBorrowEvent returns the Borrow event of the log.
+func (t *MockToken) ParseBorrowEvent(log types.Log) (TokenBorrow, error) {
+	event, ok := t.LogEvents[log.Index].(TokenBorrow)
+	if !ok {
+		return nil, fmt.Errorf("no Borrow event at log index %v", log.Index)
+	}
+	return event, nil
+}
+
+// ParseRepayBorrowEvent returns the RepayBorrow event of the log.
+func (t *MockToken) ParseRepayBorrowEvent(log types.Log) (TokenRepayBorrow, error) {
+	event, ok := t.LogEvents[log.Index].(TokenRepayBorrow)
+	if !ok {
+		return nil, fmt.Errorf("no RepayBorrow event at log index %v", log.Index)
+	}
+	return event, nil
+}
+
+// ParseLiquidateBorrowEvent returns the LiquidateBorrow event of the log.
+func (t *MockToken) ParseLiquidateBorrowEvent(log types.Log) (TokenLiquidateBorrow, error) {
+	event, ok := t.LogEvents[log.Index].(TokenLiquidateBorrow)
+	if !ok {
+		return nil, fmt.Errorf("no LiquidateBorrow event at log index %v", log.Index)
+	}
+	return event, nil
+}
+
+// ParseMintEvent returns the Mint event of the log.
+func (t *MockToken) ParseMintEvent(log types.Log) (TokenMint, error) {
+	event, ok := t.LogEvents[log.Index].(TokenMint)
+	if !ok {
+		return nil, fmt.Errorf("no Mint event at log index %v", log.Index)
+	}
+	return event, nil
+}
+
+// ParseRedeemEvent returns the Redeem event of the log.
+func (t *MockToken) ParseRedeemEvent(log types.Log) (TokenRedeem, error) {
+	event, ok := t.LogEvents[log.Index].(TokenRedeem)
+	if !ok {
+		return nil, fmt.Errorf("no Redeem event at log index %v", log.Index)
+	}
+	return event, nil
+}
+
+// ParseTransferEvent returns the Transfer event of the log.
+func (t *MockToken) ParseTransferEvent(log types.Log) (TokenTransfer, error) {
+	event, ok := t.LogEvents[log.Index].(TokenTransfer)
+	if !ok {
+		return nil, fmt.Errorf("no Transfer event at log index %v", log.Index)
+	}
+	return event, nil
+}
+
+// Underlying returns the address of the underlying token.
+func (t *MockCErc20Token) Underlying(opts *bind.CallOpts) (common.Address, error) {
+	return t.UnderlyingAddress, nil
+}
+
+// BalanceOf returns the balance of the owner.
+func (t *MockUnderlyingToken) BalanceOf(opts *bind.CallOpts, owner common.Address) (*big.Int, error) {
+	balance, ok := t.Balances[owner]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	return balance, nil
+}
+
+// Allowance returns the amount the spender may transfer from the owner.
+func (t *MockUnderlyingToken) Allowance(opts *bind.CallOpts, owner common.Address, spender common.Address) (*big.Int, error) {
+	allowance, ok := t.Allowances[owner][spender]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	return allowance, nil
+}
+
+// Approve sets the amount the spender may transfer from the sender.
+func (t *MockUnderlyingToken) Approve(opts *bind.TransactOpts, spender common.Address, amount *big.Int) (*types.Transaction, error) {
+	if t.Allowances == nil {
+		t.Allowances = make(map[common.Address]map[common.Address]*big.Int)
+	}
+	if t.Allowances[opts.From] == nil {
+		t.Allowances[opts.From] = make(map[common.Address]*big.Int)
+	}
+	t.Allowances[opts.From][spender] = amount
+	return types.NewTransaction(0, spender, common.Big0, 0, common.Big0, nil), nil
+}
+
 // Next advances the iterator to the subsequent event, returning whether there
 // are any more events found. In case of a retrieval or parsing error, false is
 // returned and Error() can be queried for the exact failure.
@@ -195,3 +584,42 @@ func (i *MockTokenBorrowIterator) GetEvent() TokenBorrow {
 	}
 	return i.BorrowEvents[i.Index-1]
 }
+
+// GetBorrowIndex returns the borrow index after the accrual.
+func (a *MockTokenAccrueInterest) GetBorrowIndex() *big.Int {
+	return a.BorrowIndex
+}
+
+// GetTotalBorrows returns the contract borrow amount after the accrual.
+func (a *MockTokenAccrueInterest) GetTotalBorrows() *big.Int {
+	return a.TotalBorrows
+}
+
+// GetBlockNumber returns the block number of the event.
+func (a *MockTokenAccrueInterest) GetBlockNumber() uint64 {
+	return a.BlockNumber
+}
+
+// GetLogIndex returns the index of the event in the block.
+func (a *MockTokenAccrueInterest) GetLogIndex() uint {
+	return a.LogIndex
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found.
+func (i *MockTokenAccrueInterestIterator) Next() bool {
+	i.Index++
+	return !(i.Index > len(i.AccrueInterestEvents))
+}
+
+// GetEvent returns the Event containing the contract specifics and raw log.
+func (i *MockTokenAccrueInterestIterator) GetEvent() TokenAccrueInterest {
+	return i.AccrueInterestEvents[i.Index-1]
+}
+
+func mockAmount(amount *big.Int) *big.Int {
+	if amount == nil {
+		return big.NewInt(0)
+	}
+	return amount
+}
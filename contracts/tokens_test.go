@@ -1,13 +1,14 @@
 package contracts
 
 import (
-	"log"
 	"reflect"
 	"testing"
 	"bytes"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/l3a0/carbon/logging"
 )
 
 func TestNewTokenContracts(t *testing.T) {
@@ -15,11 +16,11 @@ func TestNewTokenContracts(t *testing.T) {
 	// mockEthClient, _ := ethclient.Dial("https://mainnet.infura.io")
 	mockEthClient, _ := ethclient.Dial("/home/l3a0/.ethereum/geth.ipc")
 	var mockToken Token = &MockToken{}
-	tokenFactory := func(tokenSymbol string, ethClient *ethclient.Client, logger *log.Logger) (Token, error) {
+	tokenFactory := func(tokenSymbol string, address common.Address, ethClient Backend, logger logging.Logger) (Token, error) {
 		return mockToken, nil
 	}
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
 	type args struct {
 		ethClient *ethclient.Client
 	}
@@ -41,7 +42,7 @@ func TestNewTokenContracts(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Act
-			got, err := NewTokenContracts(tt.args.ethClient, logger, tokenFactory)
+			got, err := NewTokenContracts(tt.args.ethClient, logger, Mainnet, tokenFactory)
 			// Assert
 			if (err != nil) != tt.wantErr {
 				t.Errorf("NewTokenContracts() error = %v, wantErr %v", err, tt.wantErr)
@@ -62,11 +63,11 @@ func TestTokenContracts_GetTokens(t *testing.T) {
 		ethClient *ethclient.Client
 	}
 	mockToken := &MockToken{}
-	tokenFactory := func(tokenSymbol string, ethClient *ethclient.Client, logger *log.Logger) (Token, error) {
+	tokenFactory := func(tokenSymbol string, address common.Address, ethClient Backend, logger logging.Logger) (Token, error) {
 		return mockToken, nil
 	}
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
 	tests := []struct {
 		name   string
 		fields fields
@@ -91,7 +92,7 @@ func TestTokenContracts_GetTokens(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tokenContracts, err := NewTokenContracts(tt.fields.ethClient, logger, tokenFactory)
+			tokenContracts, err := NewTokenContracts(tt.fields.ethClient, logger, Mainnet, tokenFactory)
 			if err != nil {
 				t.Errorf("NewTokenContracts() error = %v", err)
 				return
@@ -109,7 +110,7 @@ func TestNewToken(t *testing.T) {
 	// mockEthClient, _ := ethclient.Dial("https://mainnet.infura.io")
 	mockEthClient, _ := ethclient.Dial("/home/l3a0/.ethereum/geth.ipc")
 	var buf bytes.Buffer
-	logger := log.New(&buf, "", 0)
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
 	type args struct {
 		tokenSymbol string
 		ethClient   *ethclient.Client
@@ -214,7 +215,7 @@ func TestNewToken(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewToken(tt.args.tokenSymbol, tt.args.ethClient, logger)
+			got, err := NewToken(tt.args.tokenSymbol, Mainnet.TokenAddresses[tt.args.tokenSymbol], tt.args.ethClient, logger)
 			if err != nil {
 				t.Fatalf("NewToken() error = %v", err)
 			}
@@ -237,6 +238,7 @@ func TestTokenContracts_GetAddresses(t *testing.T) {
 	type fields struct {
 		ethClient *ethclient.Client
 		tokens    map[string]Token
+		addresses map[string]common.Address
 	}
 	tests := []struct {
 		name   string
@@ -248,6 +250,7 @@ func TestTokenContracts_GetAddresses(t *testing.T) {
 			fields: fields{
 				ethClient: nil,
 				tokens: nil,
+				addresses: tokenAddresses,
 			},
 			want: tokenAddresses,
 		},
@@ -257,6 +260,7 @@ func TestTokenContracts_GetAddresses(t *testing.T) {
 			c := &TokenContracts{
 				ethClient: tt.fields.ethClient,
 				tokens:    tt.fields.tokens,
+				addresses: tt.fields.addresses,
 			}
 			if got := c.GetAddresses(); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("TokenContracts.GetAddresses() = %v, want %v", got, tt.want)
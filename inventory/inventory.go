@@ -0,0 +1,202 @@
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/transactions"
+)
+
+// Inventory tracks the liquidator's underlying token balances and the allowances given to token contracts.
+type Inventory interface {
+	Refresh(ctx context.Context) error
+	GetBalance(tokenSymbol string) *big.Int
+	CheckInventory(tokenSymbol string, repayAmount *big.Int) error
+	EnsureAllowance(ctx context.Context, tokenSymbol string, repayAmount *big.Int) error
+}
+
+// InsufficientInventoryError is returned when the liquidator does not hold enough of an underlying asset.
+type InsufficientInventoryError struct {
+	TokenSymbol string
+	Balance     *big.Int
+	Required    *big.Int
+}
+
+// BalanceReader reads Ether balances, e.g. ethclient.Client.
+type BalanceReader interface {
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+}
+
+// UnderlyingTokenFactory creates the binding of an underlying token.
+type UnderlyingTokenFactory func(address common.Address) (contracts.UnderlyingToken, error)
+
+// TokenInventory tracks inventory through the token contracts.
+type TokenInventory struct {
+	logger                 logging.Logger
+	tokens                 map[string]contracts.Token
+	tokenAddresses         map[string]common.Address
+	balanceReader          BalanceReader
+	underlyingTokenFactory UnderlyingTokenFactory
+	txManager              transactions.TxManager
+	owner                  common.Address
+	approveUnlimited       bool
+	underlyingTokens       map[string]contracts.UnderlyingToken
+	balances               map[string]*big.Int
+	mutex                  sync.RWMutex
+}
+
+// NewTokenInventory creates a new Inventory for the owner's account.
+// When approveUnlimited is set, allowances are raised to the maximum instead of the repay amount.
+func NewTokenInventory(
+	logger logging.Logger,
+	tokensProvider contracts.TokensProvider,
+	balanceReader BalanceReader,
+	underlyingTokenFactory UnderlyingTokenFactory,
+	txManager transactions.TxManager,
+	owner common.Address,
+	approveUnlimited bool) Inventory {
+	return &TokenInventory{
+		logger:                 logger,
+		tokens:                 tokensProvider.GetTokens(),
+		tokenAddresses:         tokensProvider.GetAddresses(),
+		balanceReader:          balanceReader,
+		underlyingTokenFactory: underlyingTokenFactory,
+		txManager:              txManager,
+		owner:                  owner,
+		approveUnlimited:       approveUnlimited,
+		underlyingTokens:       make(map[string]contracts.UnderlyingToken),
+		balances:               make(map[string]*big.Int),
+	}
+}
+
+// Error returns the error message.
+func (e *InsufficientInventoryError) Error() string {
+	return fmt.Sprintf("insufficient %v inventory: have %v, need %v", e.TokenSymbol, e.Balance, e.Required)
+}
+
+// Refresh reads the owner's balance of every underlying asset.
+func (inventory *TokenInventory) Refresh(ctx context.Context) error {
+	balances := make(map[string]*big.Int)
+	for tokenSymbol, token := range inventory.tokens {
+		underlyingToken, err := inventory.getUnderlyingToken(tokenSymbol, token)
+		if err != nil {
+			return err
+		}
+		var balance *big.Int
+		if underlyingToken == nil {
+			balance, err = inventory.balanceReader.BalanceAt(ctx, inventory.owner, nil)
+		} else {
+			balance, err = underlyingToken.BalanceOf(&bind.CallOpts{Context: ctx}, inventory.owner)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %v inventory: %v", tokenSymbol, err)
+		}
+		balances[tokenSymbol] = balance
+		inventory.logger.Debug("Inventory", logging.Fields{logging.TokenField: tokenSymbol, "balance": balance})
+	}
+	inventory.mutex.Lock()
+	inventory.balances = balances
+	inventory.mutex.Unlock()
+	return nil
+}
+
+// GetBalance returns the last read balance of the token's underlying asset.
+func (inventory *TokenInventory) GetBalance(tokenSymbol string) *big.Int {
+	inventory.mutex.RLock()
+	defer inventory.mutex.RUnlock()
+	balance, ok := inventory.balances[tokenSymbol]
+	if !ok {
+		return big.NewInt(0)
+	}
+	return balance
+}
+
+// CheckInventory returns an InsufficientInventoryError if the liquidator cannot repay the amount.
+func (inventory *TokenInventory) CheckInventory(tokenSymbol string, repayAmount *big.Int) error {
+	balance := inventory.GetBalance(tokenSymbol)
+	if balance.Cmp(repayAmount) < 0 {
+		err := &InsufficientInventoryError{
+			TokenSymbol: tokenSymbol,
+			Balance:     balance,
+			Required:    repayAmount,
+		}
+		inventory.logger.Warn("Not enough inventory for liquidation", logging.Fields{logging.ErrorField: err})
+		return err
+	}
+	return nil
+}
+
+// EnsureAllowance approves the token contract to transfer the repay amount of its underlying asset.
+func (inventory *TokenInventory) EnsureAllowance(ctx context.Context, tokenSymbol string, repayAmount *big.Int) error {
+	token, ok := inventory.tokens[tokenSymbol]
+	if !ok {
+		return fmt.Errorf("unknown token %v", tokenSymbol)
+	}
+	underlyingToken, err := inventory.getUnderlyingToken(tokenSymbol, token)
+	if err != nil {
+		return err
+	}
+	if underlyingToken == nil {
+		// Ether is sent along with the liquidation.
+		return nil
+	}
+	tokenAddress, ok := inventory.tokenAddresses[tokenSymbol]
+	if !ok {
+		return fmt.Errorf("unknown address for token %v", tokenSymbol)
+	}
+	allowance, err := underlyingToken.Allowance(&bind.CallOpts{Context: ctx}, inventory.owner, tokenAddress)
+	if err != nil {
+		return err
+	}
+	if allowance.Cmp(repayAmount) >= 0 {
+		return nil
+	}
+	amount := repayAmount
+	if inventory.approveUnlimited {
+		amount = math.MaxBig256
+	}
+	inventory.logger.Info("Approving underlying transfers", logging.Fields{logging.TokenField: tokenSymbol, "amount": amount, "allowance": allowance})
+	_, err = inventory.txManager.Send(ctx, fmt.Sprintf("approve %v", tokenSymbol), func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return underlyingToken.Approve(opts, tokenAddress, amount)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to approve %v: %v", tokenSymbol, err)
+	}
+	inventory.logger.Info("Approved underlying transfers", logging.Fields{logging.TokenField: tokenSymbol, "amount": amount})
+	return nil
+}
+
+// getUnderlyingToken returns the binding of the token's underlying asset, or nil for Ether.
+func (inventory *TokenInventory) getUnderlyingToken(tokenSymbol string, token contracts.Token) (contracts.UnderlyingToken, error) {
+	inventory.mutex.Lock()
+	defer inventory.mutex.Unlock()
+	underlyingToken, ok := inventory.underlyingTokens[tokenSymbol]
+	if ok {
+		return underlyingToken, nil
+	}
+	cErc20Token, ok := token.(contracts.CErc20Token)
+	if !ok {
+		inventory.underlyingTokens[tokenSymbol] = nil
+		return nil, nil
+	}
+	address, err := cErc20Token.Underlying(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v underlying: %v", tokenSymbol, err)
+	}
+	underlyingToken, err = inventory.underlyingTokenFactory(address)
+	if err != nil {
+		return nil, err
+	}
+	inventory.logger.Debug("Found underlying", logging.Fields{logging.TokenField: tokenSymbol, logging.AddressField: address.Hex()})
+	inventory.underlyingTokens[tokenSymbol] = underlyingToken
+	return underlyingToken, nil
+}
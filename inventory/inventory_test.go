@@ -0,0 +1,215 @@
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/transactions"
+)
+
+type mockBalanceReader struct {
+	balance *big.Int
+}
+
+func (reader *mockBalanceReader) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	return reader.balance, nil
+}
+
+func TestTokenInventory_CheckInventory(t *testing.T) {
+	// Arrange
+	owner := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	underlyingAddress := common.HexToAddress("0x0D8775F648430679A709E98d2b0Cb6250d2887EF")
+	underlyingToken := &contracts.MockUnderlyingToken{
+		Balances: map[common.Address]*big.Int{
+			owner: big.NewInt(100),
+		},
+	}
+	tokensProvider := &contracts.MockTokenContracts{
+		Contracts: map[string]contracts.Token{
+			contracts.CBATSymbol: &contracts.MockCErc20Token{UnderlyingAddress: underlyingAddress},
+			contracts.CETHSymbol: &contracts.MockToken{},
+		},
+	}
+	underlyingTokenFactory := func(address common.Address) (contracts.UnderlyingToken, error) {
+		if address != underlyingAddress {
+			t.Fatalf("underlyingTokenFactory(%v), want %v", address.Hex(), underlyingAddress.Hex())
+		}
+		return underlyingToken, nil
+	}
+	tests := []struct {
+		name        string
+		tokenSymbol string
+		repayAmount *big.Int
+		wantBalance *big.Int
+		wantErr     bool
+	}{
+		{
+			name:        "Should have enough underlying tokens.",
+			tokenSymbol: contracts.CBATSymbol,
+			repayAmount: big.NewInt(100),
+			wantBalance: big.NewInt(100),
+			wantErr:     false,
+		},
+		{
+			name:        "Should not have enough underlying tokens.",
+			tokenSymbol: contracts.CBATSymbol,
+			repayAmount: big.NewInt(101),
+			wantBalance: big.NewInt(100),
+			wantErr:     true,
+		},
+		{
+			name:        "Should have enough ether.",
+			tokenSymbol: contracts.CETHSymbol,
+			repayAmount: big.NewInt(1000),
+			wantBalance: big.NewInt(1000),
+			wantErr:     false,
+		},
+		{
+			name:        "Should not have inventory of unknown token.",
+			tokenSymbol: contracts.CZRXSymbol,
+			repayAmount: big.NewInt(1),
+			wantBalance: big.NewInt(0),
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			inventory := NewTokenInventory(
+				logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat),
+				tokensProvider,
+				&mockBalanceReader{balance: big.NewInt(1000)},
+				underlyingTokenFactory,
+				&transactions.MockTxManager{From: owner},
+				owner,
+				false)
+			// Act
+			err := inventory.Refresh(context.Background())
+			if err != nil {
+				t.Fatalf("inventory.Refresh() error = %v", err)
+			}
+			err = inventory.CheckInventory(tt.tokenSymbol, tt.repayAmount)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("inventory.CheckInventory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if _, ok := err.(*InsufficientInventoryError); tt.wantErr && !ok {
+				t.Errorf("inventory.CheckInventory() error = %T, want *InsufficientInventoryError", err)
+			}
+			got := inventory.GetBalance(tt.tokenSymbol)
+			if got.Cmp(tt.wantBalance) != 0 {
+				t.Errorf("inventory.GetBalance() = %v, want %v", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestTokenInventory_EnsureAllowance(t *testing.T) {
+	// Arrange
+	owner := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	cTokenAddress := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	type fields struct {
+		allowance        *big.Int
+		approveUnlimited bool
+	}
+	tests := []struct {
+		name          string
+		fields        fields
+		tokenSymbol   string
+		repayAmount   *big.Int
+		wantAllowance *big.Int
+		wantApprovals int
+	}{
+		{
+			name: "Should approve repay amount.",
+			fields: fields{
+				allowance: big.NewInt(0),
+			},
+			tokenSymbol:   contracts.CBATSymbol,
+			repayAmount:   big.NewInt(100),
+			wantAllowance: big.NewInt(100),
+			wantApprovals: 1,
+		},
+		{
+			name: "Should approve unlimited amount.",
+			fields: fields{
+				allowance:        big.NewInt(0),
+				approveUnlimited: true,
+			},
+			tokenSymbol:   contracts.CBATSymbol,
+			repayAmount:   big.NewInt(100),
+			wantAllowance: new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+			wantApprovals: 1,
+		},
+		{
+			name: "Should not approve when allowance is enough.",
+			fields: fields{
+				allowance: big.NewInt(100),
+			},
+			tokenSymbol:   contracts.CBATSymbol,
+			repayAmount:   big.NewInt(100),
+			wantAllowance: big.NewInt(100),
+			wantApprovals: 0,
+		},
+		{
+			name: "Should not approve ether.",
+			fields: fields{
+				allowance: big.NewInt(0),
+			},
+			tokenSymbol:   contracts.CETHSymbol,
+			repayAmount:   big.NewInt(100),
+			wantAllowance: big.NewInt(0),
+			wantApprovals: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			underlyingToken := &contracts.MockUnderlyingToken{
+				Allowances: map[common.Address]map[common.Address]*big.Int{
+					owner: {cTokenAddress: tt.fields.allowance},
+				},
+			}
+			tokensProvider := &contracts.MockTokenContracts{
+				Contracts: map[string]contracts.Token{
+					contracts.CBATSymbol: &contracts.MockCErc20Token{},
+					contracts.CETHSymbol: &contracts.MockToken{},
+				},
+				Addresses: map[string]common.Address{
+					contracts.CBATSymbol: cTokenAddress,
+					contracts.CETHSymbol: cTokenAddress,
+				},
+			}
+			txManager := &transactions.MockTxManager{From: owner}
+			var buf bytes.Buffer
+			inventory := NewTokenInventory(
+				logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat),
+				tokensProvider,
+				&mockBalanceReader{balance: big.NewInt(0)},
+				func(address common.Address) (contracts.UnderlyingToken, error) {
+					return underlyingToken, nil
+				},
+				txManager,
+				owner,
+				tt.fields.approveUnlimited)
+			// Act
+			err := inventory.EnsureAllowance(context.Background(), tt.tokenSymbol, tt.repayAmount)
+			// Assert
+			if err != nil {
+				t.Fatalf("inventory.EnsureAllowance() error = %v", err)
+			}
+			got, _ := underlyingToken.Allowance(nil, owner, cTokenAddress)
+			if got.Cmp(tt.wantAllowance) != 0 {
+				t.Errorf("underlyingToken.Allowance() = %v, want %v", got, tt.wantAllowance)
+			}
+			if len(txManager.Labels) != tt.wantApprovals {
+				t.Errorf("len(txManager.Labels) = %v, want %v", len(txManager.Labels), tt.wantApprovals)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package liquidation
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/l3a0/carbon/models"
+)
+
+// GetSeizableMarkets returns the markets whose collateral a liquidation of the account can seize:
+// the listed markets the account entered, with a collateral factor, whose seizes are not paused.
+func GetSeizableMarkets(opts *bind.CallOpts, account *models.Account, tokenAddresses map[string]common.Address, comptrollerService models.ComptrollerService) ([]string, error) {
+	markets := []string{}
+	for _, market := range account.Markets {
+		address, ok := tokenAddresses[market]
+		if !ok {
+			continue
+		}
+		comptrollerMarket, err := comptrollerService.Markets(opts, address)
+		if err != nil {
+			return nil, err
+		}
+		if !comptrollerMarket.IsListed || comptrollerMarket.CollateralFactorMantissa.Sign() <= 0 {
+			continue
+		}
+		if comptrollerService.IsActionPaused(market, models.ActionSeize) {
+			continue
+		}
+		markets = append(markets, market)
+	}
+	return markets, nil
+}
@@ -0,0 +1,73 @@
+package liquidation
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/models"
+)
+
+func TestGetSeizableMarkets(t *testing.T) {
+	// Arrange
+	tokenAddresses := map[string]common.Address{
+		contracts.CBATSymbol: common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e"),
+		contracts.CDAISymbol: common.HexToAddress("0x5d3a536e4d6dbd6114cc1ead35777bab948e3643"),
+		contracts.CETHSymbol: common.HexToAddress("0x4ddc2d193948926d02f9b1fe9e1daa0718270ed5"),
+	}
+	collateralFactor := big.NewInt(750000000000000000)
+	markets := map[common.Address]models.ComptrollerMarket{
+		tokenAddresses[contracts.CBATSymbol]: {IsListed: true, CollateralFactorMantissa: collateralFactor},
+		tokenAddresses[contracts.CDAISymbol]: {IsListed: true, CollateralFactorMantissa: big.NewInt(0)},
+		tokenAddresses[contracts.CETHSymbol]: {IsListed: true, CollateralFactorMantissa: collateralFactor},
+	}
+	tests := []struct {
+		name    string
+		markets []string
+		paused  map[string]bool
+		want    []string
+	}{
+		{
+			name:    "Should seize entered markets with collateral factor.",
+			markets: []string{contracts.CBATSymbol, contracts.CDAISymbol, contracts.CETHSymbol},
+			want:    []string{contracts.CBATSymbol, contracts.CETHSymbol},
+		},
+		{
+			name:    "Should not seize markets not entered.",
+			markets: []string{contracts.CETHSymbol},
+			want:    []string{contracts.CETHSymbol},
+		},
+		{
+			name:    "Should not seize unknown markets.",
+			markets: []string{contracts.CZRXSymbol},
+			want:    []string{},
+		},
+		{
+			name:    "Should not seize when seize is paused.",
+			markets: []string{contracts.CBATSymbol, contracts.CETHSymbol},
+			paused:  map[string]bool{models.ActionSeize: true},
+			want:    []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comptrollerService := &models.MockComptroller{
+				MarketsByAddress: markets,
+				Paused:           tt.paused,
+			}
+			account := &models.Account{Markets: tt.markets}
+			// Act
+			got, err := GetSeizableMarkets(nil, account, tokenAddresses, comptrollerService)
+			// Assert
+			if err != nil {
+				t.Fatalf("GetSeizableMarkets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSeizableMarkets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
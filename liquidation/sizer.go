@@ -0,0 +1,230 @@
+package liquidation
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+// ExpScale is the scale of Compound mantissas.
+var ExpScale = big.NewInt(1000000000000000000)
+
+// HeaderReader reads block headers, e.g. ethclient.Client.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// RepaySizer computes how much of a borrow to repay when liquidating an account.
+type RepaySizer interface {
+	Refresh(ctx context.Context) error
+	GetRepayAmount(ctx context.Context, tokenSymbol string, collateralSymbol string, borrower common.Address, maxRepayAmount *big.Int) (*big.Int, error)
+	GetSeizeTokens(ctx context.Context, tokenSymbol string, collateralSymbol string, repayAmount *big.Int) (*big.Int, error)
+}
+
+// ComptrollerRepaySizer sizes repays with the close factor of the Comptroller and the borrower's collateral.
+// The close factor and liquidation incentive are cached until a NewCloseFactor or NewLiquidationIncentive
+// event is emitted, which is checked once per Refresh.
+type ComptrollerRepaySizer struct {
+	logger                       logging.Logger
+	tokens                       map[string]contracts.Token
+	tokenAddresses               map[string]common.Address
+	comptrollerService           models.ComptrollerService
+	headerReader                 HeaderReader
+	closeFactorMantissa          *big.Int
+	liquidationIncentiveMantissa *big.Int
+	checkedBlock                 uint64
+	mutex                        sync.Mutex
+}
+
+// NewRepaySizer creates a new RepaySizer.
+func NewRepaySizer(
+	logger logging.Logger,
+	tokensProvider contracts.TokensProvider,
+	comptrollerService models.ComptrollerService,
+	headerReader HeaderReader) RepaySizer {
+	return &ComptrollerRepaySizer{
+		logger:             logger,
+		tokens:             tokensProvider.GetTokens(),
+		tokenAddresses:     tokensProvider.GetAddresses(),
+		comptrollerService: comptrollerService,
+		headerReader:       headerReader,
+	}
+}
+
+// Refresh reads the close factor and liquidation incentive again if they changed since the last refresh.
+func (sizer *ComptrollerRepaySizer) Refresh(ctx context.Context) error {
+	sizer.mutex.Lock()
+	defer sizer.mutex.Unlock()
+	return sizer.refresh(ctx)
+}
+
+// GetRepayAmount returns the largest amount of the borrower's token borrow that can be repaid in one liquidation
+// seizing the collateral token, capped at maxRepayAmount unless it is nil.
+// The repay is limited by the close factor and by the borrower's balance of the collateral token.
+func (sizer *ComptrollerRepaySizer) GetRepayAmount(ctx context.Context, tokenSymbol string, collateralSymbol string, borrower common.Address, maxRepayAmount *big.Int) (*big.Int, error) {
+	token, ok := sizer.tokens[tokenSymbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown token %v", tokenSymbol)
+	}
+	collateralToken, ok := sizer.tokens[collateralSymbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown token %v", collateralSymbol)
+	}
+	closeFactorMantissa, err := sizer.getCloseFactor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	opts := &bind.CallOpts{Context: ctx}
+	borrowBalance, err := token.BorrowBalanceStored(opts, borrower)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v borrow balance of %v: %v", tokenSymbol, borrower.Hex(), err)
+	}
+	errorCode, collateralBalance, _, _, err := collateralToken.GetAccountSnapshot(opts, borrower)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v balance of %v: %v", collateralSymbol, borrower.Hex(), err)
+	}
+	if errorCode.Sign() != 0 {
+		return nil, fmt.Errorf("failed to read %v balance of %v: error code %v", collateralSymbol, borrower.Hex(), errorCode)
+	}
+	repayAmount := mulExp(borrowBalance, closeFactorMantissa)
+	if maxRepayAmount != nil && repayAmount.Cmp(maxRepayAmount) > 0 {
+		repayAmount = new(big.Int).Set(maxRepayAmount)
+	}
+	if repayAmount.Sign() > 0 {
+		seizeTokens, err := sizer.GetSeizeTokens(ctx, tokenSymbol, collateralSymbol, repayAmount)
+		if err != nil {
+			return nil, err
+		}
+		if seizeTokens.Cmp(collateralBalance) > 0 {
+			// seized tokens are proportional to the repay, so scale the repay down to the collateral.
+			repayAmount.Mul(repayAmount, collateralBalance)
+			repayAmount.Quo(repayAmount, seizeTokens)
+			seizeTokens, err = sizer.GetSeizeTokens(ctx, tokenSymbol, collateralSymbol, repayAmount)
+			if err != nil {
+				return nil, err
+			}
+			if seizeTokens.Cmp(collateralBalance) > 0 {
+				return nil, fmt.Errorf("failed to fit %v repay of %v in %v collateral %v", tokenSymbol, borrower.Hex(), collateralSymbol, collateralBalance)
+			}
+		}
+	}
+	sizer.logger.Debug("Sized repay", logging.Fields{
+		logging.AddressField: borrower.Hex(),
+		logging.TokenField:   tokenSymbol,
+		"collateral":         collateralSymbol,
+		"repayAmount":        repayAmount,
+		"borrowBalance":      borrowBalance,
+		"collateralBalance":  collateralBalance,
+	})
+	return repayAmount, nil
+}
+
+// GetSeizeTokens returns the collateral tokens the Comptroller seizes for the repay amount of the token.
+func (sizer *ComptrollerRepaySizer) GetSeizeTokens(ctx context.Context, tokenSymbol string, collateralSymbol string, repayAmount *big.Int) (*big.Int, error) {
+	tokenAddress, ok := sizer.tokenAddresses[tokenSymbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown address for token %v", tokenSymbol)
+	}
+	collateralAddress, ok := sizer.tokenAddresses[collateralSymbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown address for token %v", collateralSymbol)
+	}
+	errorCode, seizeTokens, err := sizer.comptrollerService.LiquidateCalculateSeizeTokens(&bind.CallOpts{Context: ctx}, tokenAddress, collateralAddress, repayAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate %v seized for %v %v: %v", collateralSymbol, repayAmount, tokenSymbol, err)
+	}
+	if errorCode.Sign() != 0 {
+		return nil, fmt.Errorf("failed to calculate %v seized for %v %v: error code %v", collateralSymbol, repayAmount, tokenSymbol, errorCode)
+	}
+	return seizeTokens, nil
+}
+
+// getCloseFactor returns the cached close factor, refreshing it if it was never read.
+func (sizer *ComptrollerRepaySizer) getCloseFactor(ctx context.Context) (*big.Int, error) {
+	sizer.mutex.Lock()
+	defer sizer.mutex.Unlock()
+	if sizer.closeFactorMantissa == nil {
+		err := sizer.refresh(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sizer.closeFactorMantissa, nil
+}
+
+// refresh reads the close factor and liquidation incentive as of the latest block. The sizer must be locked.
+func (sizer *ComptrollerRepaySizer) refresh(ctx context.Context) error {
+	header, err := sizer.headerReader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	head := header.Number.Uint64()
+	if sizer.closeFactorMantissa != nil && head > sizer.checkedBlock {
+		changed, err := sizer.parametersChanged(ctx, sizer.checkedBlock+1, head)
+		if err != nil {
+			return err
+		}
+		if changed {
+			sizer.logger.Info("Comptroller parameters changed", logging.Fields{logging.BlockField: head})
+			sizer.closeFactorMantissa = nil
+			sizer.liquidationIncentiveMantissa = nil
+		}
+	}
+	if sizer.closeFactorMantissa == nil {
+		opts := &bind.CallOpts{Context: ctx, BlockNumber: header.Number}
+		closeFactorMantissa, err := sizer.comptrollerService.CloseFactorMantissa(opts)
+		if err != nil {
+			return err
+		}
+		liquidationIncentiveMantissa, err := sizer.comptrollerService.LiquidationIncentiveMantissa(opts)
+		if err != nil {
+			return err
+		}
+		sizer.closeFactorMantissa = closeFactorMantissa
+		sizer.liquidationIncentiveMantissa = liquidationIncentiveMantissa
+		sizer.logger.Info("Read Comptroller parameters", logging.Fields{"closeFactor": closeFactorMantissa, "liquidationIncentive": liquidationIncentiveMantissa, logging.BlockField: head})
+	}
+	if head > sizer.checkedBlock {
+		sizer.checkedBlock = head
+	}
+	return nil
+}
+
+// parametersChanged returns whether the close factor or liquidation incentive changed in the block range.
+func (sizer *ComptrollerRepaySizer) parametersChanged(ctx context.Context, start uint64, end uint64) (bool, error) {
+	filterOptions := &bind.FilterOpts{Start: start, End: &end, Context: ctx}
+	closeFactorEvents, err := sizer.comptrollerService.FilterNewCloseFactor(filterOptions)
+	if err != nil {
+		return false, err
+	}
+	changed, err := hasEvents(closeFactorEvents)
+	if err != nil || changed {
+		return changed, err
+	}
+	liquidationIncentiveEvents, err := sizer.comptrollerService.FilterNewLiquidationIncentive(filterOptions)
+	if err != nil {
+		return false, err
+	}
+	return hasEvents(liquidationIncentiveEvents)
+}
+
+func hasEvents(iter contracts.EventIterator) (bool, error) {
+	defer iter.Close()
+	found := iter.Next()
+	return found, iter.Error()
+}
+
+// mulExp multiplies the amount by the mantissa, truncating like Compound's mulScalarTruncate.
+func mulExp(amount *big.Int, mantissa *big.Int) *big.Int {
+	product := new(big.Int).Mul(amount, mantissa)
+	return product.Quo(product, ExpScale)
+}
@@ -0,0 +1,189 @@
+package liquidation
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+type mockHeaderReader struct {
+	head  int64
+	calls int
+}
+
+func (reader *mockHeaderReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	reader.calls++
+	return &types.Header{Number: big.NewInt(reader.head)}, nil
+}
+
+func TestComptrollerRepaySizer_GetRepayAmount(t *testing.T) {
+	// Arrange
+	borrower := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	tests := []struct {
+		name              string
+		tokenSymbol       string
+		collateralBalance int64
+		maxRepayAmount    *big.Int
+		want              *big.Int
+		wantErr           bool
+	}{
+		{
+			name:              "Should repay close factor of borrow balance.",
+			tokenSymbol:       contracts.CBATSymbol,
+			collateralBalance: 1000,
+			maxRepayAmount:    nil,
+			want:              big.NewInt(500),
+			wantErr:           false,
+		},
+		{
+			name:              "Should cap repay amount.",
+			tokenSymbol:       contracts.CBATSymbol,
+			collateralBalance: 1000,
+			maxRepayAmount:    big.NewInt(100),
+			want:              big.NewInt(100),
+			wantErr:           false,
+		},
+		{
+			name:              "Should cap repay amount at the seizable collateral.",
+			tokenSymbol:       contracts.CBATSymbol,
+			collateralBalance: 210,
+			maxRepayAmount:    nil,
+			want:              big.NewInt(200),
+			wantErr:           false,
+		},
+		{
+			name:              "Should not repay without collateral.",
+			tokenSymbol:       contracts.CBATSymbol,
+			collateralBalance: 0,
+			maxRepayAmount:    nil,
+			want:              big.NewInt(0),
+			wantErr:           false,
+		},
+		{
+			name:              "Should not repay unknown token.",
+			tokenSymbol:       contracts.CZRXSymbol,
+			collateralBalance: 1000,
+			maxRepayAmount:    nil,
+			want:              nil,
+			wantErr:           true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokensProvider := &contracts.MockTokenContracts{
+				Contracts: map[string]contracts.Token{
+					contracts.CBATSymbol: &contracts.MockToken{
+						BorrowBalances: map[common.Address]*big.Int{
+							borrower: big.NewInt(1001),
+						},
+					},
+					contracts.CUSDCSymbol: &contracts.MockToken{
+						CTokenBalances: map[common.Address]*big.Int{
+							borrower: big.NewInt(tt.collateralBalance),
+						},
+					},
+				},
+				Addresses: map[string]common.Address{
+					contracts.CBATSymbol:  common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e"),
+					contracts.CUSDCSymbol: common.HexToAddress("0x39aa39c021dfbae8fac545936693ac917d5e7563"),
+				},
+			}
+			var buf bytes.Buffer
+			sizer := NewRepaySizer(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), tokensProvider, &models.MockComptroller{}, &mockHeaderReader{head: 1})
+			// Act
+			got, err := sizer.GetRepayAmount(context.Background(), tt.tokenSymbol, contracts.CUSDCSymbol, borrower, tt.maxRepayAmount)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sizer.GetRepayAmount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && got.Cmp(tt.want) != 0 {
+				t.Errorf("sizer.GetRepayAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComptrollerRepaySizer_Refresh(t *testing.T) {
+	// Arrange
+	borrower := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	tokensProvider := &contracts.MockTokenContracts{
+		Contracts: map[string]contracts.Token{
+			contracts.CBATSymbol: &contracts.MockToken{
+				BorrowBalances: map[common.Address]*big.Int{
+					borrower: big.NewInt(1000),
+				},
+				CTokenBalances: map[common.Address]*big.Int{
+					borrower: big.NewInt(1000),
+				},
+			},
+		},
+		Addresses: map[string]common.Address{
+			contracts.CBATSymbol: common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e"),
+		},
+	}
+	comptrollerService := &models.MockComptroller{}
+	headerReader := &mockHeaderReader{head: 1}
+	var buf bytes.Buffer
+	sizer := NewRepaySizer(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), tokensProvider, comptrollerService, headerReader)
+	tests := []struct {
+		name            string
+		head            int64
+		closeFactor     *big.Int
+		newCloseFactors int
+		want            *big.Int
+	}{
+		{
+			name:            "Should read close factor.",
+			head:            1,
+			closeFactor:     big.NewInt(500000000000000000),
+			newCloseFactors: 0,
+			want:            big.NewInt(500),
+		},
+		{
+			name:            "Should cache close factor.",
+			head:            2,
+			closeFactor:     big.NewInt(200000000000000000),
+			newCloseFactors: 0,
+			want:            big.NewInt(500),
+		},
+		{
+			name:            "Should read close factor after NewCloseFactor.",
+			head:            3,
+			closeFactor:     big.NewInt(200000000000000000),
+			newCloseFactors: 1,
+			want:            big.NewInt(200),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headerReader.head = tt.head
+			comptrollerService.CloseFactor = tt.closeFactor
+			comptrollerService.NewCloseFactorIterator = &contracts.MockEventIterator{Events: tt.newCloseFactors}
+			// Act
+			err := sizer.Refresh(context.Background())
+			if err != nil {
+				t.Fatalf("sizer.Refresh() error = %v", err)
+			}
+			headerCalls := headerReader.calls
+			got, err := sizer.GetRepayAmount(context.Background(), contracts.CBATSymbol, contracts.CBATSymbol, borrower, nil)
+			// Assert
+			if err != nil {
+				t.Fatalf("sizer.GetRepayAmount() error = %v", err)
+			}
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("sizer.GetRepayAmount() = %v, want %v", got, tt.want)
+			}
+			if headerReader.calls != headerCalls {
+				t.Errorf("headerReader.calls = %v, want %v", headerReader.calls, headerCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,379 @@
+package liquidatorbot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/globalsign/mgo/bson"
+	"gopkg.in/cenkalti/backoff.v2"
+
+	"github.com/l3a0/carbon/accountsbot"
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/inventory"
+	"github.com/l3a0/carbon/liquidation"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+	"github.com/l3a0/carbon/transactions"
+)
+
+// BotType is the type of the LiquidatorBot's state.
+const BotType = "LiquidatorBot"
+
+// LiquidatorBot liquidates the stored accounts with a shortfall, largest shortfall first.
+type LiquidatorBot struct {
+	tokens             map[string]contracts.Token
+	tokenAddresses     map[string]common.Address
+	accountsService    models.AccountsService
+	botsService        models.BotsService
+	comptrollerService models.ComptrollerService
+	repaySizer         liquidation.RepaySizer
+	inventory          inventory.Inventory
+	txManager          transactions.TxManager
+	state              *BotState
+	baseLogger         logging.Logger
+	// logger adds the fields of the context of the running step, e.g. the cycle ID.
+	logger logging.Logger
+}
+
+// BotState represents the state of the bot.
+type BotState struct {
+	ShardKey             string
+	BotType              string
+	InstanceID           string
+	Version              int64
+	LastWakeTime         time.Time
+	LastSleepTime        time.Time
+	NumberOfLiquidations int64
+	LastLiquidationTime  time.Time
+}
+
+// Candidate is an account with a shortfall at the latest block.
+type Candidate struct {
+	Account   *models.Account
+	Shortfall *big.Int
+}
+
+// NewLiquidatorBot creates a new LiquidatorBot.
+func NewLiquidatorBot(
+	tokensProvider contracts.TokensProvider,
+	logger logging.Logger,
+	accountsService models.AccountsService,
+	botsService models.BotsService,
+	comptrollerService models.ComptrollerService,
+	repaySizer liquidation.RepaySizer,
+	inventory inventory.Inventory,
+	txManager transactions.TxManager) accountsbot.Bot {
+	return &LiquidatorBot{
+		tokens:             tokensProvider.GetTokens(),
+		tokenAddresses:     tokensProvider.GetAddresses(),
+		accountsService:    accountsService,
+		botsService:        botsService,
+		comptrollerService: comptrollerService,
+		repaySizer:         repaySizer,
+		inventory:          inventory,
+		txManager:          txManager,
+		baseLogger:         logger,
+		logger:             logger,
+	}
+}
+
+// GetShardKey returns the shard key.
+func (state *BotState) GetShardKey() string {
+	return state.ShardKey
+}
+
+// GetBotType returns the bot type.
+func (state *BotState) GetBotType() string {
+	return state.BotType
+}
+
+// GetInstanceID returns the bot instance ID.
+func (state *BotState) GetInstanceID() string {
+	return state.InstanceID
+}
+
+// GetVersion returns the version of the stored state.
+func (state *BotState) GetVersion() int64 {
+	return state.Version
+}
+
+// SetVersion sets the version of the stored state.
+func (state *BotState) SetVersion(version int64) {
+	state.Version = version
+}
+
+// String returns string representation of the state.
+func (state BotState) String() string {
+	value, _ := json.Marshal(&state)
+	return string(value)
+}
+
+// String returns string representation of the bot.
+func (bot LiquidatorBot) String() string {
+	return fmt.Sprintf("LiquidatorBot{%v}", bot.state)
+}
+
+// Wake gets the bot ready for work.
+func (bot *LiquidatorBot) Wake(ctx context.Context, statusChannel chan int) {
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Waking", nil)
+	bot.initializeState(ctx)
+	statusChannel <- 0
+}
+
+// Work liquidates the accounts in shortfall that the liquidator has the inventory to repay.
+func (bot *LiquidatorBot) Work(ctx context.Context, statusChannel chan int) {
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Working", nil)
+	err := bot.inventory.Refresh(ctx)
+	if err != nil {
+		bot.logger.Error("Failed to refresh inventory", logging.Fields{logging.ErrorField: err})
+		statusChannel <- 1
+		return
+	}
+	err = bot.repaySizer.Refresh(ctx)
+	if err != nil {
+		bot.logger.Error("Failed to refresh repay sizer", logging.Fields{logging.ErrorField: err})
+		statusChannel <- 1
+		return
+	}
+	err = bot.comptrollerService.RefreshPausedActions(ctx)
+	if err != nil {
+		bot.logger.Warn("Failed to refresh paused actions", logging.Fields{logging.ErrorField: err})
+	}
+	candidates := bot.findCandidates(ctx)
+	RankCandidates(candidates)
+	bot.logger.Info("Found accounts in shortfall", logging.Fields{"accounts": len(candidates)})
+	numberOfLiquidations := 0
+	for _, candidate := range candidates {
+		if bot.liquidate(ctx, candidate) {
+			numberOfLiquidations++
+		}
+	}
+	if numberOfLiquidations > 0 {
+		bot.state.NumberOfLiquidations += int64(numberOfLiquidations)
+		bot.state.LastLiquidationTime = time.Now()
+	}
+	bot.logger.Info("Liquidated accounts in shortfall", logging.Fields{"liquidated": numberOfLiquidations, "accounts": len(candidates)})
+	statusChannel <- 0
+}
+
+// Sleep saves the bot's state and lets it rest.
+func (bot *LiquidatorBot) Sleep(ctx context.Context, statusChannel chan int) {
+	bot.logger = bot.baseLogger.WithContext(ctx)
+	bot.logger.Info("Sleeping", nil)
+	bot.state.LastSleepTime = time.Now()
+	change := bson.M{
+		"$set": bson.M{
+			"lastsleeptime":        bot.state.LastSleepTime,
+			"numberofliquidations": bot.state.NumberOfLiquidations,
+			"lastliquidationtime":  bot.state.LastLiquidationTime,
+		},
+	}
+	operation := func() error {
+		err := bot.botsService.UpdateBotState(ctx, bot.state, change)
+		if err == models.ErrBotStateConflict {
+			return backoff.Permanent(err)
+		}
+		if err != nil {
+			bot.logger.Warn("Problem updating state", logging.Fields{logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		bot.logger.Panicf("Error updating record: %v", err)
+	}
+	bot.logger.Info("Updated state", logging.Fields{"shardKey": bot.state.ShardKey, "version": bot.state.Version})
+	statusChannel <- 0
+}
+
+// RankCandidates orders the candidates by descending shortfall, then by address.
+func RankCandidates(candidates []*Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		comparison := candidates[i].Shortfall.Cmp(candidates[j].Shortfall)
+		if comparison != 0 {
+			return comparison > 0
+		}
+		return candidates[i].Account.Address < candidates[j].Account.Address
+	})
+}
+
+func (bot *LiquidatorBot) initializeState(ctx context.Context) {
+	state := &BotState{}
+	err := bot.botsService.GetBotState(ctx, BotType, models.DefaultInstanceID, state)
+	if err != nil && err != models.ErrBotStateNotFound {
+		bot.logger.Panicf("Error finding bot state: %v", err)
+	}
+	var operation func() error
+	if err == models.ErrBotStateNotFound {
+		bot.logger.Info("Could not find existing state", logging.Fields{logging.ErrorField: err})
+		state.ShardKey = bson.NewObjectId().Hex()
+		state.BotType = BotType
+		state.InstanceID = models.DefaultInstanceID
+		state.LastWakeTime = time.Now()
+		operation = func() error {
+			return bot.botsService.CreateBotState(ctx, state)
+		}
+	} else {
+		state.LastWakeTime = time.Now()
+		change := bson.M{"$set": bson.M{"lastwaketime": state.LastWakeTime}}
+		operation = func() error {
+			err := bot.botsService.UpdateBotState(ctx, state, change)
+			if err == models.ErrBotStateConflict {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+	}
+	err = backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		bot.logger.Panicf("Error saving bot state: %v", err)
+	}
+	bot.state = state
+	bot.logger.Info("Initialized state", logging.Fields{"shardKey": bot.state.ShardKey, "version": bot.state.Version})
+}
+
+// findCandidates returns the stored accounts with a shortfall.
+func (bot *LiquidatorBot) findCandidates(ctx context.Context) []*Candidate {
+	accounts := []*models.Account{}
+	err := bot.accountsService.GetAccounts(ctx, &accounts)
+	if err != nil {
+		bot.logger.Error("Error finding accounts", logging.Fields{logging.ErrorField: err})
+		return nil
+	}
+	candidates := []*Candidate{}
+	for _, account := range accounts {
+		// the stored liquidity may be stale, so the shortfall is read at the latest block.
+		errorCode, _, shortfall, err := bot.comptrollerService.GetAccountLiquidity(&bind.CallOpts{Context: ctx}, common.HexToAddress(account.Address))
+		if err != nil {
+			bot.logger.Warn("Problem getting liquidity", logging.Fields{logging.AddressField: account.Address, logging.ErrorField: err})
+			continue
+		}
+		if errorCode.Sign() != 0 {
+			bot.logger.Warn("Problem getting liquidity", logging.Fields{logging.AddressField: account.Address, "errorCode": errorCode})
+			continue
+		}
+		if shortfall.Sign() > 0 {
+			candidates = append(candidates, &Candidate{Account: account, Shortfall: shortfall})
+		}
+	}
+	return candidates
+}
+
+// liquidate repays the first borrow the liquidator has inventory for, seizing the collateral allowing the largest repay.
+func (bot *LiquidatorBot) liquidate(ctx context.Context, candidate *Candidate) bool {
+	account := candidate.Account
+	borrower := common.HexToAddress(account.Address)
+	collateralMarkets, err := liquidation.GetSeizableMarkets(&bind.CallOpts{Context: ctx}, account, bot.tokenAddresses, bot.comptrollerService)
+	if err != nil {
+		bot.logger.Warn("Problem getting seizable markets", logging.Fields{logging.AddressField: account.Address, logging.ErrorField: err})
+		return false
+	}
+	if len(collateralMarkets) == 0 {
+		bot.logger.Info("Skipping account without seizable collateral", logging.Fields{logging.AddressField: account.Address, "markets": account.Markets})
+		return false
+	}
+	for _, tokenSymbol := range getBorrowedMarkets(account) {
+		repayAmount, collateralMarket := bot.sizeRepay(ctx, account, tokenSymbol, collateralMarkets)
+		if repayAmount == nil {
+			continue
+		}
+		err = bot.inventory.EnsureAllowance(ctx, tokenSymbol, repayAmount)
+		if err != nil {
+			bot.logger.Warn("Problem approving repay", logging.Fields{logging.AddressField: account.Address, logging.TokenField: tokenSymbol, logging.ErrorField: err})
+			continue
+		}
+		receipt, err := bot.sendLiquidation(ctx, tokenSymbol, borrower, repayAmount, collateralMarket)
+		if err != nil {
+			bot.logger.Error("Failed to liquidate", logging.Fields{
+				logging.AddressField: account.Address,
+				logging.TokenField:   tokenSymbol,
+				"repayAmount":        repayAmount,
+				logging.ErrorField:   err,
+			})
+			return false
+		}
+		bot.logger.Info("Liquidated account", logging.Fields{
+			logging.AddressField: account.Address,
+			logging.TokenField:   tokenSymbol,
+			logging.BlockField:   receipt.BlockNumber,
+			"repayAmount":        repayAmount,
+			"collateral":         collateralMarket,
+			"txHash":             receipt.TxHash.Hex(),
+		})
+		err = bot.inventory.Refresh(ctx)
+		if err != nil {
+			bot.logger.Warn("Failed to refresh inventory", logging.Fields{logging.ErrorField: err})
+		}
+		return true
+	}
+	bot.logger.Info("Skipping account without inventory to repay", logging.Fields{logging.AddressField: account.Address, "borrows": account.Borrows})
+	return false
+}
+
+// sizeRepay returns the largest repay of the token borrow over the collateral markets, and its collateral market.
+// The repay amount is nil if no collateral market allows a repay.
+func (bot *LiquidatorBot) sizeRepay(ctx context.Context, account *models.Account, tokenSymbol string, collateralMarkets []string) (*big.Int, string) {
+	var bestRepayAmount *big.Int
+	bestCollateralMarket := ""
+	for _, collateralMarket := range collateralMarkets {
+		repayAmount, err := bot.repaySizer.GetRepayAmount(ctx, tokenSymbol, collateralMarket, common.HexToAddress(account.Address), bot.inventory.GetBalance(tokenSymbol))
+		if err != nil {
+			bot.logger.Warn("Problem sizing repay", logging.Fields{
+				logging.AddressField: account.Address,
+				logging.TokenField:   tokenSymbol,
+				"collateral":         collateralMarket,
+				logging.ErrorField:   err,
+			})
+			continue
+		}
+		if repayAmount.Sign() > 0 && (bestRepayAmount == nil || repayAmount.Cmp(bestRepayAmount) > 0) {
+			bestRepayAmount = repayAmount
+			bestCollateralMarket = collateralMarket
+		}
+	}
+	return bestRepayAmount, bestCollateralMarket
+}
+
+// sendLiquidation sends the token's liquidateBorrow transaction, with the repay amount as value for Ether.
+func (bot *LiquidatorBot) sendLiquidation(ctx context.Context, tokenSymbol string, borrower common.Address, repayAmount *big.Int, collateralMarket string) (*types.Receipt, error) {
+	collateralAddress, ok := bot.tokenAddresses[collateralMarket]
+	if !ok {
+		return nil, fmt.Errorf("unknown address for token %v", collateralMarket)
+	}
+	label := fmt.Sprintf("liquidate %v %v", borrower.Hex(), tokenSymbol)
+	switch liquidator := bot.tokens[tokenSymbol].(type) {
+	case contracts.CErc20Liquidator:
+		return bot.txManager.Send(ctx, label, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			return liquidator.LiquidateBorrow(opts, borrower, repayAmount, collateralAddress)
+		})
+	case contracts.CEtherLiquidator:
+		return bot.txManager.Send(ctx, label, func(opts *bind.TransactOpts) (*types.Transaction, error) {
+			opts.Value = repayAmount
+			return liquidator.LiquidateBorrow(opts, borrower, collateralAddress)
+		})
+	}
+	return nil, fmt.Errorf("token %v does not support liquidations", tokenSymbol)
+}
+
+// getBorrowedMarkets returns the markets the account borrows from, in symbol order.
+func getBorrowedMarkets(account *models.Account) []string {
+	markets := []string{}
+	for tokenSymbol, borrows := range account.Borrows {
+		if borrows != nil && borrows.Sign() > 0 {
+			markets = append(markets, tokenSymbol)
+		}
+	}
+	sort.Strings(markets)
+	return markets
+}
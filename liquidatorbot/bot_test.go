@@ -0,0 +1,210 @@
+package liquidatorbot
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/inventory"
+	"github.com/l3a0/carbon/liquidation"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+	"github.com/l3a0/carbon/transactions"
+)
+
+func TestRankCandidates(t *testing.T) {
+	// Arrange
+	candidates := []*Candidate{
+		{Account: &models.Account{Address: "0xc"}, Shortfall: big.NewInt(10)},
+		{Account: &models.Account{Address: "0xb"}, Shortfall: big.NewInt(50)},
+		{Account: &models.Account{Address: "0xa"}, Shortfall: big.NewInt(10)},
+	}
+	// Act
+	RankCandidates(candidates)
+	// Assert
+	got := []string{}
+	for _, candidate := range candidates {
+		got = append(got, candidate.Account.Address)
+	}
+	want := []string{"0xb", "0xa", "0xc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RankCandidates() = %v, want %v", got, want)
+	}
+}
+
+func TestLiquidatorBot_Work(t *testing.T) {
+	// Arrange
+	owner := common.HexToAddress("0x000000000000000000000000000000000000bEEF")
+	small := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	large := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	healthy := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	cBAT := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	cUSDC := common.HexToAddress("0x39aa39c021dfbae8fac545936693ac917d5e7563")
+	cDAI := common.HexToAddress("0x5d3a536e4d6dbd6114cc1ead35777bab948e3643")
+	underlyingAddress := common.HexToAddress("0x0D8775F648430679A709E98d2b0Cb6250d2887EF")
+	borrows := map[string]*big.Int{contracts.CBATSymbol: big.NewInt(100)}
+	markets := []string{contracts.CUSDCSymbol, contracts.CDAISymbol}
+	tests := []struct {
+		name                 string
+		inventoryBalance     int64
+		cUSDCBalance         int64
+		cDAIBalance          int64
+		wantLiquidations     []contracts.MockLiquidation
+		wantNumberOfAccounts int64
+	}{
+		{
+			name:             "Should liquidate the largest shortfall first.",
+			inventoryBalance: 1000,
+			cUSDCBalance:     1000,
+			wantLiquidations: []contracts.MockLiquidation{
+				{Borrower: large, RepayAmount: big.NewInt(50), CTokenCollateral: cUSDC},
+				{Borrower: small, RepayAmount: big.NewInt(50), CTokenCollateral: cUSDC},
+			},
+			wantNumberOfAccounts: 2,
+		},
+		{
+			name:             "Should cap the repay at the inventory.",
+			inventoryBalance: 30,
+			cUSDCBalance:     1000,
+			wantLiquidations: []contracts.MockLiquidation{
+				{Borrower: large, RepayAmount: big.NewInt(30), CTokenCollateral: cUSDC},
+				{Borrower: small, RepayAmount: big.NewInt(30), CTokenCollateral: cUSDC},
+			},
+			wantNumberOfAccounts: 2,
+		},
+		{
+			name:                 "Should skip liquidations without inventory.",
+			inventoryBalance:     0,
+			cUSDCBalance:         1000,
+			wantLiquidations:     nil,
+			wantNumberOfAccounts: 0,
+		},
+		{
+			name:             "Should cap the repay at the collateral allowing the largest repay.",
+			inventoryBalance: 1000,
+			cUSDCBalance:     21,
+			cDAIBalance:      42,
+			wantLiquidations: []contracts.MockLiquidation{
+				{Borrower: large, RepayAmount: big.NewInt(40), CTokenCollateral: cDAI},
+				{Borrower: small, RepayAmount: big.NewInt(40), CTokenCollateral: cDAI},
+			},
+			wantNumberOfAccounts: 2,
+		},
+		{
+			name:                 "Should skip liquidations without collateral.",
+			inventoryBalance:     1000,
+			wantLiquidations:     nil,
+			wantNumberOfAccounts: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+			ctx := context.Background()
+			token := &contracts.MockCErc20Token{
+				MockToken: contracts.MockToken{
+					BorrowBalances: map[common.Address]*big.Int{
+						small: big.NewInt(100),
+						large: big.NewInt(100),
+					},
+				},
+				UnderlyingAddress: underlyingAddress,
+			}
+			tokensProvider := &contracts.MockTokenContracts{
+				Contracts: map[string]contracts.Token{
+					contracts.CBATSymbol:  token,
+					contracts.CUSDCSymbol: newCollateralToken(underlyingAddress, tt.cUSDCBalance, small, large),
+					contracts.CDAISymbol:  newCollateralToken(underlyingAddress, tt.cDAIBalance, small, large),
+				},
+				Addresses: map[string]common.Address{
+					contracts.CBATSymbol:  cBAT,
+					contracts.CUSDCSymbol: cUSDC,
+					contracts.CDAISymbol:  cDAI,
+				},
+			}
+			accountsService := &models.MockAccountsService{
+				Accounts: map[string]*models.Account{
+					small.Hex():   {Address: small.Hex(), Borrows: borrows, Markets: markets},
+					large.Hex():   {Address: large.Hex(), Borrows: borrows, Markets: markets},
+					healthy.Hex(): {Address: healthy.Hex(), Borrows: borrows, Markets: markets},
+				},
+			}
+			comptrollerService := &models.MockComptroller{
+				Accounts: map[common.Address]*models.MockComptrollerAccount{
+					small:   {Shortfall: big.NewInt(10)},
+					large:   {Shortfall: big.NewInt(50)},
+					healthy: {Liquidity: big.NewInt(10)},
+				},
+				MarketsByAddress: map[common.Address]models.ComptrollerMarket{
+					cUSDC: {IsListed: true, CollateralFactorMantissa: big.NewInt(750000000000000000)},
+					cDAI:  {IsListed: true, CollateralFactorMantissa: big.NewInt(750000000000000000)},
+				},
+			}
+			botsService := &models.MockBotsService{
+				CollectionFactory: &models.MockCollectionFactory{Collection: &models.MockCollection{}},
+			}
+			txManager := &transactions.MockTxManager{From: owner}
+			underlyingToken := &contracts.MockUnderlyingToken{
+				Balances: map[common.Address]*big.Int{owner: big.NewInt(tt.inventoryBalance)},
+			}
+			tokenInventory := inventory.NewTokenInventory(
+				logger,
+				tokensProvider,
+				nil,
+				func(address common.Address) (contracts.UnderlyingToken, error) {
+					return underlyingToken, nil
+				},
+				txManager,
+				owner,
+				false)
+			repaySizer := liquidation.NewRepaySizer(logger, tokensProvider, comptrollerService, &models.MockHeaderReader{Head: 1})
+			bot := NewLiquidatorBot(tokensProvider, logger, accountsService, botsService, comptrollerService, repaySizer, tokenInventory, txManager)
+			statusChannel := make(chan int)
+			// Act
+			go bot.Wake(ctx, statusChannel)
+			wakeStatus := <-statusChannel
+			go bot.Work(ctx, statusChannel)
+			workStatus := <-statusChannel
+			go bot.Sleep(ctx, statusChannel)
+			sleepStatus := <-statusChannel
+			// Assert
+			if wakeStatus != 0 || workStatus != 0 || sleepStatus != 0 {
+				t.Errorf("statuses = %v %v %v, want %v %v %v", wakeStatus, workStatus, sleepStatus, 0, 0, 0)
+			}
+			if !reflect.DeepEqual(token.Liquidations, tt.wantLiquidations) {
+				t.Errorf("token.Liquidations = %v, want %v", token.Liquidations, tt.wantLiquidations)
+			}
+			state := &BotState{}
+			err := botsService.GetBotState(ctx, BotType, models.DefaultInstanceID, state)
+			if err != nil {
+				t.Fatalf("botsService.GetBotState() error = %v", err)
+			}
+			if state.Version != 1 {
+				t.Errorf("state.Version = %v, want %v", state.Version, 1)
+			}
+			if bot.(*LiquidatorBot).state.NumberOfLiquidations != tt.wantNumberOfAccounts {
+				t.Errorf("state.NumberOfLiquidations = %v, want %v", bot.(*LiquidatorBot).state.NumberOfLiquidations, tt.wantNumberOfAccounts)
+			}
+		})
+	}
+}
+
+// newCollateralToken returns a token in which each account has the balance of cTokens.
+func newCollateralToken(underlyingAddress common.Address, balance int64, accounts ...common.Address) contracts.Token {
+	token := &contracts.MockCErc20Token{
+		MockToken: contracts.MockToken{
+			CTokenBalances: map[common.Address]*big.Int{},
+		},
+		UnderlyingAddress: underlyingAddress,
+	}
+	for _, account := range accounts {
+		token.CTokenBalances[account] = big.NewInt(balance)
+	}
+	return token
+}
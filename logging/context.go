@@ -0,0 +1,41 @@
+package logging
+
+import "context"
+
+const (
+	// ComponentField is the part of the program writing the message, e.g. AccountsBot.
+	ComponentField = "component"
+
+	// CycleField is the ID of the bot cycle writing the message.
+	CycleField = "cycle"
+
+	// TokenField is the symbol of a token.
+	TokenField = "token"
+
+	// BlockField is a block number.
+	BlockField = "block"
+
+	// AddressField is the address of an account.
+	AddressField = "address"
+
+	// ErrorField is an error.
+	ErrorField = "error"
+)
+
+type fieldsKey struct{}
+
+// WithFields returns a context whose loggers add the fields to every message.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	parent, _ := ctx.Value(fieldsKey{}).(Fields)
+	return context.WithValue(ctx, fieldsKey{}, mergeFields(parent, fields))
+}
+
+// WithCycleID returns a context whose loggers add the cycle ID to every message.
+func WithCycleID(ctx context.Context, cycleID string) context.Context {
+	return WithFields(ctx, Fields{CycleField: cycleID})
+}
+
+// Component returns a logger for the part of the program, e.g. AccountsBot.
+func Component(logger Logger, name string) Logger {
+	return logger.With(Fields{ComponentField: name})
+}
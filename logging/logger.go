@@ -0,0 +1,248 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is the severity of a message.
+type Level int
+
+const (
+	// DebugLevel is for detailed messages, e.g. per account, disabled by default.
+	DebugLevel Level = iota
+
+	// InfoLevel is for the progress of the bots.
+	InfoLevel
+
+	// WarnLevel is for problems the bots recover from.
+	WarnLevel
+
+	// ErrorLevel is for failures.
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// String returns the name of the level.
+func (level Level) String() string {
+	if level < DebugLevel || level > ErrorLevel {
+		return fmt.Sprintf("level(%d)", level)
+	}
+	return levelNames[level]
+}
+
+// ParseLevel returns the level with the name, e.g. info.
+func ParseLevel(name string) (Level, error) {
+	for i, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return Level(i), nil
+		}
+	}
+	return InfoLevel, fmt.Errorf("unknown log level %v", name)
+}
+
+// Format is the encoding of messages.
+type Format int
+
+const (
+	// JSONFormat writes a JSON object per message.
+	JSONFormat Format = iota
+
+	// TextFormat writes the message followed by its fields, e.g. for tests and terminals.
+	TextFormat
+)
+
+// ParseFormat returns the format with the name, json or text.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return JSONFormat, nil
+	case "text":
+		return TextFormat, nil
+	}
+	return JSONFormat, fmt.Errorf("unknown log format %v", name)
+}
+
+// Fields are the structured context of a message, e.g. token, block and address.
+type Fields map[string]interface{}
+
+// Logger writes leveled messages with fields.
+// Panicf and Fatalf write formatted error messages before panicking or exiting.
+type Logger interface {
+	Debug(msg string, fields Fields)
+	Info(msg string, fields Fields)
+	Warn(msg string, fields Fields)
+	Error(msg string, fields Fields)
+	With(fields Fields) Logger
+	WithContext(ctx context.Context) Logger
+	Enabled(level Level) bool
+	Panicf(format string, v ...interface{})
+	Fatalf(format string, v ...interface{})
+}
+
+// StructuredLogger is a Logger writing to an io.Writer.
+type StructuredLogger struct {
+	output *output
+	level  Level
+	format Format
+	fields Fields
+	now    func() time.Time
+	exit   func(code int)
+}
+
+// output serializes the writes of a logger and the loggers derived from it.
+type output struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+// NewLogger creates a new Logger writing the messages at or above the level.
+func NewLogger(writer io.Writer, level Level, format Format) Logger {
+	return &StructuredLogger{
+		output: &output{writer: writer},
+		level:  level,
+		format: format,
+		fields: Fields{},
+		now:    time.Now,
+		exit:   os.Exit,
+	}
+}
+
+// Debug writes a debug message.
+func (logger *StructuredLogger) Debug(msg string, fields Fields) {
+	logger.write(DebugLevel, msg, fields)
+}
+
+// Info writes an info message.
+func (logger *StructuredLogger) Info(msg string, fields Fields) {
+	logger.write(InfoLevel, msg, fields)
+}
+
+// Warn writes a warning message.
+func (logger *StructuredLogger) Warn(msg string, fields Fields) {
+	logger.write(WarnLevel, msg, fields)
+}
+
+// Error writes an error message.
+func (logger *StructuredLogger) Error(msg string, fields Fields) {
+	logger.write(ErrorLevel, msg, fields)
+}
+
+// With returns a logger adding the fields to every message.
+func (logger *StructuredLogger) With(fields Fields) Logger {
+	child := *logger
+	child.fields = mergeFields(logger.fields, fields)
+	return &child
+}
+
+// WithContext returns a logger adding the fields of the context, e.g. the cycle ID.
+func (logger *StructuredLogger) WithContext(ctx context.Context) Logger {
+	fields, ok := ctx.Value(fieldsKey{}).(Fields)
+	if !ok {
+		return logger
+	}
+	return logger.With(fields)
+}
+
+// Enabled returns whether messages at the level are written.
+func (logger *StructuredLogger) Enabled(level Level) bool {
+	return level >= logger.level
+}
+
+// Panicf writes a formatted error message and panics.
+func (logger *StructuredLogger) Panicf(format string, v ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintf(format, v...), "\n")
+	logger.write(ErrorLevel, msg, nil)
+	panic(msg)
+}
+
+// Fatalf writes a formatted error message and exits.
+func (logger *StructuredLogger) Fatalf(format string, v ...interface{}) {
+	logger.write(ErrorLevel, strings.TrimSuffix(fmt.Sprintf(format, v...), "\n"), nil)
+	logger.exit(1)
+}
+
+func (logger *StructuredLogger) write(level Level, msg string, fields Fields) {
+	if !logger.Enabled(level) {
+		return
+	}
+	fields = mergeFields(logger.fields, fields)
+	var line []byte
+	if logger.format == TextFormat {
+		line = formatText(msg, fields)
+	} else {
+		line = formatJSON(logger.now(), level, msg, fields)
+	}
+	logger.output.mutex.Lock()
+	defer logger.output.mutex.Unlock()
+	logger.output.writer.Write(line)
+}
+
+func mergeFields(parent Fields, fields Fields) Fields {
+	merged := make(Fields, len(parent)+len(fields))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+	return merged
+}
+
+// formatJSON writes the message as a JSON object. Fields named time, level or msg are overwritten.
+func formatJSON(now time.Time, level Level, msg string, fields Fields) []byte {
+	entry := make(map[string]interface{}, len(fields)+3)
+	for key, value := range fields {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[key] = value
+	}
+	entry["time"] = now.UTC().Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["msg"] = msg
+	line, err := json.Marshal(entry)
+	if err != nil {
+		// fields without a JSON encoding are written as text.
+		for key, value := range fields {
+			entry[key] = fmt.Sprint(value)
+		}
+		line, _ = json.Marshal(entry)
+	}
+	return append(line, '\n')
+}
+
+// formatText writes the message followed by the fields sorted by key, with the component as a prefix.
+func formatText(msg string, fields Fields) []byte {
+	var buf bytes.Buffer
+	if component, ok := fields[ComponentField]; ok {
+		fmt.Fprintf(&buf, "%v | ", component)
+	}
+	buf.WriteString(msg)
+	keys := []string{}
+	for key := range fields {
+		if key != ComponentField {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprint(fields[key])
+		if value == "" || strings.ContainsAny(value, " \"=\n") {
+			value = strconv.Quote(value)
+		}
+		fmt.Fprintf(&buf, " %v=%v", key, value)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestStructuredLogger(t *testing.T) {
+	// Arrange
+	now := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		level  Level
+		format Format
+		log    func(logger Logger)
+		want   string
+	}{
+		{
+			name:   "Should write JSON with fields.",
+			level:  InfoLevel,
+			format: JSONFormat,
+			log: func(logger Logger) {
+				logger.Info("Liquidated account", Fields{AddressField: "0x1", "repayAmount": big.NewInt(50), ErrorField: errors.New("reverted")})
+			},
+			want: `{"address":"0x1","error":"reverted","level":"info","msg":"Liquidated account","repayAmount":50,"time":"2020-04-01T12:00:00Z"}` + "\n",
+		},
+		{
+			name:   "Should skip messages below the level.",
+			level:  InfoLevel,
+			format: TextFormat,
+			log: func(logger Logger) {
+				logger.Debug("Got liquidity", Fields{AddressField: "0x1"})
+				logger.Warn("Problem getting liquidity", Fields{AddressField: "0x2"})
+			},
+			want: "Problem getting liquidity address=0x2\n",
+		},
+		{
+			name:   "Should add the fields of the logger and the context.",
+			level:  DebugLevel,
+			format: TextFormat,
+			log: func(logger Logger) {
+				ctx := WithCycleID(context.Background(), "AccountsBot-1")
+				Component(logger, "AccountsBot").WithContext(ctx).Debug("Got liquidity", Fields{AddressField: "0x1", "shortfall": 0})
+			},
+			want: "AccountsBot | Got liquidity address=0x1 cycle=AccountsBot-1 shortfall=0\n",
+		},
+		{
+			name:   "Should add the fields of the logger to the fields of the message.",
+			level:  InfoLevel,
+			format: TextFormat,
+			log: func(logger Logger) {
+				logger.With(Fields{TokenField: "CDAI"}).Info("Snapshotted market", Fields{BlockField: 2})
+			},
+			want: "Snapshotted market block=2 token=CDAI\n",
+		},
+		{
+			name:   "Should quote text values with spaces.",
+			level:  InfoLevel,
+			format: TextFormat,
+			log: func(logger Logger) {
+				logger.Error("Failed", Fields{ErrorField: errors.New("execution reverted"), "label": ""})
+			},
+			want: "Failed error=\"execution reverted\" label=\"\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(&buf, tt.level, tt.format)
+			logger.(*StructuredLogger).now = func() time.Time { return now }
+			// Act
+			tt.log(logger)
+			// Assert
+			if got := buf.String(); got != tt.want {
+				t.Errorf("buf.String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructuredLogger_Panicf(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := NewLogger(&buf, ErrorLevel, TextFormat)
+	defer func() {
+		// Assert
+		if recovered := recover(); recovered != "Problem getting liquidity" {
+			t.Errorf("recover() = %v, want %v", recovered, "Problem getting liquidity")
+		}
+		if got := buf.String(); got != "Problem getting liquidity\n" {
+			t.Errorf("buf.String() = %q, want %q", got, "Problem getting liquidity\n")
+		}
+	}()
+	// Act
+	logger.Panicf("Problem getting liquidity\n")
+}
+
+func TestParseLevel(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name    string
+		want    Level
+		wantErr bool
+	}{
+		{name: "debug", want: DebugLevel},
+		{name: "WARN", want: WarnLevel},
+		{name: "verbose", want: InfoLevel, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := ParseLevel(tt.name)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLevel() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+
+	"github.com/l3a0/carbon/models"
+)
+
+// InstrumentedCollectionFactory creates collections recording the latency and retries of their operations.
+type InstrumentedCollectionFactory struct {
+	factory models.CollectionFactory
+	metrics *Metrics
+	// failed holds the collection operations whose last call failed.
+	failed map[string]bool
+	mutex  sync.Mutex
+}
+
+// InstrumentedCollection records the latency and retries of the operations of a Collection.
+type InstrumentedCollection struct {
+	collection models.Collection
+	factory    *InstrumentedCollectionFactory
+}
+
+// NewInstrumentedCollectionFactory creates a new CollectionFactory recording the operations of the factory's collections.
+func NewInstrumentedCollectionFactory(factory models.CollectionFactory, metrics *Metrics) models.CollectionFactory {
+	return &InstrumentedCollectionFactory{
+		factory: factory,
+		metrics: metrics,
+		failed:  make(map[string]bool),
+	}
+}
+
+// CreateCollection creates the collection.
+func (factory *InstrumentedCollectionFactory) CreateCollection(ctx context.Context, name string) (models.Collection, error) {
+	start := time.Now()
+	collection, err := factory.factory.CreateCollection(ctx, name)
+	factory.observe(name, "create_collection", start, err)
+	if err != nil {
+		return nil, err
+	}
+	return &InstrumentedCollection{
+		collection: collection,
+		factory:    factory,
+	}, nil
+}
+
+// observe records an operation on the collection.
+// Callers retry failed storage operations with backoff, so a call after a failed call of the same operation is a retry.
+func (factory *InstrumentedCollectionFactory) observe(collectionName string, operation string, start time.Time, err error) {
+	factory.metrics.StorageDuration.Observe(Since(start), collectionName, operation)
+	// missing documents are answers, not failures.
+	failed := err != nil && err != mgo.ErrNotFound
+	key := collectionName + "/" + operation
+	factory.mutex.Lock()
+	retry := factory.failed[key]
+	if failed {
+		factory.failed[key] = true
+	} else {
+		delete(factory.failed, key)
+	}
+	factory.mutex.Unlock()
+	if retry {
+		factory.metrics.StorageRetries.Add(1, collectionName, operation)
+	}
+}
+
+// FindOne returns the first record matching the query.
+func (collection *InstrumentedCollection) FindOne(query interface{}, result interface{}) error {
+	start := time.Now()
+	err := collection.collection.FindOne(query, result)
+	collection.factory.observe(collection.GetName(), "find_one", start, err)
+	return err
+}
+
+// FindAll returns all records matching the query.
+func (collection *InstrumentedCollection) FindAll(query interface{}, result interface{}) error {
+	start := time.Now()
+	err := collection.collection.FindAll(query, result)
+	collection.factory.observe(collection.GetName(), "find_all", start, err)
+	return err
+}
+
+// Create the record.
+func (collection *InstrumentedCollection) Create(doc interface{}) error {
+	start := time.Now()
+	err := collection.collection.Create(doc)
+	collection.factory.observe(collection.GetName(), "create", start, err)
+	return err
+}
+
+// Update the record.
+func (collection *InstrumentedCollection) Update(selector interface{}, update interface{}) error {
+	start := time.Now()
+	err := collection.collection.Update(selector, update)
+	collection.factory.observe(collection.GetName(), "update", start, err)
+	return err
+}
+
+// Upsert updates or creates the record.
+func (collection *InstrumentedCollection) Upsert(selector interface{}, update interface{}) (info *mgo.ChangeInfo, err error) {
+	start := time.Now()
+	info, err = collection.collection.Upsert(selector, update)
+	collection.factory.observe(collection.GetName(), "upsert", start, err)
+	return info, err
+}
+
+// GetName returns the collection name.
+func (collection *InstrumentedCollection) GetName() string {
+	return collection.collection.GetName()
+}
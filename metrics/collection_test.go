@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/globalsign/mgo"
+
+	"github.com/l3a0/carbon/models"
+)
+
+type failingCollection struct {
+	models.MockCollection
+	errs []error
+}
+
+func (collection *failingCollection) FindOne(query interface{}, result interface{}) error {
+	err := collection.errs[0]
+	collection.errs = collection.errs[1:]
+	return err
+}
+
+func (collection *failingCollection) GetName() string {
+	return "bots"
+}
+
+type failingCollectionFactory struct {
+	collection *failingCollection
+}
+
+func (factory *failingCollectionFactory) CreateCollection(ctx context.Context, name string) (models.Collection, error) {
+	return factory.collection, nil
+}
+
+func TestInstrumentedCollection_FindOne(t *testing.T) {
+	// Arrange
+	failure := errors.New("request rate is large")
+	tests := []struct {
+		name        string
+		errs        []error
+		wantRetries string
+	}{
+		{
+			name:        "Should not count retries without failures.",
+			errs:        []error{nil, nil},
+			wantRetries: "",
+		},
+		{
+			name:        "Should count the calls after failures.",
+			errs:        []error{failure, failure, nil, nil},
+			wantRetries: "carbon_storage_retries_total{collection=\"bots\",operation=\"find_one\"} 2\n",
+		},
+		{
+			name:        "Should not count missing documents as failures.",
+			errs:        []error{mgo.ErrNotFound, nil},
+			wantRetries: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			factory := NewInstrumentedCollectionFactory(&failingCollectionFactory{&failingCollection{errs: tt.errs}}, NewMetrics(registry))
+			collection, err := factory.CreateCollection(context.Background(), "bots")
+			if err != nil {
+				t.Fatalf("factory.CreateCollection() error = %v", err)
+			}
+			// Act
+			for i, want := range tt.errs {
+				if err := collection.FindOne(nil, nil); err != want {
+					t.Errorf("collection.FindOne() #%v error = %v, want %v", i, err, want)
+				}
+			}
+			// Assert
+			var buf bytes.Buffer
+			registry.Write(&buf)
+			gotRetries := ""
+			for _, line := range strings.SplitAfter(buf.String(), "\n") {
+				if strings.HasPrefix(line, "carbon_storage_retries_total{") {
+					gotRetries += line
+				}
+			}
+			if gotRetries != tt.wantRetries {
+				t.Errorf("retries = %q, want %q", gotRetries, tt.wantRetries)
+			}
+			if !strings.Contains(buf.String(), "carbon_storage_duration_seconds_count{collection=\"bots\",operation=\"find_one\"} "+strconv.Itoa(len(tt.errs))+"\n") {
+				t.Errorf("durations = %v, want %v find_one calls", buf.String(), len(tt.errs))
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/contracts"
+)
+
+// EthClient is the part of ethclient.Client used by the bots.
+type EthClient interface {
+	contracts.Backend
+	ChainID(ctx context.Context) (*big.Int, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// InstrumentedEthClient records the latency and errors of the calls of an EthClient.
+type InstrumentedEthClient struct {
+	client  EthClient
+	metrics *Metrics
+}
+
+// NewInstrumentedEthClient creates a new EthClient recording the calls of the client.
+func NewInstrumentedEthClient(client EthClient, metrics *Metrics) EthClient {
+	return &InstrumentedEthClient{
+		client:  client,
+		metrics: metrics,
+	}
+}
+
+// observe records a call of the JSON-RPC method. Missing items, e.g. pending receipts, are not errors.
+func (client *InstrumentedEthClient) observe(method string, start time.Time, err error) {
+	client.metrics.RPCDuration.Observe(Since(start), method)
+	if err != nil && err != ethereum.NotFound {
+		client.metrics.RPCErrors.Add(1, method)
+	}
+}
+
+// CodeAt returns the code of the account.
+func (client *InstrumentedEthClient) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	start := time.Now()
+	code, err := client.client.CodeAt(ctx, account, blockNumber)
+	client.observe("eth_getCode", start, err)
+	return code, err
+}
+
+// CallContract executes a contract call.
+func (client *InstrumentedEthClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	start := time.Now()
+	result, err := client.client.CallContract(ctx, call, blockNumber)
+	client.observe("eth_call", start, err)
+	return result, err
+}
+
+// PendingCodeAt returns the code of the account in the pending state.
+func (client *InstrumentedEthClient) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	start := time.Now()
+	code, err := client.client.PendingCodeAt(ctx, account)
+	client.observe("eth_getCode", start, err)
+	return code, err
+}
+
+// PendingNonceAt returns the nonce of the account in the pending state.
+func (client *InstrumentedEthClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	start := time.Now()
+	nonce, err := client.client.PendingNonceAt(ctx, account)
+	client.observe("eth_getTransactionCount", start, err)
+	return nonce, err
+}
+
+// SuggestGasPrice returns the gas price suggested by the node.
+func (client *InstrumentedEthClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	start := time.Now()
+	gasPrice, err := client.client.SuggestGasPrice(ctx)
+	client.observe("eth_gasPrice", start, err)
+	return gasPrice, err
+}
+
+// EstimateGas estimates the gas of the call.
+func (client *InstrumentedEthClient) EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error) {
+	start := time.Now()
+	gas, err := client.client.EstimateGas(ctx, call)
+	client.observe("eth_estimateGas", start, err)
+	return gas, err
+}
+
+// SendTransaction sends a signed transaction.
+func (client *InstrumentedEthClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	start := time.Now()
+	err := client.client.SendTransaction(ctx, tx)
+	client.observe("eth_sendRawTransaction", start, err)
+	return err
+}
+
+// FilterLogs returns the logs matching the query.
+func (client *InstrumentedEthClient) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	start := time.Now()
+	logs, err := client.client.FilterLogs(ctx, query)
+	client.observe("eth_getLogs", start, err)
+	return logs, err
+}
+
+// SubscribeFilterLogs subscribes to the logs matching the query.
+func (client *InstrumentedEthClient) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	start := time.Now()
+	subscription, err := client.client.SubscribeFilterLogs(ctx, query, ch)
+	client.observe("eth_subscribe", start, err)
+	return subscription, err
+}
+
+// HeaderByNumber returns the header of the block, or of the latest block if number is nil.
+func (client *InstrumentedEthClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	start := time.Now()
+	header, err := client.client.HeaderByNumber(ctx, number)
+	client.observe("eth_getBlockByNumber", start, err)
+	return header, err
+}
+
+// ChainID returns the chain ID of the node.
+func (client *InstrumentedEthClient) ChainID(ctx context.Context) (*big.Int, error) {
+	start := time.Now()
+	chainID, err := client.client.ChainID(ctx)
+	client.observe("eth_chainId", start, err)
+	return chainID, err
+}
+
+// BalanceAt returns the Ether balance of the account.
+func (client *InstrumentedEthClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	start := time.Now()
+	balance, err := client.client.BalanceAt(ctx, account, blockNumber)
+	client.observe("eth_getBalance", start, err)
+	return balance, err
+}
+
+// TransactionReceipt returns the receipt of a mined transaction.
+func (client *InstrumentedEthClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	start := time.Now()
+	receipt, err := client.client.TransactionReceipt(ctx, txHash)
+	client.observe("eth_getTransactionReceipt", start, err)
+	return receipt, err
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"math/big"
+	"time"
+)
+
+// Metrics are the metrics of the indexer and the bots.
+type Metrics struct {
+	// EventsProcessed counts the chain events applied to accounts, by token and event type.
+	EventsProcessed Counter
+	// AccountsTracked is the number of accounts with borrows.
+	AccountsTracked Gauge
+	// AccountsInShortfall is the number of checked accounts in shortfall.
+	AccountsInShortfall Gauge
+	// TotalShortfall is the total shortfall of the checked accounts. Shortfall is not attributed to
+	// markets, as an account's shortfall is not owed to any one of the markets it borrows from.
+	TotalShortfall Gauge
+	// RPCDuration is the latency of node calls, by JSON-RPC method.
+	RPCDuration Histogram
+	// RPCErrors counts the failed node calls, by JSON-RPC method.
+	RPCErrors Counter
+	// StorageDuration is the latency of storage operations, by collection and operation.
+	StorageDuration Histogram
+	// StorageRetries counts the storage operations retried after a failure, by collection and operation.
+	StorageRetries Counter
+	// HeadBlockLag is the number of blocks between the head and the block scanned for the token.
+	HeadBlockLag Gauge
+	// PausedActions is 1 for the actions paused by the Comptroller pause guardian and 0 for the others, by action.
+	PausedActions Gauge
+}
+
+// NewMetrics registers the metrics in the registry.
+func NewMetrics(registry Registry) *Metrics {
+	return &Metrics{
+		EventsProcessed:     registry.NewCounter("carbon_events_processed_total", "Chain events applied to accounts.", "token", "event"),
+		AccountsTracked:     registry.NewGauge("carbon_accounts_tracked", "Accounts with borrows."),
+		AccountsInShortfall: registry.NewGauge("carbon_accounts_in_shortfall", "Checked accounts in shortfall."),
+		TotalShortfall:      registry.NewGauge("carbon_shortfall", "Total shortfall in wei of the checked accounts."),
+		RPCDuration:         registry.NewHistogram("carbon_rpc_duration_seconds", "Latency of node calls.", DefaultBuckets, "method"),
+		RPCErrors:           registry.NewCounter("carbon_rpc_errors_total", "Failed node calls.", "method"),
+		StorageDuration:     registry.NewHistogram("carbon_storage_duration_seconds", "Latency of storage operations.", DefaultBuckets, "collection", "operation"),
+		StorageRetries:      registry.NewCounter("carbon_storage_retries_total", "Storage operations retried after a failure.", "collection", "operation"),
+		HeadBlockLag:        registry.NewGauge("carbon_head_block_lag", "Blocks between the head and the block scanned for the token.", "token"),
+		PausedActions:       registry.NewGauge("carbon_paused_actions", "Whether the Comptroller pause guardian paused the action.", "action"),
+	}
+}
+
+// Since returns the seconds elapsed since start, for latency histograms.
+func Since(start time.Time) float64 {
+	return time.Since(start).Seconds()
+}
+
+// ToFloat converts an amount to a gauge value, losing precision beyond float64.
+func ToFloat(amount *big.Int) float64 {
+	if amount == nil {
+		return 0
+	}
+	value, _ := new(big.Float).SetInt(amount).Float64()
+	return value
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/expfmt"
+)
+
+// DefaultBuckets are the upper bounds in seconds of the buckets of latency histograms.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Counter is a metric that only goes up, e.g. the number of processed events.
+type Counter interface {
+	Add(value float64, labelValues ...string)
+}
+
+// Gauge is a metric that goes up and down, e.g. the number of tracked accounts.
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+}
+
+// Histogram is a metric counting observations in buckets, e.g. the latency of calls.
+type Histogram interface {
+	Observe(value float64, labelValues ...string)
+}
+
+// Registry holds metrics and serves them in the Prometheus text format.
+type Registry interface {
+	http.Handler
+	NewCounter(name string, help string, labelNames ...string) Counter
+	NewGauge(name string, help string, labelNames ...string) Gauge
+	NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram
+	Write(writer io.Writer) error
+}
+
+// MetricsRegistry is a Registry backed by a Prometheus registry.
+type MetricsRegistry struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+}
+
+// NewRegistry creates a new Registry.
+func NewRegistry() Registry {
+	registry := prometheus.NewRegistry()
+	return &MetricsRegistry{
+		registry: registry,
+		handler:  promhttp.HandlerFor(registry, promhttp.HandlerOpts{}),
+	}
+}
+
+// NewCounter registers a counter with the label names.
+func (registry *MetricsRegistry) NewCounter(name string, help string, labelNames ...string) Counter {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labelNames)
+	registry.registry.MustRegister(vec)
+	return &counter{vec}
+}
+
+// NewGauge registers a gauge with the label names.
+func (registry *MetricsRegistry) NewGauge(name string, help string, labelNames ...string) Gauge {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labelNames)
+	registry.registry.MustRegister(vec)
+	return &gauge{vec}
+}
+
+// NewHistogram registers a histogram with the bucket upper bounds and label names.
+func (registry *MetricsRegistry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) Histogram {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+	vec := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: help, Buckets: buckets}, labelNames)
+	registry.registry.MustRegister(vec)
+	return &histogram{vec}
+}
+
+type counter struct {
+	vec *prometheus.CounterVec
+}
+
+// Add increases the counter of the label values. Negative values are ignored.
+func (metric *counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		return
+	}
+	metric.vec.WithLabelValues(labelValues...).Add(value)
+}
+
+type gauge struct {
+	vec *prometheus.GaugeVec
+}
+
+// Set sets the gauge of the label values.
+func (metric *gauge) Set(value float64, labelValues ...string) {
+	metric.vec.WithLabelValues(labelValues...).Set(value)
+}
+
+type histogram struct {
+	vec *prometheus.HistogramVec
+}
+
+// Observe adds the value to the histogram of the label values.
+func (metric *histogram) Observe(value float64, labelValues ...string) {
+	metric.vec.WithLabelValues(labelValues...).Observe(value)
+}
+
+// Write writes the metrics in the Prometheus text format, sorted by name with series sorted by labels.
+func (registry *MetricsRegistry) Write(writer io.Writer) error {
+	families, err := registry.registry.Gather()
+	if err != nil {
+		return err
+	}
+	encoder := expfmt.NewEncoder(writer, expfmt.FmtText)
+	for _, family := range families {
+		err = encoder.Encode(family)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP writes the metrics in the format negotiated with the scraper.
+func (registry *MetricsRegistry) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	registry.handler.ServeHTTP(writer, request)
+}
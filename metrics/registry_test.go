@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+func TestMetricsRegistry_Write(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name    string
+		observe func(registry Registry)
+		want    string
+	}{
+		{
+			name: "Should write counters sorted by label.",
+			observe: func(registry Registry) {
+				counter := registry.NewCounter("events_total", "Events.", "token", "event")
+				counter.Add(1, "CDAI", "Borrow")
+				counter.Add(2, "CBAT", "Borrow")
+				counter.Add(1, "CDAI", "Borrow")
+				counter.Add(-1, "CDAI", "Borrow")
+			},
+			want: "# HELP events_total Events.\n" +
+				"# TYPE events_total counter\n" +
+				"events_total{event=\"Borrow\",token=\"CBAT\"} 2\n" +
+				"events_total{event=\"Borrow\",token=\"CDAI\"} 2\n",
+		},
+		{
+			name: "Should write the last gauge value without labels.",
+			observe: func(registry Registry) {
+				gauge := registry.NewGauge("accounts", "Accounts.")
+				gauge.Set(3)
+				gauge.Set(1.5)
+			},
+			want: "# HELP accounts Accounts.\n" +
+				"# TYPE accounts gauge\n" +
+				"accounts 1.5\n",
+		},
+		{
+			name: "Should write cumulative histogram buckets.",
+			observe: func(registry Registry) {
+				histogram := registry.NewHistogram("latency_seconds", "Latency.", []float64{1, 0.1}, "method")
+				histogram.Observe(0.05, "eth_call")
+				histogram.Observe(0.5, "eth_call")
+				histogram.Observe(2, "eth_call")
+			},
+			want: "# HELP latency_seconds Latency.\n" +
+				"# TYPE latency_seconds histogram\n" +
+				"latency_seconds_bucket{method=\"eth_call\",le=\"0.1\"} 1\n" +
+				"latency_seconds_bucket{method=\"eth_call\",le=\"1\"} 2\n" +
+				"latency_seconds_bucket{method=\"eth_call\",le=\"+Inf\"} 3\n" +
+				"latency_seconds_sum{method=\"eth_call\"} 2.55\n" +
+				"latency_seconds_count{method=\"eth_call\"} 3\n",
+		},
+		{
+			name: "Should escape label values.",
+			observe: func(registry Registry) {
+				registry.NewGauge("escaped", "Line\nbreak.", "value").Set(1, "a\"b\\c\nd")
+			},
+			want: "# HELP escaped Line\\nbreak.\n" +
+				"# TYPE escaped gauge\n" +
+				"escaped{value=\"a\\\"b\\\\c\\nd\"} 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry()
+			tt.observe(registry)
+			var buf bytes.Buffer
+			// Act
+			err := registry.Write(&buf)
+			// Assert
+			if err != nil {
+				t.Fatalf("registry.Write() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("registry.Write() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMetricsRegistry_ServeHTTP(t *testing.T) {
+	// Arrange
+	registry := NewRegistry()
+	NewMetrics(registry).AccountsTracked.Set(7)
+	recorder := httptest.NewRecorder()
+	// Act
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// Assert
+	if recorder.Code != http.StatusOK {
+		t.Errorf("recorder.Code = %v, want %v", recorder.Code, http.StatusOK)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(recorder.Body)
+	if err != nil {
+		t.Fatalf("parser.TextToMetricFamilies() error = %v", err)
+	}
+	tracked, ok := families["carbon_accounts_tracked"]
+	if !ok || tracked.GetMetric()[0].GetGauge().GetValue() != 7 {
+		t.Errorf("families[carbon_accounts_tracked] = %v, want 7", tracked)
+	}
+}
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+const (
+	// AccountEventBorrow is a change of an account's borrow balance from a Borrow event.
+	AccountEventBorrow = "Borrow"
+
+	// AccountEventMarketEntered is an account entering a market from a MarketEntered event.
+	AccountEventMarketEntered = "MarketEntered"
+
+	// AccountEventMarketExited is an account exiting a market from a MarketExited event.
+	AccountEventMarketExited = "MarketExited"
+)
+
+// AccountEvent records a change applied to an account.
+type AccountEvent struct {
+	ID           bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey     string
+	Address      string
+	EventType    string
+	TokenSymbol  string
+	OldValue     string
+	NewValue     string
+	BlockNumber  uint64
+	TxHash       string
+	LogIndex     uint
+	RecordedTime time.Time
+}
+
+// AccountEventsService is responsible for the append-only history of account changes.
+type AccountEventsService interface {
+	AppendAccountEvents(ctx context.Context, events []*AccountEvent) error
+	GetAccountEvents(ctx context.Context, address string, fromBlock uint64, toBlock uint64) ([]*AccountEvent, error)
+}
+
+// CosmosAccountEventsService works against Cosmos DB SQL Core.
+type CosmosAccountEventsService struct {
+	logger               logging.Logger
+	collectionFactory    CollectionFactory
+	eventsCollection     Collection
+	eventsCollectionName string
+}
+
+// MockAccountEventsService works against an in-memory data store that is not durable.
+type MockAccountEventsService struct {
+	Events []*AccountEvent
+	mutex  sync.Mutex
+}
+
+// NewCosmosAccountEventsService creates a new AccountEventsService.
+func NewCosmosAccountEventsService(logger logging.Logger, collectionFactory CollectionFactory, eventsCollectionName string) AccountEventsService {
+	return &CosmosAccountEventsService{
+		logger:               logger,
+		collectionFactory:    collectionFactory,
+		eventsCollectionName: eventsCollectionName,
+	}
+}
+
+// GetEventID derives the ID of a chain event from the transaction emitting it and its index in the block,
+// so recording the event again is a no-op.
+func GetEventID(txHash string, logIndex uint) bson.ObjectId {
+	index := make([]byte, 8)
+	binary.BigEndian.PutUint64(index, uint64(logIndex))
+	hash := crypto.Keccak256(common.HexToHash(txHash).Bytes(), index)
+	return bson.ObjectId(hash[:12])
+}
+
+// AppendAccountEvents records the account changes. Events are partitioned by account address.
+func (service *CosmosAccountEventsService) AppendAccountEvents(ctx context.Context, events []*AccountEvent) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if event.ID == "" {
+			event.ID = bson.NewObjectId()
+		}
+		event.ShardKey = event.Address
+		err = service.eventsCollection.Create(event)
+		if mgo.IsDup(err) {
+			// Already appended by an earlier attempt.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAccountEvents returns the account's changes in the inclusive block range, in the order they were applied.
+func (service *CosmosAccountEventsService) GetAccountEvents(ctx context.Context, address string, fromBlock uint64, toBlock uint64) ([]*AccountEvent, error) {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := []*AccountEvent{}
+	query := bson.M{
+		"shardkey":    address,
+		"blocknumber": bson.M{"$gte": fromBlock, "$lte": toBlock},
+	}
+	err = service.eventsCollection.FindAll(query, &events)
+	if err != nil {
+		return nil, err
+	}
+	sortAccountEvents(events)
+	return events, nil
+}
+
+func (service *CosmosAccountEventsService) initializeCollection(ctx context.Context) error {
+	if service.eventsCollection == nil {
+		collection, err := service.collectionFactory.CreateCollection(ctx, service.eventsCollectionName)
+		if err != nil {
+			return err
+		}
+		service.eventsCollection = collection
+	}
+	return nil
+}
+
+// AppendAccountEvents records the account changes.
+func (service *MockAccountEventsService) AppendAccountEvents(ctx context.Context, events []*AccountEvent) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	for _, event := range events {
+		if event.ID != "" && service.hasEvent(event.ID) {
+			continue
+		}
+		stored := *event
+		stored.ShardKey = stored.Address
+		service.Events = append(service.Events, &stored)
+	}
+	return nil
+}
+
+// GetAccountEvents returns the account's changes in the inclusive block range, in the order they were applied.
+func (service *MockAccountEventsService) GetAccountEvents(ctx context.Context, address string, fromBlock uint64, toBlock uint64) ([]*AccountEvent, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	events := []*AccountEvent{}
+	for _, event := range service.Events {
+		if event.Address == address && event.BlockNumber >= fromBlock && event.BlockNumber <= toBlock {
+			events = append(events, event)
+		}
+	}
+	sortAccountEvents(events)
+	return events, nil
+}
+
+func (service *MockAccountEventsService) hasEvent(id bson.ObjectId) bool {
+	for _, event := range service.Events {
+		if event.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sortAccountEvents orders the events by block number and log index.
+func sortAccountEvents(events []*AccountEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].BlockNumber != events[j].BlockNumber {
+			return events[i].BlockNumber < events[j].BlockNumber
+		}
+		return events[i].LogIndex < events[j].LogIndex
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMockAccountEventsService_GetAccountEvents(t *testing.T) {
+	// Arrange
+	borrower := "0x000000000000000000000000000000000000dEaD"
+	other := "0x0000000000000000000000000000000000000001"
+	service := &MockAccountEventsService{}
+	err := service.AppendAccountEvents(context.Background(), []*AccountEvent{
+		{Address: borrower, EventType: AccountEventBorrow, BlockNumber: 12, LogIndex: 3, NewValue: "3"},
+		{Address: borrower, EventType: AccountEventBorrow, BlockNumber: 10, LogIndex: 1, NewValue: "1"},
+		{Address: other, EventType: AccountEventBorrow, BlockNumber: 11, LogIndex: 0, NewValue: "9"},
+		{Address: borrower, EventType: AccountEventMarketEntered, BlockNumber: 12, LogIndex: 2, NewValue: "true"},
+		{Address: borrower, EventType: AccountEventMarketExited, BlockNumber: 20, LogIndex: 0, NewValue: "false"},
+	})
+	if err != nil {
+		t.Fatalf("service.AppendAccountEvents() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		address   string
+		fromBlock uint64
+		toBlock   uint64
+		want      []string
+	}{
+		{
+			name:      "Should return events in order.",
+			address:   borrower,
+			fromBlock: 0,
+			toBlock:   20,
+			want:      []string{"1", "true", "3", "false"},
+		},
+		{
+			name:      "Should return events in inclusive block range.",
+			address:   borrower,
+			fromBlock: 11,
+			toBlock:   12,
+			want:      []string{"true", "3"},
+		},
+		{
+			name:      "Should not return events of other accounts.",
+			address:   other,
+			fromBlock: 0,
+			toBlock:   10,
+			want:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			events, err := service.GetAccountEvents(context.Background(), tt.address, tt.fromBlock, tt.toBlock)
+			// Assert
+			if err != nil {
+				t.Fatalf("service.GetAccountEvents() error = %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("len(events) = %v, want %v", len(events), len(tt.want))
+			}
+			for i, event := range events {
+				if event.NewValue != tt.want[i] {
+					t.Errorf("events[%v].NewValue = %v, want %v", i, event.NewValue, tt.want[i])
+				}
+				if event.ShardKey != tt.address {
+					t.Errorf("events[%v].ShardKey = %v, want %v", i, event.ShardKey, tt.address)
+				}
+			}
+		})
+	}
+}
+
+func TestMockAccountEventsService_AppendAccountEvents(t *testing.T) {
+	// Arrange
+	borrower := "0x000000000000000000000000000000000000dEaD"
+	txHash := "0x1e9b2b9ab3d3d5c3bd5b5cf2bd8c3e0c8e0f1f2fc3d4b5a6978877665544332a"
+	tests := []struct {
+		name     string
+		events   []*AccountEvent
+		wantLogs []uint
+	}{
+		{
+			name: "Should skip events already appended.",
+			events: []*AccountEvent{
+				{ID: GetEventID(txHash, 0), Address: borrower, LogIndex: 0},
+				{ID: GetEventID(txHash, 0), Address: borrower, LogIndex: 0},
+				{ID: GetEventID(txHash, 1), Address: borrower, LogIndex: 1},
+			},
+			wantLogs: []uint{0, 1},
+		},
+		{
+			name: "Should append events without IDs.",
+			events: []*AccountEvent{
+				{Address: borrower, LogIndex: 0},
+				{Address: borrower, LogIndex: 0},
+			},
+			wantLogs: []uint{0, 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &MockAccountEventsService{}
+			// Act
+			for _, event := range tt.events {
+				err := service.AppendAccountEvents(context.Background(), []*AccountEvent{event})
+				if err != nil {
+					t.Fatalf("service.AppendAccountEvents() error = %v", err)
+				}
+			}
+			// Assert
+			if len(service.Events) != len(tt.wantLogs) {
+				t.Fatalf("len(service.Events) = %v, want %v", len(service.Events), len(tt.wantLogs))
+			}
+			for i, event := range service.Events {
+				if event.LogIndex != tt.wantLogs[i] {
+					t.Errorf("service.Events[%v].LogIndex = %v, want %v", i, event.LogIndex, tt.wantLogs[i])
+				}
+			}
+		})
+	}
+}
@@ -2,27 +2,63 @@ package models
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
 
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
 )
 
+// ErrNoAccountsHistory is returned when historical account state is requested from a store without history.
+var ErrNoAccountsHistory = errors.New("historical account state requires an archive accounts service")
+
 // AccountsService is responsible for CRUD on account data.
 type AccountsService interface {
 	GetAccounts(ctx context.Context, result interface{}) error
 	UpsertAccount(ctx context.Context, account *Account) error
+	GetAccountAt(ctx context.Context, address common.Address, blockNumber uint64) (*Account, error)
+}
+
+// AccountsHistory reconstructs accounts as of past blocks.
+type AccountsHistory interface {
+	GetAccountAt(ctx context.Context, address common.Address, blockNumber uint64) (*Account, error)
 }
 
 // CosmosAccountsService works against Cosmos DB SQL Core.
 type CosmosAccountsService struct {
-	logger                 *log.Logger
+	logger                 logging.Logger
 	collectionFactory      CollectionFactory
 	accountsCollection     Collection
 	accountsCollectionName string
 }
 
+// ArchiveAccountsHistory reads past account state from an archive node.
+type ArchiveAccountsHistory struct {
+	logger             logging.Logger
+	tokens             map[string]contracts.Token
+	tokenAddresses     map[string]common.Address
+	comptrollerService ComptrollerService
+}
+
+// ArchiveAccountsService adds historical account state to an AccountsService.
+type ArchiveAccountsService struct {
+	AccountsService
+	history AccountsHistory
+}
+
+// MockAccountsService works against an in-memory data store that is not durable.
+type MockAccountsService struct {
+	Accounts map[string]*Account
+}
+
 // NewCosmosAccountsService creats a new AccountsService.
-func NewCosmosAccountsService(logger *log.Logger, collectionFactory CollectionFactory, accountsCollectionName string) AccountsService {
+func NewCosmosAccountsService(logger logging.Logger, collectionFactory CollectionFactory, accountsCollectionName string) AccountsService {
 	return &CosmosAccountsService{
 		logger:                 logger,
 		collectionFactory:      collectionFactory,
@@ -54,3 +90,112 @@ func (service *CosmosAccountsService) UpsertAccount(ctx context.Context, account
 	_, err := service.accountsCollection.Upsert(bson.M{"shardkey": account.Address}, account)
 	return err
 }
+
+// GetAccountAt is not supported by Cosmos DB, which only stores the latest account state.
+func (service *CosmosAccountsService) GetAccountAt(ctx context.Context, address common.Address, blockNumber uint64) (*Account, error) {
+	return nil, ErrNoAccountsHistory
+}
+
+// NewArchiveAccountsHistory creates a new AccountsHistory reading contract state at past blocks.
+// The Ethereum node must be an archive node to serve blocks older than its pruning window.
+func NewArchiveAccountsHistory(logger logging.Logger, tokensProvider contracts.TokensProvider, comptrollerService ComptrollerService) AccountsHistory {
+	return &ArchiveAccountsHistory{
+		logger:             logger,
+		tokens:             tokensProvider.GetTokens(),
+		tokenAddresses:     tokensProvider.GetAddresses(),
+		comptrollerService: comptrollerService,
+	}
+}
+
+// GetAccountAt returns the account's borrows, collateral, entered markets and liquidity as of the block.
+func (history *ArchiveAccountsHistory) GetAccountAt(ctx context.Context, address common.Address, blockNumber uint64) (*Account, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(blockNumber)}
+	account := &Account{
+		ShardKey:   address.Hex(),
+		Address:    address.Hex(),
+		Borrows:    make(map[string]*big.Int),
+		Collateral: make(map[string]*big.Int),
+	}
+	for tokenSymbol, token := range history.tokens {
+		errorCode, tokenBalance, borrowBalance, _, err := token.GetAccountSnapshot(opts, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v snapshot at block # %v: %v", tokenSymbol, blockNumber, err)
+		}
+		if errorCode.Sign() != 0 {
+			return nil, fmt.Errorf("failed to read %v snapshot at block # %v: error code %v", tokenSymbol, blockNumber, errorCode)
+		}
+		if borrowBalance.Sign() > 0 {
+			account.Borrows[tokenSymbol] = borrowBalance
+		}
+		if tokenBalance.Sign() > 0 {
+			account.Collateral[tokenSymbol] = tokenBalance
+		}
+	}
+	assetsIn, err := history.comptrollerService.GetAssetsIn(opts, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assets in at block # %v: %v", blockNumber, err)
+	}
+	account.Markets = []string{}
+	for _, asset := range assetsIn {
+		account.EnterMarket(contracts.GetTokenSymbol(history.tokenAddresses, asset))
+	}
+	errorCode, liquidity, shortfall, err := history.comptrollerService.GetAccountLiquidity(opts, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read liquidity at block # %v: %v", blockNumber, err)
+	}
+	if errorCode.Sign() != 0 {
+		return nil, fmt.Errorf("failed to read liquidity at block # %v: error code %v", blockNumber, errorCode)
+	}
+	account.Liquidity = liquidity
+	account.Shortfall = shortfall
+	history.logger.Debug("Read account", logging.Fields{
+		logging.AddressField: account.Address,
+		logging.BlockField:   blockNumber,
+		"borrows":            account.Borrows,
+		"liquidity":          liquidity,
+		"shortfall":          shortfall,
+	})
+	return account, nil
+}
+
+// NewArchiveAccountsService creates a new AccountsService answering historical queries from the history.
+func NewArchiveAccountsService(accountsService AccountsService, history AccountsHistory) AccountsService {
+	return &ArchiveAccountsService{
+		AccountsService: accountsService,
+		history:         history,
+	}
+}
+
+// GetAccountAt returns the account as of the block.
+func (service *ArchiveAccountsService) GetAccountAt(ctx context.Context, address common.Address, blockNumber uint64) (*Account, error) {
+	return service.history.GetAccountAt(ctx, address, blockNumber)
+}
+
+// GetAccounts returns all accounts sorted by address.
+func (service *MockAccountsService) GetAccounts(ctx context.Context, result interface{}) error {
+	accounts, ok := result.(*[]*Account)
+	if !ok {
+		return fmt.Errorf("unsupported result type %T", result)
+	}
+	for _, account := range service.Accounts {
+		*accounts = append(*accounts, account)
+	}
+	sort.Slice(*accounts, func(i, j int) bool {
+		return (*accounts)[i].Address < (*accounts)[j].Address
+	})
+	return nil
+}
+
+// UpsertAccount creates or updates an account.
+func (service *MockAccountsService) UpsertAccount(ctx context.Context, account *Account) error {
+	if service.Accounts == nil {
+		service.Accounts = make(map[string]*Account)
+	}
+	service.Accounts[account.Address] = account
+	return nil
+}
+
+// GetAccountAt is not supported by the in-memory data store.
+func (service *MockAccountsService) GetAccountAt(ctx context.Context, address common.Address, blockNumber uint64) (*Account, error) {
+	return nil, ErrNoAccountsHistory
+}
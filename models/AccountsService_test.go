@@ -0,0 +1,104 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+)
+
+func TestArchiveAccountsHistory_GetAccountAt(t *testing.T) {
+	// Arrange
+	borrower := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	cBAT := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	cETH := common.HexToAddress("0x4ddc2d193948926d02f9b1fe9e1daa0718270ed5")
+	tokensProvider := &contracts.MockTokenContracts{
+		Contracts: map[string]contracts.Token{
+			contracts.CBATSymbol: &contracts.MockToken{
+				BorrowBalances: map[common.Address]*big.Int{borrower: big.NewInt(100)},
+			},
+			contracts.CETHSymbol: &contracts.MockToken{
+				CTokenBalances: map[common.Address]*big.Int{borrower: big.NewInt(5000)},
+			},
+		},
+		Addresses: map[string]common.Address{
+			contracts.CBATSymbol: cBAT,
+			contracts.CETHSymbol: cETH,
+		},
+	}
+	comptrollerService := &MockComptroller{
+		Accounts: map[common.Address]*MockComptrollerAccount{
+			borrower: {Shortfall: big.NewInt(7), AssetsIn: []common.Address{cETH, cBAT}},
+		},
+	}
+	tests := []struct {
+		name           string
+		address        common.Address
+		wantBorrows    map[string]*big.Int
+		wantCollateral map[string]*big.Int
+		wantMarkets    []string
+		wantShortfall  *big.Int
+	}{
+		{
+			name:           "Should reconstruct account.",
+			address:        borrower,
+			wantBorrows:    map[string]*big.Int{contracts.CBATSymbol: big.NewInt(100)},
+			wantCollateral: map[string]*big.Int{contracts.CETHSymbol: big.NewInt(5000)},
+			wantMarkets:    []string{contracts.CBATSymbol, contracts.CETHSymbol},
+			wantShortfall:  big.NewInt(7),
+		},
+		{
+			name:           "Should reconstruct account without positions.",
+			address:        common.Address{},
+			wantBorrows:    map[string]*big.Int{},
+			wantCollateral: map[string]*big.Int{},
+			wantMarkets:    []string{},
+			wantShortfall:  big.NewInt(0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			history := NewArchiveAccountsHistory(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), tokensProvider, comptrollerService)
+			accountsService := NewArchiveAccountsService(&CosmosAccountsService{}, history)
+			// Act
+			got, err := accountsService.GetAccountAt(context.Background(), tt.address, 9000000)
+			// Assert
+			if err != nil {
+				t.Fatalf("accountsService.GetAccountAt() error = %v", err)
+			}
+			if got.Address != tt.address.Hex() {
+				t.Errorf("got.Address = %v, want %v", got.Address, tt.address.Hex())
+			}
+			if !reflect.DeepEqual(got.Borrows, tt.wantBorrows) {
+				t.Errorf("got.Borrows = %v, want %v", got.Borrows, tt.wantBorrows)
+			}
+			if !reflect.DeepEqual(got.Collateral, tt.wantCollateral) {
+				t.Errorf("got.Collateral = %v, want %v", got.Collateral, tt.wantCollateral)
+			}
+			if !reflect.DeepEqual(got.Markets, tt.wantMarkets) {
+				t.Errorf("got.Markets = %v, want %v", got.Markets, tt.wantMarkets)
+			}
+			if got.Shortfall.Cmp(tt.wantShortfall) != 0 {
+				t.Errorf("got.Shortfall = %v, want %v", got.Shortfall, tt.wantShortfall)
+			}
+		})
+	}
+}
+
+func TestCosmosAccountsService_GetAccountAt(t *testing.T) {
+	// Arrange
+	service := &CosmosAccountsService{}
+	// Act
+	_, err := service.GetAccountAt(context.Background(), common.Address{}, 1)
+	// Assert
+	if err != ErrNoAccountsHistory {
+		t.Errorf("service.GetAccountAt() error = %v, want %v", err, ErrNoAccountsHistory)
+	}
+}
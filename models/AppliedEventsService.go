@@ -0,0 +1,135 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+// AppliedEvent records a chain event whose changes are stored.
+type AppliedEvent struct {
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey    string
+	EventType   string
+	TokenSymbol string
+	BlockNumber uint64
+	TxHash      string
+	LogIndex    uint
+	AppliedTime time.Time
+}
+
+// AppliedEventsService is responsible for deduplicating the chain events applied to accounts.
+type AppliedEventsService interface {
+	GetAppliedEvents(ctx context.Context, ids []bson.ObjectId) (map[bson.ObjectId]bool, error)
+	MarkEventsApplied(ctx context.Context, events []*AppliedEvent) error
+}
+
+// CosmosAppliedEventsService works against Cosmos DB SQL Core.
+type CosmosAppliedEventsService struct {
+	logger               logging.Logger
+	collectionFactory    CollectionFactory
+	eventsCollection     Collection
+	eventsCollectionName string
+}
+
+// MockAppliedEventsService works against an in-memory data store that is not durable.
+type MockAppliedEventsService struct {
+	Events map[bson.ObjectId]*AppliedEvent
+	mutex  sync.Mutex
+}
+
+// NewCosmosAppliedEventsService creates a new AppliedEventsService.
+func NewCosmosAppliedEventsService(logger logging.Logger, collectionFactory CollectionFactory, eventsCollectionName string) AppliedEventsService {
+	return &CosmosAppliedEventsService{
+		logger:               logger,
+		collectionFactory:    collectionFactory,
+		eventsCollectionName: eventsCollectionName,
+	}
+}
+
+// GetAppliedEvents returns which of the events are applied.
+func (service *CosmosAppliedEventsService) GetAppliedEvents(ctx context.Context, ids []bson.ObjectId) (map[bson.ObjectId]bool, error) {
+	applied := make(map[bson.ObjectId]bool)
+	if len(ids) == 0 {
+		return applied, nil
+	}
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := []*AppliedEvent{}
+	err = service.eventsCollection.FindAll(bson.M{"_id": bson.M{"$in": ids}}, &events)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		applied[event.ID] = true
+	}
+	return applied, nil
+}
+
+// MarkEventsApplied records the events as applied. Events are partitioned by transaction hash.
+func (service *CosmosAppliedEventsService) MarkEventsApplied(ctx context.Context, events []*AppliedEvent) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		event.ID = GetEventID(event.TxHash, event.LogIndex)
+		event.ShardKey = event.TxHash
+		err = service.eventsCollection.Create(event)
+		if mgo.IsDup(err) {
+			// Already marked by an earlier attempt.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (service *CosmosAppliedEventsService) initializeCollection(ctx context.Context) error {
+	if service.eventsCollection == nil {
+		collection, err := service.collectionFactory.CreateCollection(ctx, service.eventsCollectionName)
+		if err != nil {
+			return err
+		}
+		service.eventsCollection = collection
+	}
+	return nil
+}
+
+// GetAppliedEvents returns which of the events are applied.
+func (service *MockAppliedEventsService) GetAppliedEvents(ctx context.Context, ids []bson.ObjectId) (map[bson.ObjectId]bool, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	applied := make(map[bson.ObjectId]bool)
+	for _, id := range ids {
+		if _, ok := service.Events[id]; ok {
+			applied[id] = true
+		}
+	}
+	return applied, nil
+}
+
+// MarkEventsApplied records the events as applied.
+func (service *MockAppliedEventsService) MarkEventsApplied(ctx context.Context, events []*AppliedEvent) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.Events == nil {
+		service.Events = make(map[bson.ObjectId]*AppliedEvent)
+	}
+	for _, event := range events {
+		stored := *event
+		stored.ID = GetEventID(stored.TxHash, stored.LogIndex)
+		stored.ShardKey = stored.TxHash
+		service.Events[stored.ID] = &stored
+	}
+	return nil
+}
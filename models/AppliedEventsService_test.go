@@ -0,0 +1,60 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func TestMockAppliedEventsService_GetAppliedEvents(t *testing.T) {
+	// Arrange
+	txHash := "0x1e9b2b9ab3d3d5c3bd5b5cf2bd8c3e0c8e0f1f2fc3d4b5a6978877665544332a"
+	otherTxHash := "0x2e9b2b9ab3d3d5c3bd5b5cf2bd8c3e0c8e0f1f2fc3d4b5a6978877665544332a"
+	service := &MockAppliedEventsService{}
+	err := service.MarkEventsApplied(context.Background(), []*AppliedEvent{
+		{EventType: AccountEventBorrow, TxHash: txHash, LogIndex: 1},
+	})
+	if err != nil {
+		t.Fatalf("service.MarkEventsApplied() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		txHash   string
+		logIndex uint
+		want     bool
+	}{
+		{
+			name:     "Should find the applied event.",
+			txHash:   txHash,
+			logIndex: 1,
+			want:     true,
+		},
+		{
+			name:     "Should not find other events of the transaction.",
+			txHash:   txHash,
+			logIndex: 2,
+			want:     false,
+		},
+		{
+			name:     "Should not find events of other transactions.",
+			txHash:   otherTxHash,
+			logIndex: 1,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := GetEventID(tt.txHash, tt.logIndex)
+			// Act
+			applied, err := service.GetAppliedEvents(context.Background(), []bson.ObjectId{id})
+			// Assert
+			if err != nil {
+				t.Fatalf("service.GetAppliedEvents() error = %v", err)
+			}
+			if applied[id] != tt.want {
+				t.Errorf("applied[%v] = %v, want %v", id.Hex(), applied[id], tt.want)
+			}
+		})
+	}
+}
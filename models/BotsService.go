@@ -2,26 +2,52 @@ package models
 
 import (
 	"context"
-	"log"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
 
+	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
 )
 
+// DefaultInstanceID identifies the state shared by all instances of a leader-elected bot.
+const DefaultInstanceID = "default"
+
+// ErrBotStateNotFound is returned when no state is stored for the bot type and instance.
+var ErrBotStateNotFound = mgo.ErrNotFound
+
+// ErrBotStateConflict is returned when the bot state changed since it was read.
+var ErrBotStateConflict = errors.New("bot state was updated concurrently")
+
 // BotsService is responsible for CRUD on bot data.
 type BotsService interface {
 	CreateBotState(ctx context.Context, state BotState) error
-	GetBotState(ctx context.Context, state BotState) error
-	UpdateBotState(ctx context.Context, selector interface{}, update interface{}) error
+	GetBotState(ctx context.Context, botType string, instanceID string, state BotState) error
+	UpdateBotState(ctx context.Context, state BotState, change bson.M) error
+	RegisterWorker(ctx context.Context, botType string, workerID string, heartbeat time.Time) error
+	GetWorkers(ctx context.Context, botType string, aliveSince time.Time) ([]*Worker, error)
+	GetShardAssignment(ctx context.Context, botType string) (*ShardAssignment, error)
+	UpdateShardAssignment(ctx context.Context, botType string, assignment *ShardAssignment) error
 }
 
 // BotState is responsible for accessing bot state.
 type BotState interface {
 	GetShardKey() string
+	GetBotType() string
+	GetInstanceID() string
+	GetVersion() int64
+	SetVersion(version int64)
 }
 
 // MockBotState stores bot state.
 type MockBotState struct {
-	ShardKey string
+	ShardKey   string
+	BotType    string
+	InstanceID string
+	Version    int64
 }
 
 // GetShardKey returns the shard key.
@@ -29,11 +55,35 @@ func (state *MockBotState) GetShardKey() string {
 	return state.ShardKey
 }
 
+// GetBotType returns the bot type.
+func (state *MockBotState) GetBotType() string {
+	return state.BotType
+}
+
+// GetInstanceID returns the bot instance ID.
+func (state *MockBotState) GetInstanceID() string {
+	return state.InstanceID
+}
+
+// GetVersion returns the version of the stored state.
+func (state *MockBotState) GetVersion() int64 {
+	return state.Version
+}
+
+// SetVersion sets the version of the stored state.
+func (state *MockBotState) SetVersion(version int64) {
+	state.Version = version
+}
+
 // MockBotsService works against an in-memory data store that is not durable.
 type MockBotsService struct {
 	CollectionFactory   CollectionFactory
+	States              map[string]BotState
+	Workers             map[string]*Worker
+	ShardAssignments    map[string]*ShardAssignment
 	stateCollection     Collection
 	stateCollectionName string
+	mutex               sync.Mutex
 }
 
 // CreateBotState creates the bot state in the collection.
@@ -45,19 +95,60 @@ func (service *MockBotsService) CreateBotState(ctx context.Context, state BotSta
 		}
 		service.stateCollection = collection
 	}
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.States == nil {
+		service.States = make(map[string]BotState)
+	}
+	service.States[getBotStateKey(state.GetBotType(), state.GetInstanceID())] = copyBotState(state)
 	return service.stateCollection.Create(state)
 }
 
+// GetBotState copies the state of the bot type and instance into the state.
+func (service *MockBotsService) GetBotState(ctx context.Context, botType string, instanceID string, state BotState) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	stored, ok := service.States[getBotStateKey(botType, instanceID)]
+	if !ok {
+		return ErrBotStateNotFound
+	}
+	reflect.ValueOf(state).Elem().Set(reflect.ValueOf(stored).Elem())
+	return nil
+}
+
+// UpdateBotState checks the version of the state and increments it. The change is not applied.
+func (service *MockBotsService) UpdateBotState(ctx context.Context, state BotState, change bson.M) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	stored, ok := service.States[getBotStateKey(state.GetBotType(), state.GetInstanceID())]
+	if !ok || stored.GetVersion() != state.GetVersion() {
+		return ErrBotStateConflict
+	}
+	stored.SetVersion(stored.GetVersion() + 1)
+	state.SetVersion(stored.GetVersion())
+	return nil
+}
+
+func getBotStateKey(botType string, instanceID string) string {
+	return botType + "/" + instanceID
+}
+
+func copyBotState(state BotState) BotState {
+	copied := reflect.New(reflect.TypeOf(state).Elem())
+	copied.Elem().Set(reflect.ValueOf(state).Elem())
+	return copied.Interface().(BotState)
+}
+
 // CosmosBotsService works against Cosmos DB SQL Core.
 type CosmosBotsService struct {
-	logger              *log.Logger
+	logger              logging.Logger
 	collectionFactory   CollectionFactory
 	stateCollection     Collection
 	stateCollectionName string
 }
 
 // NewCosmosBotsService creats a new BotsService.
-func NewCosmosBotsService(logger *log.Logger, collectionFactory CollectionFactory, stateCollectionName string) BotsService {
+func NewCosmosBotsService(logger logging.Logger, collectionFactory CollectionFactory, stateCollectionName string) BotsService {
 	return &CosmosBotsService{
 		logger:              logger,
 		collectionFactory:   collectionFactory,
@@ -68,36 +159,91 @@ func NewCosmosBotsService(logger *log.Logger, collectionFactory CollectionFactor
 // CreateBotState creates the bot state in the collection.
 func (service *CosmosBotsService) CreateBotState(ctx context.Context, state BotState) error {
 	if service.stateCollection == nil {
-		service.logger.Printf("State Collection not found: %v.\n", service.stateCollectionName)
-		service.logger.Printf("Creating State Collection: %v.\n", service.stateCollectionName)
+		service.logger.Info("State collection not found", logging.Fields{"collection": service.stateCollectionName})
+		service.logger.Info("Creating state collection", logging.Fields{"collection": service.stateCollectionName})
 		collection, err := service.collectionFactory.CreateCollection(ctx, service.stateCollectionName)
 		if err != nil {
-			service.logger.Printf("Failed to create State Collection: %v.\n", service.stateCollectionName)
+			service.logger.Error("Failed to create state collection", logging.Fields{"collection": service.stateCollectionName, logging.ErrorField: err})
 			return err
 		}
 		service.stateCollection = collection
-		service.logger.Printf("Created State Collection: %v.\n", service.stateCollection.GetName())
+		service.logger.Info("Created state collection", logging.Fields{"collection": service.stateCollection.GetName()})
 	}
-	service.logger.Printf("Creating Bot State: %v.\n", state)
+	service.logger.Debug("Creating bot state", logging.Fields{"shardKey": state.GetShardKey(), "botType": state.GetBotType()})
 	err := service.stateCollection.Create(state)
-	service.logger.Printf("Created Bot State: %v.\n", state)
+	service.logger.Debug("Created bot state", logging.Fields{"shardKey": state.GetShardKey(), "botType": state.GetBotType()})
 	return err
 }
 
-// GetBotState returns the bot state.
-func (service *CosmosBotsService) GetBotState(ctx context.Context, state BotState) error {
-	if service.stateCollection == nil {
-		collection, err := service.collectionFactory.CreateCollection(ctx, service.stateCollectionName)
-		if err != nil {
-			return err
+// GetBotState returns the state of the bot type and instance.
+func (service *CosmosBotsService) GetBotState(ctx context.Context, botType string, instanceID string, state BotState) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	err = service.stateCollection.FindOne(bson.M{"bottype": botType, "instanceid": instanceID}, state)
+	if err != mgo.ErrNotFound || instanceID != DefaultInstanceID {
+		return err
+	}
+	// states stored before instance IDs and versions are adopted by the default instance.
+	// shard assignments stored with the bot's type are not states.
+	legacy := bson.M{
+		"bottype":    botType,
+		"instanceid": bson.M{"$exists": false},
+		"shardkey":   bson.M{"$ne": GetShardAssignmentShardKey(botType)},
+	}
+	err = service.stateCollection.FindOne(legacy, state)
+	if err != nil {
+		return err
+	}
+	selector := bson.M{"shardkey": state.GetShardKey(), "instanceid": bson.M{"$exists": false}}
+	change := bson.M{"$set": bson.M{"instanceid": instanceID, "version": int64(0)}}
+	err = service.stateCollection.Update(selector, change)
+	if err == mgo.ErrNotFound {
+		return ErrBotStateConflict
+	}
+	if err != nil {
+		return err
+	}
+	service.logger.Info("Adopted bot state", logging.Fields{"botType": botType, "shardKey": state.GetShardKey(), "instanceID": instanceID})
+	return service.stateCollection.FindOne(bson.M{"shardkey": state.GetShardKey()}, state)
+}
+
+// UpdateBotState applies the change if the stored state has the version of the state, incrementing the version.
+func (service *CosmosBotsService) UpdateBotState(ctx context.Context, state BotState, change bson.M) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	selector := bson.M{
+		"shardkey":   state.GetShardKey(),
+		"bottype":    state.GetBotType(),
+		"instanceid": state.GetInstanceID(),
+		"version":    state.GetVersion(),
+	}
+	versioned := bson.M{}
+	for operator, fields := range change {
+		versioned[operator] = fields
+	}
+	inc := bson.M{"version": 1}
+	if fields, ok := change["$inc"].(bson.M); ok {
+		for field, value := range fields {
+			inc[field] = value
 		}
-		service.stateCollection = collection
 	}
-	return service.stateCollection.FindOne(bson.M{"bottype": "AccountsBot"}, state)
+	versioned["$inc"] = inc
+	err = service.stateCollection.Update(selector, versioned)
+	if err == mgo.ErrNotFound {
+		return ErrBotStateConflict
+	}
+	if err != nil {
+		return err
+	}
+	state.SetVersion(state.GetVersion() + 1)
+	return nil
 }
 
-// UpdateBotState updates the bot state.
-func (service *CosmosBotsService) UpdateBotState(ctx context.Context, selector interface{}, update interface{}) error {
+func (service *CosmosBotsService) initializeCollection(ctx context.Context) error {
 	if service.stateCollection == nil {
 		collection, err := service.collectionFactory.CreateCollection(ctx, service.stateCollectionName)
 		if err != nil {
@@ -105,5 +251,5 @@ func (service *CosmosBotsService) UpdateBotState(ctx context.Context, selector i
 		}
 		service.stateCollection = collection
 	}
-	return service.stateCollection.Update(selector, update)
+	return nil
 }
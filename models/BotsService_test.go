@@ -1,13 +1,17 @@
 package models
 
 import (
+	"bytes"
 	"context"
-	"log"
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
 )
 
 func TestMockBotsService_CreateBotState(t *testing.T) {
@@ -77,7 +81,7 @@ func TestMockBotsService_CreateBotState(t *testing.T) {
 
 func TestDocumentDbBotsService_CreateBotState(t *testing.T) {
 	cosmosClient := &CosmosClient{
-		logger: log.New(os.Stderr, "CosmosClient | ", 0),
+		logger: logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "CosmosClient"),
 		configuration: CosmosConfiguration{
 			SubscriptionID:    "6951e94d-0947-4c5e-b865-f864609da246",
 			CloudName:         "AzurePublicCloud",
@@ -93,12 +97,12 @@ func TestDocumentDbBotsService_CreateBotState(t *testing.T) {
 	}
 	defer session.Close()
 	documentDbCollectionFactory := &CosmosCollectionFactory{
-		logger:       log.New(os.Stderr, "DocumentDbCollectionFactory | ", 0),
+		logger:       logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "DocumentDbCollectionFactory"),
 		cosmosClient: cosmosClient,
 		session:      session,
 	}
 	type fields struct {
-		logger              *log.Logger
+		logger              logging.Logger
 		collectionFactory   CollectionFactory
 		stateCollectionName string
 	}
@@ -111,7 +115,7 @@ func TestDocumentDbBotsService_CreateBotState(t *testing.T) {
 		{
 			name: "Should create bot state.",
 			fields: fields{
-				logger:              log.New(os.Stderr, "DocumentDbBotsService | ", 0),
+				logger:              logging.Component(logging.NewLogger(os.Stderr, logging.DebugLevel, logging.TextFormat), "DocumentDbBotsService"),
 				collectionFactory:   documentDbCollectionFactory,
 				stateCollectionName: "mock-bots",
 			},
@@ -150,3 +154,226 @@ func TestDocumentDbBotsService_CreateBotState(t *testing.T) {
 		})
 	}
 }
+
+func TestMockBotsService_UpdateBotState(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name        string
+		version     int64
+		wantErr     error
+		wantVersion int64
+	}{
+		{
+			name:        "Should update the state at the stored version.",
+			version:     0,
+			wantErr:     nil,
+			wantVersion: 1,
+		},
+		{
+			name:        "Should reject the state at a stale version.",
+			version:     -1,
+			wantErr:     ErrBotStateConflict,
+			wantVersion: -1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			service := &MockBotsService{
+				CollectionFactory: &MockCollectionFactory{
+					Collection: &MockCollection{},
+				},
+			}
+			err := service.CreateBotState(ctx, &MockBotState{ShardKey: "a", BotType: "AccountsBot", InstanceID: DefaultInstanceID})
+			if err != nil {
+				t.Fatalf("service.CreateBotState() error = %v", err)
+			}
+			state := &MockBotState{}
+			err = service.GetBotState(ctx, "AccountsBot", DefaultInstanceID, state)
+			if err != nil {
+				t.Fatalf("service.GetBotState() error = %v", err)
+			}
+			state.Version = tt.version
+			// Act
+			err = service.UpdateBotState(ctx, state, bson.M{"$set": bson.M{"lastwaketime": time.Now()}})
+			// Assert
+			if err != tt.wantErr {
+				t.Errorf("service.UpdateBotState() error = %v, want %v", err, tt.wantErr)
+			}
+			if state.Version != tt.wantVersion {
+				t.Errorf("state.Version = %v, want %v", state.Version, tt.wantVersion)
+			}
+			err = service.GetBotState(ctx, "AccountsBot", "other", &MockBotState{})
+			if err != ErrBotStateNotFound {
+				t.Errorf("service.GetBotState() error = %v, want %v", err, ErrBotStateNotFound)
+			}
+		})
+	}
+}
+
+// documentCollection is an in-memory collection matching equality, $exists and $ne queries.
+type documentCollection struct {
+	MockCollection
+	documents []bson.M
+}
+
+func toDocument(value interface{}) bson.M {
+	data, err := bson.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+	document := bson.M{}
+	if err := bson.Unmarshal(data, &document); err != nil {
+		panic(err)
+	}
+	return document
+}
+
+func (collection *documentCollection) find(query interface{}) bson.M {
+	for _, document := range collection.documents {
+		if matches(document, toDocument(query)) {
+			return document
+		}
+	}
+	return nil
+}
+
+func matches(document bson.M, query bson.M) bool {
+	for key, want := range query {
+		got, ok := document[key]
+		operators, isOperator := want.(bson.M)
+		switch {
+		case isOperator && operators["$exists"] != nil:
+			if ok != operators["$exists"].(bool) {
+				return false
+			}
+		case isOperator && operators["$ne"] != nil:
+			if reflect.DeepEqual(got, operators["$ne"]) {
+				return false
+			}
+		case !ok || !reflect.DeepEqual(got, want):
+			return false
+		}
+	}
+	return true
+}
+
+func (collection *documentCollection) FindOne(query interface{}, result interface{}) error {
+	document := collection.find(query)
+	if document == nil {
+		return mgo.ErrNotFound
+	}
+	data, err := bson.Marshal(document)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(data, result)
+}
+
+func (collection *documentCollection) Create(doc interface{}) error {
+	collection.documents = append(collection.documents, toDocument(doc))
+	return nil
+}
+
+func (collection *documentCollection) Update(selector interface{}, update interface{}) error {
+	document := collection.find(selector)
+	if document == nil {
+		return mgo.ErrNotFound
+	}
+	for key, value := range toDocument(update)["$set"].(bson.M) {
+		document[key] = value
+	}
+	return nil
+}
+
+func (collection *documentCollection) Upsert(selector interface{}, update interface{}) (*mgo.ChangeInfo, error) {
+	document := collection.find(selector)
+	if document == nil {
+		return nil, collection.Create(update)
+	}
+	for key := range document {
+		delete(document, key)
+	}
+	for key, value := range toDocument(update) {
+		document[key] = value
+	}
+	return nil, nil
+}
+
+func TestCosmosBotsService_GetBotState(t *testing.T) {
+	// Arrange
+	// states stored before instance IDs have no instanceid field.
+	legacyState := bson.M{"shardkey": "0123456789abcdef01234567", "bottype": "AccountsBot"}
+	legacyAssignment := &ShardAssignment{ShardKey: GetShardAssignmentShardKey("AccountsBot"), BotType: "AccountsBot", Workers: []string{"a"}}
+	tests := []struct {
+		name         string
+		documents    []interface{}
+		assignment   *ShardAssignment
+		wantShardKey string
+		wantErr      error
+		wantBotTypes []string
+	}{
+		{
+			name:         "Should adopt the legacy state stored after a legacy shard assignment.",
+			documents:    []interface{}{legacyAssignment, legacyState},
+			wantShardKey: "0123456789abcdef01234567",
+			wantBotTypes: []string{"AccountsBot", "AccountsBot"},
+		},
+		{
+			name:         "Should adopt the legacy state stored before a shard assignment.",
+			documents:    []interface{}{legacyState},
+			assignment:   &ShardAssignment{Workers: []string{"a"}},
+			wantShardKey: "0123456789abcdef01234567",
+			wantBotTypes: []string{"AccountsBot", "AccountsBotShards"},
+		},
+		{
+			name:         "Should not adopt a legacy shard assignment.",
+			documents:    []interface{}{legacyAssignment},
+			wantErr:      mgo.ErrNotFound,
+			wantBotTypes: []string{"AccountsBot"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collection := &documentCollection{}
+			for _, document := range tt.documents {
+				collection.Create(document)
+			}
+			var buf bytes.Buffer
+			service := NewCosmosBotsService(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), &MockCollectionFactory{Collection: &collection.MockCollection}, "bots").(*CosmosBotsService)
+			service.stateCollection = collection
+			if tt.assignment != nil {
+				if err := service.UpdateShardAssignment(context.Background(), "AccountsBot", tt.assignment); err != nil {
+					t.Fatalf("service.UpdateShardAssignment() error = %v", err)
+				}
+			}
+			state := &MockBotState{}
+			// Act
+			err := service.GetBotState(context.Background(), "AccountsBot", DefaultInstanceID, state)
+			// Assert
+			if err != tt.wantErr {
+				t.Fatalf("service.GetBotState() error = %v, want %v", err, tt.wantErr)
+			}
+			if state.ShardKey != tt.wantShardKey {
+				t.Errorf("state.ShardKey = %v, want %v", state.ShardKey, tt.wantShardKey)
+			}
+			if err == nil && state.InstanceID != DefaultInstanceID {
+				t.Errorf("state.InstanceID = %v, want %v", state.InstanceID, DefaultInstanceID)
+			}
+			botTypes := []string{}
+			for _, document := range collection.documents {
+				botTypes = append(botTypes, document["bottype"].(string))
+			}
+			if !reflect.DeepEqual(botTypes, tt.wantBotTypes) {
+				t.Errorf("botTypes = %v, want %v", botTypes, tt.wantBotTypes)
+			}
+			assignment, err := service.GetShardAssignment(context.Background(), "AccountsBot")
+			if err != nil {
+				t.Fatalf("service.GetShardAssignment() error = %v", err)
+			}
+			if assignment != nil && !reflect.DeepEqual(assignment.Workers, []string{"a"}) {
+				t.Errorf("assignment.Workers = %v, want %v", assignment.Workers, []string{"a"})
+			}
+		})
+	}
+}
@@ -1,43 +1,103 @@
 package models
 
 import (
-	"log"
+	"context"
+	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
 )
 
 // ComptrollerService is responsible for interacting with Comptroller contract.
 type ComptrollerService interface {
 	GetAccountLiquidity(opts *bind.CallOpts, account common.Address) (errorCode *big.Int, liquidity *big.Int, shortfall *big.Int, err error)
+	CloseFactorMantissa(opts *bind.CallOpts) (*big.Int, error)
+	LiquidationIncentiveMantissa(opts *bind.CallOpts) (*big.Int, error)
+	FilterNewCloseFactor(opts *bind.FilterOpts) (contracts.EventIterator, error)
+	FilterNewLiquidationIncentive(opts *bind.FilterOpts) (contracts.EventIterator, error)
+	RefreshPausedActions(ctx context.Context) error
+	IsActionPaused(market string, action string) bool
+	GetPausedActions(market string) []string
+	GetAssetsIn(opts *bind.CallOpts, account common.Address) ([]common.Address, error)
+	CheckMembership(opts *bind.CallOpts, account common.Address, cToken common.Address) (bool, error)
+	Markets(opts *bind.CallOpts, cToken common.Address) (ComptrollerMarket, error)
+	Oracle(opts *bind.CallOpts) (common.Address, error)
+	MaxAssets(opts *bind.CallOpts) (*big.Int, error)
+	LiquidateCalculateSeizeTokens(opts *bind.CallOpts, cTokenBorrowed common.Address, cTokenCollateral common.Address, repayAmount *big.Int) (errorCode *big.Int, seizeTokens *big.Int, err error)
+	FilterMarketEntered(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error)
+	FilterMarketExited(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error)
+}
+
+// ComptrollerMarket is the Comptroller's record of a market.
+type ComptrollerMarket struct {
+	IsListed                 bool
+	CollateralFactorMantissa *big.Int
 }
 
 // Comptroller contains blockchain client and state.
 type Comptroller struct {
-	logger   *log.Logger
-	address  common.Address
-	contract *contracts.Comptroller
+	logger        logging.Logger
+	address       common.Address
+	contract      *contracts.Comptroller
+	pausedActions *PausedActions
 }
 
 // MockComptroller enables unit testing.
+// Accounts without scripted results have liquidity 1 and no assets.
 type MockComptroller struct {
+	Accounts                        map[common.Address]*MockComptrollerAccount
+	MarketsByAddress                map[common.Address]ComptrollerMarket
+	OracleAddress                   common.Address
+	MaxAssetsCount                  *big.Int
+	MarketEnteredIterator           contracts.MarketMembershipIterator
+	MarketExitedIterator            contracts.MarketMembershipIterator
+	CalculateSeizeTokens            func(cTokenBorrowed common.Address, cTokenCollateral common.Address, repayAmount *big.Int) (*big.Int, error)
+	Paused                          map[string]bool
+	CloseFactor                     *big.Int
+	LiquidationIncentive            *big.Int
+	NewCloseFactorIterator          contracts.EventIterator
+	NewLiquidationIncentiveIterator contracts.EventIterator
+}
+
+// MockComptrollerAccount scripts the Comptroller results for an account.
+type MockComptrollerAccount struct {
+	ErrorCode *big.Int
+	Liquidity *big.Int
+	Shortfall *big.Int
+	AssetsIn  []common.Address
+	Err       error
 }
 
-// NewComptrollerService creates a new ComptrollerService
-func NewComptrollerService(logger *log.Logger, ethClient *ethclient.Client) ComptrollerService {
-	address := common.HexToAddress("0x3d9819210a31b4961b30ef54be2aed79b9c9cd3b")
+var (
+	// mockCloseFactorMantissa is 0.5.
+	mockCloseFactorMantissa = big.NewInt(500000000000000000)
+
+	// mockLiquidationIncentiveMantissa is 1.05.
+	mockLiquidationIncentiveMantissa = big.NewInt(1050000000000000000)
+
+	// mockMaxAssets is the maximum number of markets an account may enter.
+	mockMaxAssets = big.NewInt(20)
+
+	mockExpScale = big.NewInt(1000000000000000000)
+)
+
+// NewComptrollerService creates a new ComptrollerService for the Comptroller at the address.
+// pausedGauge reports whether each action is paused by the pause guardian.
+func NewComptrollerService(logger logging.Logger, ethClient contracts.Backend, address common.Address, pausedGauge Gauge) (ComptrollerService, error) {
 	contract, err := contracts.NewComptroller(address, ethClient)
 	if err != nil {
-		logger.Panicf("Failed to load comptroller: %v\n", err)
+		return nil, fmt.Errorf("failed to load comptroller at %v: %v", address.Hex(), err)
 	}
 	return &Comptroller{
-		logger:   logger,
-		address:  address,
-		contract: contract,
-	}
+		logger:        logger,
+		address:       address,
+		contract:      contract,
+		pausedActions: NewPausedActions(logger, contract, ethClient, pausedGauge),
+	}, nil
 }
 
 // GetAccountLiquidity returns the account's liquidity.
@@ -45,7 +105,240 @@ func (service *Comptroller) GetAccountLiquidity(opts *bind.CallOpts, account com
 	return service.contract.GetAccountLiquidity(opts, account)
 }
 
+// CloseFactorMantissa returns the maximum fraction of a borrow that can be repaid in one liquidation.
+func (service *Comptroller) CloseFactorMantissa(opts *bind.CallOpts) (*big.Int, error) {
+	return service.contract.CloseFactorMantissa(opts)
+}
+
+// LiquidationIncentiveMantissa returns the multiplier of collateral seized by liquidators.
+func (service *Comptroller) LiquidationIncentiveMantissa(opts *bind.CallOpts) (*big.Int, error) {
+	return service.contract.LiquidationIncentiveMantissa(opts)
+}
+
+// FilterNewCloseFactor returns the NewCloseFactor events.
+func (service *Comptroller) FilterNewCloseFactor(opts *bind.FilterOpts) (contracts.EventIterator, error) {
+	return service.contract.FilterNewCloseFactor(opts)
+}
+
+// FilterNewLiquidationIncentive returns the NewLiquidationIncentive events.
+func (service *Comptroller) FilterNewLiquidationIncentive(opts *bind.FilterOpts) (contracts.EventIterator, error) {
+	return service.contract.FilterNewLiquidationIncentive(opts)
+}
+
+// RefreshPausedActions updates the actions paused by the pause guardian.
+func (service *Comptroller) RefreshPausedActions(ctx context.Context) error {
+	return service.pausedActions.Refresh(ctx)
+}
+
+// IsActionPaused returns whether the action is paused for the market.
+func (service *Comptroller) IsActionPaused(market string, action string) bool {
+	return service.pausedActions.IsPaused(market, action)
+}
+
+// GetPausedActions returns the actions paused for the market.
+func (service *Comptroller) GetPausedActions(market string) []string {
+	return service.pausedActions.GetPausedActions(market)
+}
+
+// GetAssetsIn returns the markets the account entered.
+func (service *Comptroller) GetAssetsIn(opts *bind.CallOpts, account common.Address) ([]common.Address, error) {
+	return service.contract.GetAssetsIn(opts, account)
+}
+
+// CheckMembership returns whether the account entered the market.
+func (service *Comptroller) CheckMembership(opts *bind.CallOpts, account common.Address, cToken common.Address) (bool, error) {
+	return service.contract.CheckMembership(opts, account, cToken)
+}
+
+// Markets returns the Comptroller's record of the market.
+func (service *Comptroller) Markets(opts *bind.CallOpts, cToken common.Address) (ComptrollerMarket, error) {
+	market, err := service.contract.Markets(opts, cToken)
+	if err != nil {
+		return ComptrollerMarket{}, err
+	}
+	return ComptrollerMarket{
+		IsListed:                 market.IsListed,
+		CollateralFactorMantissa: market.CollateralFactorMantissa,
+	}, nil
+}
+
+// Oracle returns the address of the price oracle.
+func (service *Comptroller) Oracle(opts *bind.CallOpts) (common.Address, error) {
+	return service.contract.Oracle(opts)
+}
+
+// MaxAssets returns the maximum number of markets an account may enter.
+func (service *Comptroller) MaxAssets(opts *bind.CallOpts) (*big.Int, error) {
+	return service.contract.MaxAssets(opts)
+}
+
+// LiquidateCalculateSeizeTokens returns the collateral tokens seized for repaying the borrowed tokens.
+func (service *Comptroller) LiquidateCalculateSeizeTokens(opts *bind.CallOpts, cTokenBorrowed common.Address, cTokenCollateral common.Address, repayAmount *big.Int) (errorCode *big.Int, seizeTokens *big.Int, err error) {
+	return service.contract.LiquidateCalculateSeizeTokens(opts, cTokenBorrowed, cTokenCollateral, repayAmount)
+}
+
+// FilterMarketEntered returns the MarketEntered events.
+func (service *Comptroller) FilterMarketEntered(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error) {
+	return service.contract.FilterMarketEnteredEvents(opts)
+}
+
+// FilterMarketExited returns the MarketExited events.
+func (service *Comptroller) FilterMarketExited(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error) {
+	return service.contract.FilterMarketExitedEvents(opts)
+}
+
 // GetAccountLiquidity returns the account's liquidity.
 func (service *MockComptroller) GetAccountLiquidity(opts *bind.CallOpts, account common.Address) (errorCode *big.Int, liquidity *big.Int, shortfall *big.Int, err error) {
-	return common.Big0, common.Big1, common.Big0, nil
+	scripted, ok := service.Accounts[account]
+	if !ok {
+		return common.Big0, common.Big1, common.Big0, nil
+	}
+	if scripted.Err != nil {
+		return nil, nil, nil, scripted.Err
+	}
+	errorCode = scripted.ErrorCode
+	if errorCode == nil {
+		errorCode = common.Big0
+	}
+	return errorCode, orZero(scripted.Liquidity), orZero(scripted.Shortfall), nil
+}
+
+// GetAssetsIn returns the markets the account entered.
+func (service *MockComptroller) GetAssetsIn(opts *bind.CallOpts, account common.Address) ([]common.Address, error) {
+	scripted, ok := service.Accounts[account]
+	if !ok {
+		return []common.Address{}, nil
+	}
+	if scripted.Err != nil {
+		return nil, scripted.Err
+	}
+	return scripted.AssetsIn, nil
+}
+
+// CheckMembership returns whether the account entered the market.
+func (service *MockComptroller) CheckMembership(opts *bind.CallOpts, account common.Address, cToken common.Address) (bool, error) {
+	assetsIn, err := service.GetAssetsIn(opts, account)
+	if err != nil {
+		return false, err
+	}
+	for _, asset := range assetsIn {
+		if asset == cToken {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Markets returns the Comptroller's record of the market.
+func (service *MockComptroller) Markets(opts *bind.CallOpts, cToken common.Address) (ComptrollerMarket, error) {
+	market, ok := service.MarketsByAddress[cToken]
+	if !ok {
+		return ComptrollerMarket{CollateralFactorMantissa: common.Big0}, nil
+	}
+	return market, nil
+}
+
+// Oracle returns the address of the price oracle.
+func (service *MockComptroller) Oracle(opts *bind.CallOpts) (common.Address, error) {
+	return service.OracleAddress, nil
+}
+
+// MaxAssets returns the maximum number of markets an account may enter.
+func (service *MockComptroller) MaxAssets(opts *bind.CallOpts) (*big.Int, error) {
+	if service.MaxAssetsCount == nil {
+		return mockMaxAssets, nil
+	}
+	return service.MaxAssetsCount, nil
+}
+
+// LiquidateCalculateSeizeTokens returns the collateral tokens seized for repaying the borrowed tokens.
+// Unless scripted, prices and exchange rates are 1 so only the liquidation incentive applies.
+func (service *MockComptroller) LiquidateCalculateSeizeTokens(opts *bind.CallOpts, cTokenBorrowed common.Address, cTokenCollateral common.Address, repayAmount *big.Int) (errorCode *big.Int, seizeTokens *big.Int, err error) {
+	if service.CalculateSeizeTokens != nil {
+		seizeTokens, err = service.CalculateSeizeTokens(cTokenBorrowed, cTokenCollateral, repayAmount)
+		if err != nil {
+			return nil, nil, err
+		}
+		return common.Big0, seizeTokens, nil
+	}
+	liquidationIncentive, _ := service.LiquidationIncentiveMantissa(opts)
+	seizeTokens = new(big.Int).Mul(repayAmount, liquidationIncentive)
+	return common.Big0, seizeTokens.Quo(seizeTokens, mockExpScale), nil
+}
+
+// FilterMarketEntered returns the MarketEntered events.
+func (service *MockComptroller) FilterMarketEntered(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error) {
+	if service.MarketEnteredIterator == nil {
+		return &contracts.MockMarketMembershipIterator{}, nil
+	}
+	return service.MarketEnteredIterator, nil
+}
+
+// FilterMarketExited returns the MarketExited events.
+func (service *MockComptroller) FilterMarketExited(opts *bind.FilterOpts) (contracts.MarketMembershipIterator, error) {
+	if service.MarketExitedIterator == nil {
+		return &contracts.MockMarketMembershipIterator{}, nil
+	}
+	return service.MarketExitedIterator, nil
+}
+
+func orZero(value *big.Int) *big.Int {
+	if value == nil {
+		return common.Big0
+	}
+	return value
+}
+
+// CloseFactorMantissa returns the maximum fraction of a borrow that can be repaid in one liquidation.
+func (service *MockComptroller) CloseFactorMantissa(opts *bind.CallOpts) (*big.Int, error) {
+	if service.CloseFactor == nil {
+		return mockCloseFactorMantissa, nil
+	}
+	return service.CloseFactor, nil
+}
+
+// LiquidationIncentiveMantissa returns the multiplier of collateral seized by liquidators.
+func (service *MockComptroller) LiquidationIncentiveMantissa(opts *bind.CallOpts) (*big.Int, error) {
+	if service.LiquidationIncentive == nil {
+		return mockLiquidationIncentiveMantissa, nil
+	}
+	return service.LiquidationIncentive, nil
+}
+
+// FilterNewCloseFactor returns the NewCloseFactor events.
+func (service *MockComptroller) FilterNewCloseFactor(opts *bind.FilterOpts) (contracts.EventIterator, error) {
+	if service.NewCloseFactorIterator == nil {
+		return &contracts.MockEventIterator{}, nil
+	}
+	return service.NewCloseFactorIterator, nil
+}
+
+// FilterNewLiquidationIncentive returns the NewLiquidationIncentive events.
+func (service *MockComptroller) FilterNewLiquidationIncentive(opts *bind.FilterOpts) (contracts.EventIterator, error) {
+	if service.NewLiquidationIncentiveIterator == nil {
+		return &contracts.MockEventIterator{}, nil
+	}
+	return service.NewLiquidationIncentiveIterator, nil
+}
+
+// RefreshPausedActions updates the actions paused by the pause guardian.
+func (service *MockComptroller) RefreshPausedActions(ctx context.Context) error {
+	return nil
+}
+
+// IsActionPaused returns whether the action is paused for the market.
+func (service *MockComptroller) IsActionPaused(market string, action string) bool {
+	return service.Paused[action]
+}
+
+// GetPausedActions returns the actions paused for the market.
+func (service *MockComptroller) GetPausedActions(market string) []string {
+	actions := []string{}
+	for action, paused := range service.Paused {
+		if paused {
+			actions = append(actions, action)
+		}
+	}
+	sort.Strings(actions)
+	return actions
 }
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMockComptroller_GetAccountLiquidity(t *testing.T) {
+	// Arrange
+	healthy := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	underwater := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	failing := common.HexToAddress("0x0000000000000000000000000000000000000003")
+	comptroller := &MockComptroller{
+		Accounts: map[common.Address]*MockComptrollerAccount{
+			underwater: {Shortfall: big.NewInt(42)},
+			failing:    {Err: errors.New("call failed")},
+		},
+	}
+	tests := []struct {
+		name          string
+		account       common.Address
+		wantLiquidity *big.Int
+		wantShortfall *big.Int
+		wantErr       bool
+	}{
+		{
+			name:          "Should return default liquidity.",
+			account:       healthy,
+			wantLiquidity: big.NewInt(1),
+			wantShortfall: big.NewInt(0),
+			wantErr:       false,
+		},
+		{
+			name:          "Should return scripted shortfall.",
+			account:       underwater,
+			wantLiquidity: big.NewInt(0),
+			wantShortfall: big.NewInt(42),
+			wantErr:       false,
+		},
+		{
+			name:    "Should return scripted error.",
+			account: failing,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			errorCode, liquidity, shortfall, err := comptroller.GetAccountLiquidity(nil, tt.account)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("comptroller.GetAccountLiquidity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if errorCode.Sign() != 0 {
+				t.Errorf("errorCode = %v, want %v", errorCode, 0)
+			}
+			if liquidity.Cmp(tt.wantLiquidity) != 0 {
+				t.Errorf("liquidity = %v, want %v", liquidity, tt.wantLiquidity)
+			}
+			if shortfall.Cmp(tt.wantShortfall) != 0 {
+				t.Errorf("shortfall = %v, want %v", shortfall, tt.wantShortfall)
+			}
+		})
+	}
+}
+
+func TestMockComptroller_CheckMembership(t *testing.T) {
+	// Arrange
+	account := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	cBAT := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	cDAI := common.HexToAddress("0x5d3a536e4d6dbd6114cc1ead35777bab948e3643")
+	comptroller := &MockComptroller{
+		Accounts: map[common.Address]*MockComptrollerAccount{
+			account: {AssetsIn: []common.Address{cBAT}},
+		},
+	}
+	tests := []struct {
+		name    string
+		account common.Address
+		cToken  common.Address
+		want    bool
+	}{
+		{
+			name:    "Should be member of entered market.",
+			account: account,
+			cToken:  cBAT,
+			want:    true,
+		},
+		{
+			name:    "Should not be member of other market.",
+			account: account,
+			cToken:  cDAI,
+			want:    false,
+		},
+		{
+			name:    "Should not be member without scripted assets.",
+			account: common.Address{},
+			cToken:  cBAT,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			got, err := comptroller.CheckMembership(nil, tt.account, tt.cToken)
+			// Assert
+			if err != nil {
+				t.Fatalf("comptroller.CheckMembership() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("comptroller.CheckMembership() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,13 +4,14 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mongodb"
 	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/globalsign/mgo"
+
+	"github.com/l3a0/carbon/logging"
 )
 
 // OAuthGrantType specifies which grant type to use.
@@ -44,13 +45,13 @@ type CosmosConfiguration struct {
 
 // CosmosClient stores Cosmos DB client.
 type CosmosClient struct {
-	logger        *log.Logger
+	logger        logging.Logger
 	configuration CosmosConfiguration
 	client        documentdb.DatabaseAccountsClient
 }
 
 // NewCosmosService creates a new CosmosService
-func NewCosmosService(logger *log.Logger, configuration CosmosConfiguration) CosmosService {
+func NewCosmosService(logger logging.Logger, configuration CosmosConfiguration) CosmosService {
 	return &CosmosClient{
 		logger:        logger,
 		configuration: configuration,
@@ -84,13 +85,13 @@ func (c *CosmosClient) GetDatabaseClient() documentdb.DatabaseAccountsClient {
 func (c *CosmosClient) GetSession(ctx context.Context) (session *mgo.Session, err error) {
 	keys, err := c.client.ListKeys(ctx, c.configuration.ResourceGroupName, c.configuration.AccountName)
 	if err != nil {
-		c.logger.Printf("cannot list keys: %v\n", err)
+		c.logger.Error("Cannot list keys", logging.Fields{logging.ErrorField: err})
 		return nil, err
 	}
 	host := fmt.Sprintf("%s.documents.azure.com", c.configuration.AccountName)
 	session, err = mongodb.NewMongoDBClientWithCredentials(c.configuration.AccountName, *keys.PrimaryMasterKey, host)
 	if err != nil {
-		c.logger.Printf("cannot get mongoDB session: %v", err)
+		c.logger.Error("Cannot get mongoDB session", logging.Fields{logging.ErrorField: err})
 		return nil, err
 	}
 	return session, nil
@@ -131,7 +132,7 @@ func (c *CosmosClient) DeleteSQLContainer(ctx context.Context, containerName str
 	if err != nil {
 		return err
 	}
-	c.logger.Printf("Deleted container: %v", string(buf))
+	c.logger.Info("Deleted container", logging.Fields{"response": string(buf)})
 	return nil
 }
 
@@ -239,13 +240,13 @@ func (adapter *CosmosCollection) GetName() string {
 
 // CosmosCollectionFactory is responsible for creating Document DB collections.
 type CosmosCollectionFactory struct {
-	logger       *log.Logger
+	logger       logging.Logger
 	cosmosClient CosmosService
 	session      *mgo.Session
 }
 
 // NewCosmosCollectionFactory creates a new CollectionFactory.
-func NewCosmosCollectionFactory(logger *log.Logger, cosmosClient CosmosService, session *mgo.Session) CollectionFactory {
+func NewCosmosCollectionFactory(logger logging.Logger, cosmosClient CosmosService, session *mgo.Session) CollectionFactory {
 	return &CosmosCollectionFactory{
 		logger:       logger,
 		cosmosClient: cosmosClient,
@@ -258,10 +259,10 @@ func (factory *CosmosCollectionFactory) CreateCollection(ctx context.Context, co
 	adapter := &CosmosCollection{}
 	_, err := factory.cosmosClient.GetSQLContainer(ctx, collectionName)
 	if err != nil {
-		factory.logger.Printf("Collection not found: %v.\n", collectionName)
+		factory.logger.Info("Collection not found", logging.Fields{"collection": collectionName})
 	} else {
 		adapter.collection = factory.session.DB(factory.cosmosClient.GetDatabaseName()).C(collectionName)
-		factory.logger.Printf("Collection found: %v.\n", adapter.GetName())
+		factory.logger.Info("Collection found", logging.Fields{"collection": adapter.GetName()})
 		return adapter, nil
 	}
 	containerParameters := documentdb.SQLContainerCreateUpdateParameters{
@@ -276,7 +277,7 @@ func (factory *CosmosCollectionFactory) CreateCollection(ctx context.Context, co
 			Options: map[string]*string{},
 		},
 	}
-	factory.logger.Printf("Creating collection: %v.\n", *containerParameters.Resource.ID)
+	factory.logger.Info("Creating collection", logging.Fields{"collection": *containerParameters.Resource.ID})
 	future, err := factory.cosmosClient.CreateUpdateSQLContainer(ctx, collectionName, containerParameters)
 	if err != nil {
 		return nil, err
@@ -290,6 +291,6 @@ func (factory *CosmosCollectionFactory) CreateCollection(ctx context.Context, co
 		return nil, err
 	}
 	adapter.collection = factory.session.DB(factory.cosmosClient.GetDatabaseName()).C(collectionName)
-	factory.logger.Printf("Created collection: %v.\n", adapter.GetName())
+	factory.logger.Info("Created collection", logging.Fields{"collection": adapter.GetName()})
 	return adapter, nil
 }
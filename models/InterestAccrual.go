@@ -0,0 +1,247 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+)
+
+// InterestAccrual tracks each market's borrow index to project borrow balances
+// without calling BorrowBalanceCurrent per account.
+type InterestAccrual interface {
+	Refresh(ctx context.Context) error
+	GetBorrowIndexAt(ctx context.Context, tokenSymbol string, blockNumber uint64, logIndex uint) (*big.Int, error)
+	ProjectBorrowBalance(tokenSymbol string, principal *big.Int, interestIndex *big.Int) *big.Int
+	ProjectAccountBorrows(account *Account) map[string]*big.Int
+}
+
+// BorrowIndexAccrual tracks the borrow indexes through the AccrueInterest events of the token contracts.
+type BorrowIndexAccrual struct {
+	logger       logging.Logger
+	tokens       map[string]contracts.Token
+	headerReader HeaderReader
+	markets      map[string]*marketAccrual
+	lastBlock    uint64
+	mutex        sync.RWMutex
+}
+
+// marketAccrual is a market's borrow index as of its last accrual.
+type marketAccrual struct {
+	borrowIndex  *big.Int
+	accrualBlock uint64
+	borrowRate   *big.Int
+	// checkpoints are the AccrueInterest events applied by the last refresh.
+	checkpoints []borrowIndexCheckpoint
+}
+
+type borrowIndexCheckpoint struct {
+	blockNumber uint64
+	logIndex    uint
+	borrowIndex *big.Int
+}
+
+// MockInterestAccrual is used for testing.
+type MockInterestAccrual struct {
+	// BorrowIndexes default to 1.
+	BorrowIndexes map[string]*big.Int
+}
+
+var expScale = big.NewInt(1000000000000000000)
+
+// NewInterestAccrual creates a new InterestAccrual.
+func NewInterestAccrual(logger logging.Logger, tokensProvider contracts.TokensProvider, headerReader HeaderReader) InterestAccrual {
+	return &BorrowIndexAccrual{
+		logger:       logger,
+		tokens:       tokensProvider.GetTokens(),
+		headerReader: headerReader,
+		markets:      make(map[string]*marketAccrual),
+	}
+}
+
+// Refresh reads the borrow indexes on first use, then applies the AccrueInterest events since the last refresh.
+func (accrual *BorrowIndexAccrual) Refresh(ctx context.Context) error {
+	header, err := accrual.headerReader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	head := header.Number.Uint64()
+	accrual.mutex.Lock()
+	defer accrual.mutex.Unlock()
+	if head <= accrual.lastBlock {
+		return nil
+	}
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: header.Number}
+	for tokenSymbol, token := range accrual.tokens {
+		market, ok := accrual.markets[tokenSymbol]
+		if !ok {
+			market, err = accrual.readMarket(opts, token)
+			if err != nil {
+				return fmt.Errorf("failed to read %v borrow index: %v", tokenSymbol, err)
+			}
+			accrual.markets[tokenSymbol] = market
+			accrual.logger.Info("Read borrow index", logging.Fields{logging.TokenField: tokenSymbol, logging.BlockField: market.accrualBlock, "borrowIndex": market.borrowIndex})
+		} else {
+			err = accrual.applyAccrueInterestEvents(ctx, token, market, head)
+			if err != nil {
+				return fmt.Errorf("failed to apply %v AccrueInterest events: %v", tokenSymbol, err)
+			}
+		}
+		market.borrowRate, err = token.BorrowRatePerBlock(opts)
+		if err != nil {
+			return fmt.Errorf("failed to read %v borrow rate: %v", tokenSymbol, err)
+		}
+	}
+	accrual.lastBlock = head
+	return nil
+}
+
+// GetBorrowIndexAt returns the market's borrow index as of the event at the block and log index.
+// The Borrow event of a transaction follows its AccrueInterest event,
+// so the borrow index is that of the last AccrueInterest event before it.
+func (accrual *BorrowIndexAccrual) GetBorrowIndexAt(ctx context.Context, tokenSymbol string, blockNumber uint64, logIndex uint) (*big.Int, error) {
+	accrual.mutex.RLock()
+	market, ok := accrual.markets[tokenSymbol]
+	var borrowIndex *big.Int
+	if ok {
+		for _, checkpoint := range market.checkpoints {
+			if checkpoint.blockNumber > blockNumber || (checkpoint.blockNumber == blockNumber && checkpoint.logIndex > logIndex) {
+				break
+			}
+			borrowIndex = checkpoint.borrowIndex
+		}
+		if len(market.checkpoints) == 0 && blockNumber >= market.accrualBlock {
+			borrowIndex = market.borrowIndex
+		}
+	}
+	accrual.mutex.RUnlock()
+	if borrowIndex != nil {
+		return borrowIndex, nil
+	}
+	token, ok := accrual.tokens[tokenSymbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown token %v", tokenSymbol)
+	}
+	// The event precedes the tracked accruals, e.g. during the first sync.
+	return token.BorrowIndex(&bind.CallOpts{Context: ctx, BlockNumber: new(big.Int).SetUint64(blockNumber)})
+}
+
+// ProjectBorrowBalance returns the principal grown by the market's borrow index since the interest index,
+// as BorrowBalanceCurrent would at the last refreshed block.
+func (accrual *BorrowIndexAccrual) ProjectBorrowBalance(tokenSymbol string, principal *big.Int, interestIndex *big.Int) *big.Int {
+	if principal == nil {
+		return big.NewInt(0)
+	}
+	accrual.mutex.RLock()
+	defer accrual.mutex.RUnlock()
+	market, ok := accrual.markets[tokenSymbol]
+	if !ok || interestIndex == nil || interestIndex.Sign() == 0 {
+		return principal
+	}
+	borrowIndex := projectBorrowIndex(market, accrual.lastBlock)
+	balance := new(big.Int).Mul(principal, borrowIndex)
+	return balance.Div(balance, interestIndex)
+}
+
+// ProjectAccountBorrows returns the projected borrow balance of each of the account's borrowed markets.
+func (accrual *BorrowIndexAccrual) ProjectAccountBorrows(account *Account) map[string]*big.Int {
+	return projectAccountBorrows(accrual, account)
+}
+
+func (accrual *BorrowIndexAccrual) readMarket(opts *bind.CallOpts, token contracts.Token) (*marketAccrual, error) {
+	borrowIndex, err := token.BorrowIndex(opts)
+	if err != nil {
+		return nil, err
+	}
+	accrualBlock, err := token.AccrualBlockNumber(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &marketAccrual{
+		borrowIndex:  borrowIndex,
+		accrualBlock: accrualBlock.Uint64(),
+		checkpoints:  []borrowIndexCheckpoint{},
+	}, nil
+}
+
+func (accrual *BorrowIndexAccrual) applyAccrueInterestEvents(ctx context.Context, token contracts.Token, market *marketAccrual, head uint64) error {
+	iter, err := token.FilterAccrueInterestEvents(&bind.FilterOpts{Start: accrual.lastBlock + 1, End: &head, Context: ctx})
+	if err != nil {
+		return err
+	}
+	checkpoints := []borrowIndexCheckpoint{}
+	for iter.Next() {
+		event := iter.GetEvent()
+		checkpoints = append(checkpoints, borrowIndexCheckpoint{
+			blockNumber: event.GetBlockNumber(),
+			logIndex:    event.GetLogIndex(),
+			borrowIndex: event.GetBorrowIndex(),
+		})
+	}
+	market.checkpoints = checkpoints
+	if len(checkpoints) > 0 {
+		last := checkpoints[len(checkpoints)-1]
+		market.borrowIndex = last.borrowIndex
+		market.accrualBlock = last.blockNumber
+	}
+	return nil
+}
+
+// projectBorrowIndex applies the simple interest accrued since the market's last accrual, as accrueInterest does.
+func projectBorrowIndex(market *marketAccrual, blockNumber uint64) *big.Int {
+	if market.borrowRate == nil || blockNumber <= market.accrualBlock {
+		return market.borrowIndex
+	}
+	interestFactor := new(big.Int).Mul(market.borrowRate, new(big.Int).SetUint64(blockNumber-market.accrualBlock))
+	interest := new(big.Int).Mul(interestFactor, market.borrowIndex)
+	interest.Div(interest, expScale)
+	return interest.Add(interest, market.borrowIndex)
+}
+
+func projectAccountBorrows(accrual InterestAccrual, account *Account) map[string]*big.Int {
+	borrows := make(map[string]*big.Int)
+	for tokenSymbol, principal := range account.Borrows {
+		borrows[tokenSymbol] = accrual.ProjectBorrowBalance(tokenSymbol, principal, account.BorrowIndexes[tokenSymbol])
+	}
+	return borrows
+}
+
+// Refresh does nothing.
+func (accrual *MockInterestAccrual) Refresh(ctx context.Context) error {
+	return nil
+}
+
+// GetBorrowIndexAt returns the market's borrow index.
+func (accrual *MockInterestAccrual) GetBorrowIndexAt(ctx context.Context, tokenSymbol string, blockNumber uint64, logIndex uint) (*big.Int, error) {
+	return accrual.getBorrowIndex(tokenSymbol), nil
+}
+
+// ProjectBorrowBalance returns the principal grown by the market's borrow index since the interest index.
+func (accrual *MockInterestAccrual) ProjectBorrowBalance(tokenSymbol string, principal *big.Int, interestIndex *big.Int) *big.Int {
+	if principal == nil {
+		return big.NewInt(0)
+	}
+	if interestIndex == nil || interestIndex.Sign() == 0 {
+		return principal
+	}
+	balance := new(big.Int).Mul(principal, accrual.getBorrowIndex(tokenSymbol))
+	return balance.Div(balance, interestIndex)
+}
+
+// ProjectAccountBorrows returns the projected borrow balance of each of the account's borrowed markets.
+func (accrual *MockInterestAccrual) ProjectAccountBorrows(account *Account) map[string]*big.Int {
+	return projectAccountBorrows(accrual, account)
+}
+
+func (accrual *MockInterestAccrual) getBorrowIndex(tokenSymbol string) *big.Int {
+	borrowIndex, ok := accrual.BorrowIndexes[tokenSymbol]
+	if !ok {
+		return expScale
+	}
+	return borrowIndex
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+)
+
+func TestBorrowIndexAccrual_ProjectBorrowBalance(t *testing.T) {
+	// Arrange
+	oneIndex := big.NewInt(1000000000000000000)
+	laterIndex := big.NewInt(1050000000000000000)
+	token := &contracts.MockToken{
+		BorrowIndexMantissa: oneIndex,
+		AccrualBlock:        10,
+		// 1% per block.
+		BorrowRateMantissa: big.NewInt(10000000000000000),
+	}
+	tokensProvider := &contracts.MockTokenContracts{
+		Contracts: map[string]contracts.Token{contracts.CBATSymbol: token},
+	}
+	headerReader := &mockHeaderReader{}
+	var buf bytes.Buffer
+	accrual := NewInterestAccrual(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), tokensProvider, headerReader)
+	tests := []struct {
+		name                 string
+		head                 int64
+		accrueInterestEvents []contracts.TokenAccrueInterest
+		principal            *big.Int
+		interestIndex        *big.Int
+		wantBorrowIndex      *big.Int
+		want                 *big.Int
+	}{
+		{
+			name:            "Should read borrow index.",
+			head:            10,
+			principal:       big.NewInt(100),
+			interestIndex:   oneIndex,
+			wantBorrowIndex: oneIndex,
+			want:            big.NewInt(100),
+		},
+		{
+			name: "Should project borrow balance since the last AccrueInterest event.",
+			head: 20,
+			accrueInterestEvents: []contracts.TokenAccrueInterest{
+				&contracts.MockTokenAccrueInterest{BorrowIndex: laterIndex, BlockNumber: 15, LogIndex: 2},
+			},
+			principal:       big.NewInt(100),
+			interestIndex:   oneIndex,
+			wantBorrowIndex: laterIndex,
+			// 1.05 * (1 + 0.01 * 5)
+			want: big.NewInt(110),
+		},
+		{
+			name:            "Should project borrow balance since the interest index.",
+			head:            20,
+			principal:       big.NewInt(10500),
+			interestIndex:   laterIndex,
+			wantBorrowIndex: laterIndex,
+			want:            big.NewInt(11025),
+		},
+		{
+			name:            "Should not project borrow balance without interest index.",
+			head:            20,
+			principal:       big.NewInt(100),
+			interestIndex:   nil,
+			wantBorrowIndex: laterIndex,
+			want:            big.NewInt(100),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headerReader.head = tt.head
+			token.TokenAccrueInterestIterator = &contracts.MockTokenAccrueInterestIterator{AccrueInterestEvents: tt.accrueInterestEvents}
+			// Act
+			err := accrual.Refresh(context.Background())
+			// Assert
+			if err != nil {
+				t.Fatalf("accrual.Refresh() error = %v", err)
+			}
+			borrowIndex, err := accrual.GetBorrowIndexAt(context.Background(), contracts.CBATSymbol, 15, 3)
+			if err != nil {
+				t.Fatalf("accrual.GetBorrowIndexAt() error = %v", err)
+			}
+			if borrowIndex.Cmp(tt.wantBorrowIndex) != 0 {
+				t.Errorf("accrual.GetBorrowIndexAt() = %v, want %v", borrowIndex, tt.wantBorrowIndex)
+			}
+			got := accrual.ProjectBorrowBalance(contracts.CBATSymbol, tt.principal, tt.interestIndex)
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("accrual.ProjectBorrowBalance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+// Lease is a time-bounded claim of the leadership of a bot type.
+type Lease struct {
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey    string
+	BotType     string
+	Owner       string
+	LeaseExpiry time.Time
+}
+
+// LeaseService is responsible for the lease documents stored with the bots.
+type LeaseService interface {
+	AcquireLease(ctx context.Context, botType string, owner string, now time.Time, expiry time.Time) (bool, error)
+	ReleaseLease(ctx context.Context, botType string, owner string) error
+}
+
+// LeaderElector elects a single leader among the instances of a bot.
+type LeaderElector interface {
+	AcquireLeadership(ctx context.Context) (bool, error)
+	ResignLeadership(ctx context.Context) error
+	GetOwnerID() string
+}
+
+// CosmosLeaseService works against Cosmos DB SQL Core.
+type CosmosLeaseService struct {
+	logger              logging.Logger
+	collectionFactory   CollectionFactory
+	stateCollection     Collection
+	stateCollectionName string
+}
+
+// MockLeaseService works against an in-memory data store that is not durable.
+type MockLeaseService struct {
+	Leases map[string]*Lease
+	mutex  sync.Mutex
+}
+
+// LeaseLeaderElector elects the instance holding an unexpired lease, renewing it on every acquisition.
+type LeaseLeaderElector struct {
+	logger        logging.Logger
+	leaseService  LeaseService
+	botType       string
+	ownerID       string
+	leaseDuration time.Duration
+	now           func() time.Time
+	leader        bool
+}
+
+// NewCosmosLeaseService creates a new LeaseService storing leases in the bots collection.
+func NewCosmosLeaseService(logger logging.Logger, collectionFactory CollectionFactory, stateCollectionName string) LeaseService {
+	return &CosmosLeaseService{
+		logger:              logger,
+		collectionFactory:   collectionFactory,
+		stateCollectionName: stateCollectionName,
+	}
+}
+
+// NewLeaseLeaderElector creates a new LeaderElector.
+func NewLeaseLeaderElector(logger logging.Logger, leaseService LeaseService, botType string, ownerID string, leaseDuration time.Duration, now func() time.Time) LeaderElector {
+	if now == nil {
+		now = time.Now
+	}
+	return &LeaseLeaderElector{
+		logger:        logger,
+		leaseService:  leaseService,
+		botType:       botType,
+		ownerID:       ownerID,
+		leaseDuration: leaseDuration,
+		now:           now,
+	}
+}
+
+// GetLeaseShardKey returns the shard key of the bot type's lease.
+func GetLeaseShardKey(botType string) string {
+	return "lease-" + botType
+}
+
+// AcquireLease claims or renews the lease until the expiry if the owner holds it or it expired.
+func (service *CosmosLeaseService) AcquireLease(ctx context.Context, botType string, owner string, now time.Time, expiry time.Time) (bool, error) {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return false, err
+	}
+	shardKey := GetLeaseShardKey(botType)
+	selector := bson.M{
+		"shardkey": shardKey,
+		"$or": []bson.M{
+			{"owner": owner},
+			{"leaseexpiry": bson.M{"$lte": now}},
+		},
+	}
+	change := bson.M{"$set": bson.M{"owner": owner, "leaseexpiry": expiry}}
+	err = service.stateCollection.Update(selector, change)
+	if err == nil {
+		return true, nil
+	}
+	if err != mgo.ErrNotFound {
+		return false, err
+	}
+	// the lease is held by another owner or was never created.
+	lease := &Lease{
+		// a fixed ID makes concurrent creations of the lease conflict.
+		ID:          bson.ObjectId(crypto.Keccak256([]byte(shardKey))[:12]),
+		ShardKey:    shardKey,
+		BotType:     botType + "Lease",
+		Owner:       owner,
+		LeaseExpiry: expiry,
+	}
+	err = service.stateCollection.Create(lease)
+	if mgo.IsDup(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ReleaseLease expires the lease if the owner holds it.
+func (service *CosmosLeaseService) ReleaseLease(ctx context.Context, botType string, owner string) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	selector := bson.M{"shardkey": GetLeaseShardKey(botType), "owner": owner}
+	change := bson.M{"$set": bson.M{"leaseexpiry": time.Time{}}}
+	err = service.stateCollection.Update(selector, change)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (service *CosmosLeaseService) initializeCollection(ctx context.Context) error {
+	if service.stateCollection == nil {
+		collection, err := service.collectionFactory.CreateCollection(ctx, service.stateCollectionName)
+		if err != nil {
+			return err
+		}
+		service.stateCollection = collection
+	}
+	return nil
+}
+
+// AcquireLease claims or renews the lease until the expiry if the owner holds it or it expired.
+func (service *MockLeaseService) AcquireLease(ctx context.Context, botType string, owner string, now time.Time, expiry time.Time) (bool, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.Leases == nil {
+		service.Leases = make(map[string]*Lease)
+	}
+	lease, ok := service.Leases[botType]
+	if ok && lease.Owner != owner && lease.LeaseExpiry.After(now) {
+		return false, nil
+	}
+	service.Leases[botType] = &Lease{
+		ShardKey:    GetLeaseShardKey(botType),
+		BotType:     botType + "Lease",
+		Owner:       owner,
+		LeaseExpiry: expiry,
+	}
+	return true, nil
+}
+
+// ReleaseLease expires the lease if the owner holds it.
+func (service *MockLeaseService) ReleaseLease(ctx context.Context, botType string, owner string) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	lease, ok := service.Leases[botType]
+	if ok && lease.Owner == owner {
+		lease.LeaseExpiry = time.Time{}
+	}
+	return nil
+}
+
+// AcquireLeadership acquires or renews the lease, returning whether this instance is the leader.
+func (elector *LeaseLeaderElector) AcquireLeadership(ctx context.Context) (bool, error) {
+	now := elector.now()
+	leader, err := elector.leaseService.AcquireLease(ctx, elector.botType, elector.ownerID, now, now.Add(elector.leaseDuration))
+	if err != nil {
+		return false, err
+	}
+	if leader != elector.leader {
+		if leader {
+			elector.logger.Info("Acquired lease", logging.Fields{"owner": elector.ownerID, "botType": elector.botType})
+		} else {
+			elector.logger.Warn("Lost lease", logging.Fields{"owner": elector.ownerID, "botType": elector.botType})
+		}
+	}
+	elector.leader = leader
+	return leader, nil
+}
+
+// ResignLeadership releases the lease so a standby can take over without waiting for it to expire.
+func (elector *LeaseLeaderElector) ResignLeadership(ctx context.Context) error {
+	if !elector.leader {
+		return nil
+	}
+	err := elector.leaseService.ReleaseLease(ctx, elector.botType, elector.ownerID)
+	if err != nil {
+		return err
+	}
+	elector.leader = false
+	elector.logger.Info("Released lease", logging.Fields{"owner": elector.ownerID, "botType": elector.botType})
+	return nil
+}
+
+// GetOwnerID returns the ID of this instance.
+func (elector *LeaseLeaderElector) GetOwnerID() string {
+	return elector.ownerID
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+func TestLeaseLeaderElector_AcquireLeadership(t *testing.T) {
+	// Arrange
+	start := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		steps      []string
+		wantLeader []bool
+	}{
+		{
+			name:       "Should elect the first instance only.",
+			steps:      []string{"a", "b", "a", "b"},
+			wantLeader: []bool{true, false, true, false},
+		},
+		{
+			name:       "Should elect a standby once the lease expires.",
+			steps:      []string{"a", "expire", "b", "a"},
+			wantLeader: []bool{true, false, true, false},
+		},
+		{
+			name:       "Should elect a standby once the leader resigns.",
+			steps:      []string{"a", "resign", "b", "a"},
+			wantLeader: []bool{true, false, true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+			now := start
+			clock := func() time.Time {
+				return now
+			}
+			leaseService := &MockLeaseService{}
+			electors := map[string]LeaderElector{
+				"a": NewLeaseLeaderElector(logger, leaseService, "AccountsBot", "a", time.Minute, clock),
+				"b": NewLeaseLeaderElector(logger, leaseService, "AccountsBot", "b", time.Minute, clock),
+			}
+			for i, step := range tt.steps {
+				// Act
+				var leader bool
+				var err error
+				switch step {
+				case "expire":
+					now = now.Add(2 * time.Minute)
+				case "resign":
+					err = electors["a"].ResignLeadership(context.Background())
+				default:
+					leader, err = electors[step].AcquireLeadership(context.Background())
+				}
+				// Assert
+				if err != nil {
+					t.Fatalf("step %v error = %v", step, err)
+				}
+				if leader != tt.wantLeader[i] {
+					t.Errorf("step %v (%v) leader = %v, want %v", i, step, leader, tt.wantLeader[i])
+				}
+				now = now.Add(time.Second)
+			}
+		})
+	}
+}
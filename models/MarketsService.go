@@ -0,0 +1,239 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+)
+
+// Market represents the state of a token contract's market.
+type Market struct {
+	ID                 bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey           string
+	TokenSymbol        string
+	Address            string
+	AccrualBlockNumber uint64
+	TotalBorrows       *big.Int
+	TotalSupply        *big.Int
+	TotalReserves      *big.Int
+	Cash               *big.Int
+	ExchangeRate       *big.Int
+	BorrowRatePerBlock *big.Int
+	SupplyRatePerBlock *big.Int
+	ReserveFactor      *big.Int
+	InterestRateModel  string
+	SnapshotTime       time.Time
+}
+
+// MarketsService is responsible for CRUD on market data.
+type MarketsService interface {
+	GetMarkets(ctx context.Context) ([]*Market, error)
+	UpsertMarket(ctx context.Context, market *Market) error
+}
+
+// CosmosMarketsService works against Cosmos DB SQL Core.
+type CosmosMarketsService struct {
+	logger                logging.Logger
+	collectionFactory     CollectionFactory
+	marketsCollection     Collection
+	marketsCollectionName string
+}
+
+// MockMarketsService works against an in-memory data store that is not durable.
+type MockMarketsService struct {
+	Markets map[string]*Market
+	mutex   sync.Mutex
+}
+
+// NewCosmosMarketsService creates a new MarketsService.
+func NewCosmosMarketsService(logger logging.Logger, collectionFactory CollectionFactory, marketsCollectionName string) MarketsService {
+	return &CosmosMarketsService{
+		logger:                logger,
+		collectionFactory:     collectionFactory,
+		marketsCollectionName: marketsCollectionName,
+	}
+}
+
+// ReadMarket reads the market state from the token contract.
+func ReadMarket(opts *bind.CallOpts, tokenSymbol string, address common.Address, token contracts.Token) (*Market, error) {
+	market := &Market{
+		ShardKey:     tokenSymbol,
+		TokenSymbol:  tokenSymbol,
+		Address:      address.Hex(),
+		SnapshotTime: time.Now(),
+	}
+	readers := []struct {
+		name   string
+		read   func(opts *bind.CallOpts) (*big.Int, error)
+		result **big.Int
+	}{
+		{"total borrows", token.TotalBorrows, &market.TotalBorrows},
+		{"total supply", token.TotalSupply, &market.TotalSupply},
+		{"total reserves", token.TotalReserves, &market.TotalReserves},
+		{"cash", token.GetCash, &market.Cash},
+		{"exchange rate", token.ExchangeRateStored, &market.ExchangeRate},
+		{"borrow rate", token.BorrowRatePerBlock, &market.BorrowRatePerBlock},
+		{"supply rate", token.SupplyRatePerBlock, &market.SupplyRatePerBlock},
+		{"reserve factor", token.ReserveFactorMantissa, &market.ReserveFactor},
+	}
+	for _, reader := range readers {
+		value, err := reader.read(opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v %v: %v", tokenSymbol, reader.name, err)
+		}
+		*reader.result = value
+	}
+	accrualBlock, err := token.AccrualBlockNumber(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v accrual block: %v", tokenSymbol, err)
+	}
+	market.AccrualBlockNumber = accrualBlock.Uint64()
+	interestRateModel, err := token.InterestRateModel(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %v interest rate model: %v", tokenSymbol, err)
+	}
+	market.InterestRateModel = interestRateModel.Hex()
+	return market, nil
+}
+
+// GetMarkets returns all markets.
+func (service *CosmosMarketsService) GetMarkets(ctx context.Context) ([]*Market, error) {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	markets := []*Market{}
+	err = service.marketsCollection.FindAll(nil, &markets)
+	if err != nil {
+		return nil, err
+	}
+	return markets, nil
+}
+
+// UpsertMarket creates or updates a market. Markets are partitioned by token symbol.
+func (service *CosmosMarketsService) UpsertMarket(ctx context.Context, market *Market) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	if market.ID == "" {
+		market.ID = bson.NewObjectId()
+	}
+	market.ShardKey = market.TokenSymbol
+	_, err = service.marketsCollection.Upsert(bson.M{"shardkey": market.ShardKey}, market)
+	return err
+}
+
+func (service *CosmosMarketsService) initializeCollection(ctx context.Context) error {
+	if service.marketsCollection == nil {
+		collection, err := service.collectionFactory.CreateCollection(ctx, service.marketsCollectionName)
+		if err != nil {
+			return err
+		}
+		service.marketsCollection = collection
+	}
+	return nil
+}
+
+// GetMarkets returns all markets sorted by token symbol.
+func (service *MockMarketsService) GetMarkets(ctx context.Context) ([]*Market, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	markets := []*Market{}
+	for _, market := range service.Markets {
+		markets = append(markets, market)
+	}
+	sort.Slice(markets, func(i, j int) bool {
+		return markets[i].TokenSymbol < markets[j].TokenSymbol
+	})
+	return markets, nil
+}
+
+// UpsertMarket creates or updates a market.
+func (service *MockMarketsService) UpsertMarket(ctx context.Context, market *Market) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.Markets == nil {
+		service.Markets = make(map[string]*Market)
+	}
+	market.ShardKey = market.TokenSymbol
+	service.Markets[market.TokenSymbol] = market
+	return nil
+}
+
+// GetBSON marshals the market to BSON.
+func (market *Market) GetBSON() (interface{}, error) {
+	return bson.M{
+		"_id":                market.ID,
+		"shardkey":           market.ShardKey,
+		"tokensymbol":        market.TokenSymbol,
+		"address":            market.Address,
+		"accrualblocknumber": market.AccrualBlockNumber,
+		"totalborrows":       bigIntString(market.TotalBorrows),
+		"totalsupply":        bigIntString(market.TotalSupply),
+		"totalreserves":      bigIntString(market.TotalReserves),
+		"cash":               bigIntString(market.Cash),
+		"exchangerate":       bigIntString(market.ExchangeRate),
+		"borrowrateperblock": bigIntString(market.BorrowRatePerBlock),
+		"supplyrateperblock": bigIntString(market.SupplyRatePerBlock),
+		"reservefactor":      bigIntString(market.ReserveFactor),
+		"interestratemodel":  market.InterestRateModel,
+		"snapshottime":       market.SnapshotTime,
+	}, nil
+}
+
+// SetBSON unmarshals the BSON to Market.
+func (market *Market) SetBSON(raw bson.Raw) error {
+	var value bson.M
+	err := raw.Unmarshal(&value)
+	if err != nil {
+		return err
+	}
+	market.ID, _ = value["_id"].(bson.ObjectId)
+	market.ShardKey, _ = value["shardkey"].(string)
+	market.TokenSymbol, _ = value["tokensymbol"].(string)
+	market.Address, _ = value["address"].(string)
+	switch accrualBlockNumber := value["accrualblocknumber"].(type) {
+	case int64:
+		market.AccrualBlockNumber = uint64(accrualBlockNumber)
+	case int:
+		market.AccrualBlockNumber = uint64(accrualBlockNumber)
+	}
+	market.TotalBorrows = parseBigInt(value["totalborrows"])
+	market.TotalSupply = parseBigInt(value["totalsupply"])
+	market.TotalReserves = parseBigInt(value["totalreserves"])
+	market.Cash = parseBigInt(value["cash"])
+	market.ExchangeRate = parseBigInt(value["exchangerate"])
+	market.BorrowRatePerBlock = parseBigInt(value["borrowrateperblock"])
+	market.SupplyRatePerBlock = parseBigInt(value["supplyrateperblock"])
+	market.ReserveFactor = parseBigInt(value["reservefactor"])
+	market.InterestRateModel, _ = value["interestratemodel"].(string)
+	market.SnapshotTime, _ = value["snapshottime"].(time.Time)
+	return nil
+}
+
+func bigIntString(value *big.Int) string {
+	if value == nil {
+		return "0"
+	}
+	return value.String()
+}
+
+func parseBigInt(value interface{}) *big.Int {
+	result := big.NewInt(0)
+	text, ok := value.(string)
+	if ok {
+		result.SetString(text, 10)
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/contracts"
+)
+
+func TestReadMarket(t *testing.T) {
+	// Arrange
+	address := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	interestRateModel := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	token := &contracts.MockToken{
+		AccrualBlock:       10,
+		BorrowRateMantissa: big.NewInt(3),
+		Market: contracts.MockTokenMarket{
+			TotalBorrows:      big.NewInt(100),
+			TotalSupply:       big.NewInt(500),
+			TotalReserves:     big.NewInt(7),
+			Cash:              big.NewInt(400),
+			SupplyRate:        big.NewInt(2),
+			ReserveFactor:     big.NewInt(100000000000000000),
+			InterestRateModel: interestRateModel,
+		},
+	}
+	service := &MockMarketsService{}
+	// Act
+	market, err := ReadMarket(nil, contracts.CBATSymbol, address, token)
+	if err != nil {
+		t.Fatalf("ReadMarket() error = %v", err)
+	}
+	err = service.UpsertMarket(context.Background(), market)
+	if err != nil {
+		t.Fatalf("service.UpsertMarket() error = %v", err)
+	}
+	markets, _ := service.GetMarkets(context.Background())
+	// Assert
+	if len(markets) != 1 {
+		t.Fatalf("len(markets) = %v, want %v", len(markets), 1)
+	}
+	got := markets[0]
+	if got.ShardKey != contracts.CBATSymbol {
+		t.Errorf("got.ShardKey = %v, want %v", got.ShardKey, contracts.CBATSymbol)
+	}
+	if got.Address != address.Hex() {
+		t.Errorf("got.Address = %v, want %v", got.Address, address.Hex())
+	}
+	if got.AccrualBlockNumber != 10 {
+		t.Errorf("got.AccrualBlockNumber = %v, want %v", got.AccrualBlockNumber, 10)
+	}
+	wantAmounts := map[string][2]*big.Int{
+		"TotalBorrows":       {got.TotalBorrows, big.NewInt(100)},
+		"TotalSupply":        {got.TotalSupply, big.NewInt(500)},
+		"TotalReserves":      {got.TotalReserves, big.NewInt(7)},
+		"Cash":               {got.Cash, big.NewInt(400)},
+		"ExchangeRate":       {got.ExchangeRate, big.NewInt(1000000000000000000)},
+		"BorrowRatePerBlock": {got.BorrowRatePerBlock, big.NewInt(3)},
+		"SupplyRatePerBlock": {got.SupplyRatePerBlock, big.NewInt(2)},
+		"ReserveFactor":      {got.ReserveFactor, big.NewInt(100000000000000000)},
+	}
+	for name, amounts := range wantAmounts {
+		if amounts[0].Cmp(amounts[1]) != 0 {
+			t.Errorf("got.%v = %v, want %v", name, amounts[0], amounts[1])
+		}
+	}
+	if got.InterestRateModel != interestRateModel.Hex() {
+		t.Errorf("got.InterestRateModel = %v, want %v", got.InterestRateModel, interestRateModel.Hex())
+	}
+}
+
+func TestMarket_SetBSON(t *testing.T) {
+	// Arrange
+	market := &Market{
+		ID:                 bson.NewObjectId(),
+		ShardKey:           contracts.CBATSymbol,
+		TokenSymbol:        contracts.CBATSymbol,
+		Address:            "FakeAddress",
+		AccrualBlockNumber: 10,
+		TotalBorrows:       big.NewInt(100),
+		TotalSupply:        big.NewInt(500),
+		TotalReserves:      big.NewInt(7),
+		Cash:               big.NewInt(400),
+		ExchangeRate:       big.NewInt(1000000000000000000),
+		BorrowRatePerBlock: big.NewInt(3),
+		SupplyRatePerBlock: big.NewInt(2),
+		ReserveFactor:      big.NewInt(100000000000000000),
+		InterestRateModel:  "FakeInterestRateModel",
+	}
+	data, err := bson.Marshal(market)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw bson.Raw
+	err = bson.Unmarshal(data, &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := &Market{}
+	// Act
+	err = got.SetBSON(raw)
+	// Assert
+	if err != nil {
+		t.Errorf("Market.SetBSON() error = %v", err)
+	}
+	got.SnapshotTime = market.SnapshotTime
+	if !reflect.DeepEqual(got, market) {
+		t.Errorf("Market.SetBSON() = %v, want %v", got, market)
+	}
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+)
+
+const (
+	// ActionMint is the action name of mints in ActionPaused events.
+	ActionMint = "Mint"
+
+	// ActionBorrow is the action name of borrows in ActionPaused events.
+	ActionBorrow = "Borrow"
+
+	// ActionTransfer is the action name of transfers in ActionPaused events.
+	ActionTransfer = "Transfer"
+
+	// ActionSeize is the action name of seizes in ActionPaused events.
+	ActionSeize = "Seize"
+)
+
+// HeaderReader reads block headers, e.g. ethclient.Client.
+type HeaderReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// Gauge records a value by label values, e.g. metrics.Gauge.
+type Gauge interface {
+	Set(value float64, labelValues ...string)
+}
+
+// PauseGuardian is the part of the Comptroller contract exposing the pause guardian state.
+type PauseGuardian interface {
+	MintGuardianPaused(opts *bind.CallOpts) (bool, error)
+	BorrowGuardianPaused(opts *bind.CallOpts) (bool, error)
+	TransferGuardianPaused(opts *bind.CallOpts) (bool, error)
+	SeizeGuardianPaused(opts *bind.CallOpts) (bool, error)
+	FilterActionPausedEvents(opts *bind.FilterOpts) (contracts.ActionPausedIterator, error)
+}
+
+// PausedActions tracks the actions paused by the Comptroller pause guardian.
+// The deployed Comptroller pauses actions for all markets at once,
+// so every market reports the same paused actions.
+type PausedActions struct {
+	logger        logging.Logger
+	pauseGuardian PauseGuardian
+	headerReader  HeaderReader
+	pausedGauge   Gauge
+	paused        map[string]bool
+	lastBlock     uint64
+	mutex         sync.RWMutex
+}
+
+// MockHeaderReader is used for testing.
+type MockHeaderReader struct {
+	Head int64
+}
+
+// MockPauseGuardian is used for testing.
+type MockPauseGuardian struct {
+	Paused               map[string]bool
+	ActionPausedIterator contracts.ActionPausedIterator
+}
+
+// NewPausedActions creates a new PausedActions.
+// After each refresh, pausedGauge is set to 1 for the paused actions and 0 for the others, by action.
+func NewPausedActions(logger logging.Logger, pauseGuardian PauseGuardian, headerReader HeaderReader, pausedGauge Gauge) *PausedActions {
+	return &PausedActions{
+		logger:        logger,
+		pauseGuardian: pauseGuardian,
+		headerReader:  headerReader,
+		pausedGauge:   pausedGauge,
+	}
+}
+
+// Refresh reads the guardian flags on first use, then applies the ActionPaused events since the last refresh.
+func (p *PausedActions) Refresh(ctx context.Context) error {
+	header, err := p.headerReader.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return err
+	}
+	head := header.Number.Uint64()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.paused == nil {
+		paused, err := p.readPaused(&bind.CallOpts{Context: ctx, BlockNumber: header.Number})
+		if err != nil {
+			return err
+		}
+		p.paused = paused
+		p.lastBlock = head
+		p.logger.Info("Read paused actions", logging.Fields{logging.BlockField: head, "paused": p.getPausedActions()})
+		p.setPausedGauge()
+		return nil
+	}
+	if head <= p.lastBlock {
+		return nil
+	}
+	iter, err := p.pauseGuardian.FilterActionPausedEvents(&bind.FilterOpts{Start: p.lastBlock + 1, End: &head, Context: ctx})
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.Next() {
+		event := iter.GetEvent()
+		p.paused[event.GetAction()] = event.GetPauseState()
+		p.logger.Info("Pause guardian set action", logging.Fields{"action": event.GetAction(), "paused": event.GetPauseState(), logging.BlockField: event.GetBlockNumber()})
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	p.lastBlock = head
+	p.setPausedGauge()
+	return nil
+}
+
+// IsPaused returns whether the action is paused for the market.
+func (p *PausedActions) IsPaused(market string, action string) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.paused[action]
+}
+
+// GetPausedActions returns the sorted names of the actions paused for the market.
+func (p *PausedActions) GetPausedActions(market string) []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return p.getPausedActions()
+}
+
+func (p *PausedActions) getPausedActions() []string {
+	actions := []string{}
+	for action, paused := range p.paused {
+		if paused {
+			actions = append(actions, action)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// setPausedGauge sets the gauge of every action. The actions must be locked.
+func (p *PausedActions) setPausedGauge() {
+	for _, action := range []string{ActionMint, ActionBorrow, ActionTransfer, ActionSeize} {
+		value := 0.0
+		if p.paused[action] {
+			value = 1
+		}
+		p.pausedGauge.Set(value, action)
+	}
+}
+
+func (p *PausedActions) readPaused(opts *bind.CallOpts) (map[string]bool, error) {
+	readers := map[string]func(opts *bind.CallOpts) (bool, error){
+		ActionMint:     p.pauseGuardian.MintGuardianPaused,
+		ActionBorrow:   p.pauseGuardian.BorrowGuardianPaused,
+		ActionTransfer: p.pauseGuardian.TransferGuardianPaused,
+		ActionSeize:    p.pauseGuardian.SeizeGuardianPaused,
+	}
+	paused := make(map[string]bool)
+	for action, reader := range readers {
+		value, err := reader(opts)
+		if err != nil {
+			return nil, err
+		}
+		paused[action] = value
+	}
+	return paused, nil
+}
+
+// MintGuardianPaused returns whether mints are paused.
+func (g *MockPauseGuardian) MintGuardianPaused(opts *bind.CallOpts) (bool, error) {
+	return g.Paused[ActionMint], nil
+}
+
+// BorrowGuardianPaused returns whether borrows are paused.
+func (g *MockPauseGuardian) BorrowGuardianPaused(opts *bind.CallOpts) (bool, error) {
+	return g.Paused[ActionBorrow], nil
+}
+
+// TransferGuardianPaused returns whether transfers are paused.
+func (g *MockPauseGuardian) TransferGuardianPaused(opts *bind.CallOpts) (bool, error) {
+	return g.Paused[ActionTransfer], nil
+}
+
+// SeizeGuardianPaused returns whether seizes are paused.
+func (g *MockPauseGuardian) SeizeGuardianPaused(opts *bind.CallOpts) (bool, error) {
+	return g.Paused[ActionSeize], nil
+}
+
+// FilterActionPausedEvents returns the ActionPaused events.
+func (g *MockPauseGuardian) FilterActionPausedEvents(opts *bind.FilterOpts) (contracts.ActionPausedIterator, error) {
+	if g.ActionPausedIterator == nil {
+		return &contracts.MockActionPausedIterator{}, nil
+	}
+	return g.ActionPausedIterator, nil
+}
+
+// HeaderByNumber returns the head block header.
+func (reader *MockHeaderReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(reader.Head)}, nil
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+)
+
+type mockHeaderReader struct {
+	head int64
+}
+
+func (reader *mockHeaderReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(reader.head)}, nil
+}
+
+type mockGauge struct {
+	values map[string]float64
+}
+
+func (gauge *mockGauge) Set(value float64, labelValues ...string) {
+	gauge.values[labelValues[0]] = value
+}
+
+func TestPausedActions_Refresh(t *testing.T) {
+	// Arrange
+	pauseGuardian := &MockPauseGuardian{
+		Paused: map[string]bool{ActionMint: true},
+	}
+	headerReader := &mockHeaderReader{}
+	pausedGauge := &mockGauge{values: map[string]float64{}}
+	var buf bytes.Buffer
+	pausedActions := NewPausedActions(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), pauseGuardian, headerReader, pausedGauge)
+	tests := []struct {
+		name               string
+		head               int64
+		actionPausedEvents []contracts.ActionPaused
+		wantSeizePaused    bool
+		want               []string
+		wantGauge          map[string]float64
+	}{
+		{
+			name:            "Should read guardian flags.",
+			head:            10,
+			wantSeizePaused: false,
+			want:            []string{ActionMint},
+			wantGauge:       map[string]float64{ActionMint: 1, ActionBorrow: 0, ActionTransfer: 0, ActionSeize: 0},
+		},
+		{
+			name: "Should pause seize.",
+			head: 11,
+			actionPausedEvents: []contracts.ActionPaused{
+				&contracts.MockActionPaused{Action: ActionSeize, PauseState: true, BlockNumber: 11},
+			},
+			wantSeizePaused: true,
+			want:            []string{ActionMint, ActionSeize},
+			wantGauge:       map[string]float64{ActionMint: 1, ActionBorrow: 0, ActionTransfer: 0, ActionSeize: 1},
+		},
+		{
+			name: "Should unpause mint and seize.",
+			head: 12,
+			actionPausedEvents: []contracts.ActionPaused{
+				&contracts.MockActionPaused{Action: ActionMint, PauseState: false, BlockNumber: 12},
+				&contracts.MockActionPaused{Action: ActionSeize, PauseState: false, BlockNumber: 12},
+			},
+			wantSeizePaused: false,
+			want:            []string{},
+			wantGauge:       map[string]float64{ActionMint: 0, ActionBorrow: 0, ActionTransfer: 0, ActionSeize: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headerReader.head = tt.head
+			pauseGuardian.ActionPausedIterator = &contracts.MockActionPausedIterator{ActionPausedEvents: tt.actionPausedEvents}
+			// Act
+			err := pausedActions.Refresh(context.Background())
+			// Assert
+			if err != nil {
+				t.Fatalf("pausedActions.Refresh() error = %v", err)
+			}
+			if got := pausedActions.IsPaused(contracts.CBATSymbol, ActionSeize); got != tt.wantSeizePaused {
+				t.Errorf("pausedActions.IsPaused() = %v, want %v", got, tt.wantSeizePaused)
+			}
+			if got := pausedActions.GetPausedActions(contracts.CBATSymbol); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pausedActions.GetPausedActions() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(pausedGauge.values, tt.wantGauge) {
+				t.Errorf("pausedGauge.values = %v, want %v", pausedGauge.values, tt.wantGauge)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// Worker is a bot instance checking the accounts of its shard.
+type Worker struct {
+	ID            bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey      string
+	BotType       string
+	WorkerID      string
+	LastHeartbeat time.Time
+}
+
+// ShardAssignment is the set of workers sharing the accounts of a bot type.
+// Its bot type is suffixed so the assignment is never read as a bot state.
+type ShardAssignment struct {
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey    string
+	BotType     string
+	Workers     []string
+	Replicas    int
+	Version     int64
+	UpdatedTime time.Time
+}
+
+// GetWorkerShardKey returns the shard key of a worker's heartbeat.
+func GetWorkerShardKey(botType string, workerID string) string {
+	return "worker-" + botType + "-" + workerID
+}
+
+// GetShardAssignmentShardKey returns the shard key of the bot type's shard assignment.
+func GetShardAssignmentShardKey(botType string) string {
+	return "shards-" + botType
+}
+
+// RegisterWorker records the heartbeat of the worker.
+func (service *CosmosBotsService) RegisterWorker(ctx context.Context, botType string, workerID string, heartbeat time.Time) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	shardKey := GetWorkerShardKey(botType, workerID)
+	change := bson.M{"$set": bson.M{"bottype": botType + "Worker", "workerid": workerID, "lastheartbeat": heartbeat}}
+	_, err = service.stateCollection.Upsert(bson.M{"shardkey": shardKey}, change)
+	return err
+}
+
+// GetWorkers returns the workers with a heartbeat since the given time.
+func (service *CosmosBotsService) GetWorkers(ctx context.Context, botType string, aliveSince time.Time) ([]*Worker, error) {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	workers := []*Worker{}
+	query := bson.M{"bottype": botType + "Worker", "lastheartbeat": bson.M{"$gte": aliveSince}}
+	err = service.stateCollection.FindAll(query, &workers)
+	if err != nil {
+		return nil, err
+	}
+	return workers, nil
+}
+
+// GetShardAssignment returns the shard assignment of the bot type, or nil if none was stored.
+func (service *CosmosBotsService) GetShardAssignment(ctx context.Context, botType string) (*ShardAssignment, error) {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	assignment := &ShardAssignment{}
+	err = service.stateCollection.FindOne(bson.M{"shardkey": GetShardAssignmentShardKey(botType)}, assignment)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// UpdateShardAssignment stores the shard assignment of the bot type.
+func (service *CosmosBotsService) UpdateShardAssignment(ctx context.Context, botType string, assignment *ShardAssignment) error {
+	err := service.initializeCollection(ctx)
+	if err != nil {
+		return err
+	}
+	assignment.ShardKey = GetShardAssignmentShardKey(botType)
+	assignment.BotType = botType + "Shards"
+	_, err = service.stateCollection.Upsert(bson.M{"shardkey": assignment.ShardKey}, assignment)
+	return err
+}
+
+// RegisterWorker records the heartbeat of the worker.
+func (service *MockBotsService) RegisterWorker(ctx context.Context, botType string, workerID string, heartbeat time.Time) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.Workers == nil {
+		service.Workers = make(map[string]*Worker)
+	}
+	shardKey := GetWorkerShardKey(botType, workerID)
+	service.Workers[shardKey] = &Worker{
+		ShardKey:      shardKey,
+		BotType:       botType + "Worker",
+		WorkerID:      workerID,
+		LastHeartbeat: heartbeat,
+	}
+	return nil
+}
+
+// GetWorkers returns the workers with a heartbeat since the given time.
+func (service *MockBotsService) GetWorkers(ctx context.Context, botType string, aliveSince time.Time) ([]*Worker, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	workers := []*Worker{}
+	for _, worker := range service.Workers {
+		if worker.BotType == botType+"Worker" && !worker.LastHeartbeat.Before(aliveSince) {
+			workers = append(workers, worker)
+		}
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].WorkerID < workers[j].WorkerID
+	})
+	return workers, nil
+}
+
+// GetShardAssignment returns the shard assignment of the bot type, or nil if none was stored.
+func (service *MockBotsService) GetShardAssignment(ctx context.Context, botType string) (*ShardAssignment, error) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	assignment, ok := service.ShardAssignments[botType]
+	if !ok {
+		return nil, nil
+	}
+	copied := *assignment
+	return &copied, nil
+}
+
+// UpdateShardAssignment stores the shard assignment of the bot type.
+func (service *MockBotsService) UpdateShardAssignment(ctx context.Context, botType string, assignment *ShardAssignment) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.ShardAssignments == nil {
+		service.ShardAssignments = make(map[string]*ShardAssignment)
+	}
+	assignment.ShardKey = GetShardAssignmentShardKey(botType)
+	assignment.BotType = botType + "Shards"
+	copied := *assignment
+	service.ShardAssignments[botType] = &copied
+	return nil
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+const (
+	// TransactionStatusPending is a transaction that has been sent but not mined.
+	TransactionStatusPending = "pending"
+
+	// TransactionStatusMined is a transaction that has been mined successfully.
+	TransactionStatusMined = "mined"
+
+	// TransactionStatusFailed is a transaction that has been mined but reverted.
+	TransactionStatusFailed = "failed"
+
+	// TransactionStatusReplaced is a transaction that has been replaced by another one with the same nonce.
+	TransactionStatusReplaced = "replaced"
+
+	// TransactionStatusDropped is a transaction that was given up on after it could no longer be replaced.
+	TransactionStatusDropped = "dropped"
+)
+
+// TransactionRecord represents a transaction sent by the bot.
+type TransactionRecord struct {
+	ID          bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey    string
+	Hash        string
+	Label       string
+	From        string
+	To          string
+	Nonce       uint64
+	GasPrice    string
+	GasLimit    uint64
+	Status      string
+	ReplacedBy  string
+	BlockNumber uint64
+	SentTime    time.Time
+	MinedTime   time.Time
+}
+
+// TransactionsService is responsible for CRUD on sent transactions.
+type TransactionsService interface {
+	CreateTransaction(ctx context.Context, record *TransactionRecord) error
+	UpdateTransaction(ctx context.Context, record *TransactionRecord) error
+}
+
+// CosmosTransactionsService works against Cosmos DB SQL Core.
+type CosmosTransactionsService struct {
+	logger                     logging.Logger
+	collectionFactory          CollectionFactory
+	transactionsCollection     Collection
+	transactionsCollectionName string
+}
+
+// NewCosmosTransactionsService creates a new TransactionsService.
+func NewCosmosTransactionsService(logger logging.Logger, collectionFactory CollectionFactory, transactionsCollectionName string) TransactionsService {
+	return &CosmosTransactionsService{
+		logger:                     logger,
+		collectionFactory:          collectionFactory,
+		transactionsCollectionName: transactionsCollectionName,
+	}
+}
+
+// CreateTransaction records a newly sent transaction.
+func (service *CosmosTransactionsService) CreateTransaction(ctx context.Context, record *TransactionRecord) error {
+	if service.transactionsCollection == nil {
+		collection, err := service.collectionFactory.CreateCollection(ctx, service.transactionsCollectionName)
+		if err != nil {
+			return err
+		}
+		service.transactionsCollection = collection
+	}
+	return service.transactionsCollection.Create(record)
+}
+
+// UpdateTransaction updates the status of a sent transaction.
+func (service *CosmosTransactionsService) UpdateTransaction(ctx context.Context, record *TransactionRecord) error {
+	if service.transactionsCollection == nil {
+		collection, err := service.collectionFactory.CreateCollection(ctx, service.transactionsCollectionName)
+		if err != nil {
+			return err
+		}
+		service.transactionsCollection = collection
+	}
+	_, err := service.transactionsCollection.Upsert(bson.M{"shardkey": record.ShardKey}, record)
+	return err
+}
+
+// MockTransactionsService works against an in-memory data store that is not durable.
+type MockTransactionsService struct {
+	Records map[string]*TransactionRecord
+	mutex   sync.Mutex
+}
+
+// CreateTransaction records a newly sent transaction.
+func (service *MockTransactionsService) CreateTransaction(ctx context.Context, record *TransactionRecord) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	if service.Records == nil {
+		service.Records = make(map[string]*TransactionRecord)
+	}
+	stored := *record
+	service.Records[record.ShardKey] = &stored
+	return nil
+}
+
+// UpdateTransaction updates the status of a sent transaction.
+func (service *MockTransactionsService) UpdateTransaction(ctx context.Context, record *TransactionRecord) error {
+	return service.CreateTransaction(ctx, record)
+}
+
+// GetTransaction returns a copy of the recorded transaction.
+func (service *MockTransactionsService) GetTransaction(hash string) (TransactionRecord, bool) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	record, ok := service.Records[hash]
+	if !ok {
+		return TransactionRecord{}, false
+	}
+	return *record, true
+}
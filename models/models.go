@@ -2,18 +2,52 @@ package models
 
 import (
 	"math/big"
+	"sort"
 
 	"github.com/globalsign/mgo/bson"
 )
 
 // Account represents an account with debt.
 type Account struct {
-	ID        bson.ObjectId `bson:"_id,omitempty"`
-	ShardKey  string
-	Address   string
-	Borrows   map[string]*big.Int
-	Liquidity *big.Int
-	Shortfall *big.Int
+	ID         bson.ObjectId `bson:"_id,omitempty"`
+	ShardKey   string
+	Address    string
+	Borrows    map[string]*big.Int
+	Liquidity  *big.Int
+	Shortfall  *big.Int
+	Markets    []string
+	Collateral map[string]*big.Int
+	// BorrowIndexes are the market borrow indexes as of the stored borrow balances.
+	BorrowIndexes map[string]*big.Int
+}
+
+// HasEnteredMarket returns whether the account entered the market as collateral.
+func (account *Account) HasEnteredMarket(tokenSymbol string) bool {
+	for _, market := range account.Markets {
+		if market == tokenSymbol {
+			return true
+		}
+	}
+	return false
+}
+
+// EnterMarket adds the market to the account's entered markets.
+func (account *Account) EnterMarket(tokenSymbol string) {
+	if !account.HasEnteredMarket(tokenSymbol) {
+		account.Markets = append(account.Markets, tokenSymbol)
+		sort.Strings(account.Markets)
+	}
+}
+
+// ExitMarket removes the market from the account's entered markets.
+func (account *Account) ExitMarket(tokenSymbol string) {
+	markets := []string{}
+	for _, market := range account.Markets {
+		if market != tokenSymbol {
+			markets = append(markets, market)
+		}
+	}
+	account.Markets = markets
 }
 
 // GetBSON marshals the account to BSON.
@@ -22,13 +56,26 @@ func (account *Account) GetBSON() (interface{}, error) {
 	for tokenSymbol, borrow := range account.Borrows {
 		borrows[tokenSymbol] = borrow.String()
 	}
+	markets := []string{}
+	markets = append(markets, account.Markets...)
+	collateral := make(map[string]string)
+	for tokenSymbol, balance := range account.Collateral {
+		collateral[tokenSymbol] = balance.String()
+	}
+	borrowIndexes := make(map[string]string)
+	for tokenSymbol, borrowIndex := range account.BorrowIndexes {
+		borrowIndexes[tokenSymbol] = borrowIndex.String()
+	}
 	bson := bson.M{
-		"_id":       account.ID,
-		"shardkey":  account.ShardKey,
-		"address":   account.Address,
-		"borrows":   borrows,
-		"liquidity": account.Liquidity.String(),
-		"shortfall": account.Shortfall.String(),
+		"_id":           account.ID,
+		"shardkey":      account.ShardKey,
+		"address":       account.Address,
+		"borrows":       borrows,
+		"liquidity":     account.Liquidity.String(),
+		"shortfall":     account.Shortfall.String(),
+		"markets":       markets,
+		"collateral":    collateral,
+		"borrowindexes": borrowIndexes,
 	}
 	return bson, nil
 }
@@ -57,5 +104,30 @@ func (account *Account) SetBSON(raw bson.Raw) error {
 		account.Shortfall = big.NewInt(0)
 		account.Shortfall.SetString(shortfall.(string), 10)
 	}
+	account.Markets = []string{}
+	markets, ok := value["markets"].([]interface{})
+	if ok {
+		for _, market := range markets {
+			account.Markets = append(account.Markets, market.(string))
+		}
+	}
+	account.Collateral = make(map[string]*big.Int)
+	collateral, ok := value["collateral"].(bson.M)
+	if ok {
+		for tokenSymbol, balance := range collateral {
+			balanceValue := big.NewInt(0)
+			balanceValue.SetString(balance.(string), 10)
+			account.Collateral[tokenSymbol] = balanceValue
+		}
+	}
+	account.BorrowIndexes = make(map[string]*big.Int)
+	borrowIndexes, ok := value["borrowindexes"].(bson.M)
+	if ok {
+		for tokenSymbol, borrowIndex := range borrowIndexes {
+			borrowIndexValue := big.NewInt(0)
+			borrowIndexValue.SetString(borrowIndex.(string), 10)
+			account.BorrowIndexes[tokenSymbol] = borrowIndexValue
+		}
+	}
 	return nil
 }
@@ -12,12 +12,14 @@ import (
 func TestAccount_GetBSON(t *testing.T) {
 	// Arrange
 	type fields struct {
-		ID        bson.ObjectId
-		ShardKey  string
-		Address   string
-		Borrows   map[string]*big.Int
-		Liquidity *big.Int
-		Shortfall *big.Int
+		ID            bson.ObjectId
+		ShardKey      string
+		Address       string
+		Borrows       map[string]*big.Int
+		Liquidity     *big.Int
+		Shortfall     *big.Int
+		Markets       []string
+		BorrowIndexes map[string]*big.Int
 	}
 	tests := []struct {
 		name          string
@@ -36,6 +38,10 @@ func TestAccount_GetBSON(t *testing.T) {
 				Borrows: map[string]*big.Int{
 					contracts.CUSDCSymbol: big.NewInt(1000000000000),
 				},
+				Markets: []string{contracts.CETHSymbol},
+				BorrowIndexes: map[string]*big.Int{
+					contracts.CUSDCSymbol: big.NewInt(1000000000000000000),
+				},
 			},
 			StringBorrows: map[string]string{
 				contracts.CUSDCSymbol: "1000000000000",
@@ -46,12 +52,14 @@ func TestAccount_GetBSON(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := &Account{
-				ID:        tt.fields.ID,
-				ShardKey:  tt.fields.ShardKey,
-				Address:   tt.fields.Address,
-				Borrows:   tt.fields.Borrows,
-				Liquidity: tt.fields.Liquidity,
-				Shortfall: tt.fields.Shortfall,
+				ID:            tt.fields.ID,
+				ShardKey:      tt.fields.ShardKey,
+				Address:       tt.fields.Address,
+				Borrows:       tt.fields.Borrows,
+				Liquidity:     tt.fields.Liquidity,
+				Shortfall:     tt.fields.Shortfall,
+				Markets:       tt.fields.Markets,
+				BorrowIndexes: tt.fields.BorrowIndexes,
 			}
 			// Act
 			result, err := a.GetBSON()
@@ -94,6 +102,14 @@ func TestAccount_GetBSON(t *testing.T) {
 			if gotShortfall != wantShortfall {
 				t.Errorf(`gotShortfall = %v, want %v`, gotShortfall, wantShortfall)
 			}
+			gotMarkets := got["markets"].([]string)
+			if !reflect.DeepEqual(gotMarkets, tt.fields.Markets) {
+				t.Errorf(`gotMarkets = %v, want %v`, gotMarkets, tt.fields.Markets)
+			}
+			gotBorrowIndexes := got["borrowindexes"].(map[string]string)
+			if gotBorrowIndexes[contracts.CUSDCSymbol] != tt.fields.BorrowIndexes[contracts.CUSDCSymbol].String() {
+				t.Errorf(`gotBorrowIndexes[contracts.CUSDCSymbol] = %v, want %v`, gotBorrowIndexes[contracts.CUSDCSymbol], tt.fields.BorrowIndexes[contracts.CUSDCSymbol])
+			}
 		})
 	}
 }
@@ -107,15 +123,18 @@ func TestAccount_SetBSON(t *testing.T) {
 		Liquidity *big.Int
 		Shortfall *big.Int
 		Borrows   map[string]*big.Int
+		Markets   []string
 	}
 	type args struct {
 		raw bson.Raw
 	}
 	tests := []struct {
-		name          string
-		fields        fields
-		StringBorrows map[string]string
-		wantErr       bool
+		name             string
+		fields           fields
+		StringBorrows    map[string]string
+		StringCollateral map[string]string
+		StringIndexes    map[string]string
+		wantErr          bool
 	}{
 		{
 			name: "Should set BSON.",
@@ -128,22 +147,32 @@ func TestAccount_SetBSON(t *testing.T) {
 				Borrows: map[string]*big.Int{
 					contracts.CUSDCSymbol: big.NewInt(1000000000000),
 				},
+				Markets: []string{contracts.CETHSymbol, contracts.CUSDCSymbol},
 			},
 			StringBorrows: map[string]string{
 				contracts.CUSDCSymbol: "1000000000000",
 			},
+			StringCollateral: map[string]string{
+				contracts.CETHSymbol: "5000000000",
+			},
+			StringIndexes: map[string]string{
+				contracts.CUSDCSymbol: "1000000000000000000",
+			},
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			want := bson.M{
-				"_id":       tt.fields.ID,
-				"shardkey":  tt.fields.ShardKey,
-				"address":   tt.fields.Address,
-				"liquidity": tt.fields.Liquidity.String(),
-				"shortfall": tt.fields.Shortfall.String(),
-				"borrows":   tt.StringBorrows,
+				"_id":           tt.fields.ID,
+				"shardkey":      tt.fields.ShardKey,
+				"address":       tt.fields.Address,
+				"liquidity":     tt.fields.Liquidity.String(),
+				"shortfall":     tt.fields.Shortfall.String(),
+				"borrows":       tt.StringBorrows,
+				"markets":       tt.fields.Markets,
+				"collateral":    tt.StringCollateral,
+				"borrowindexes": tt.StringIndexes,
 			}
 			data, err := bson.Marshal(want)
 			if err != nil {
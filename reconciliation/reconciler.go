@@ -0,0 +1,123 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/models"
+)
+
+// MaxDriftBps is the drift between a positive and a zero total.
+const MaxDriftBps = 10000
+
+// EventTotalBorrows is the TotalBorrows reported by a market's latest Borrow event.
+type EventTotalBorrows struct {
+	TotalBorrows *big.Int
+	BlockNumber  uint64
+}
+
+// Report compares the stored borrows of a market against its reported totals.
+type Report struct {
+	TokenSymbol         string
+	StoredBorrows       *big.Int
+	EventTotalBorrows   *big.Int
+	EventBlockNumber    uint64
+	EventDriftBps       uint64
+	OnChainTotalBorrows *big.Int
+	OnChainDriftBps     uint64
+	Drifted             bool
+}
+
+// Reconciler checks the stored account borrows against the markets' total borrows.
+type Reconciler interface {
+	Reconcile(ctx context.Context, accounts map[string]*models.Account, eventTotalBorrows map[string]*EventTotalBorrows) ([]*Report, error)
+}
+
+// BorrowsReconciler reconciles the projected stored borrows with the token contracts.
+type BorrowsReconciler struct {
+	tokens          map[string]contracts.Token
+	interestAccrual models.InterestAccrual
+	thresholdBps    uint64
+}
+
+// NewBorrowsReconciler creates a new Reconciler flagging drift beyond the threshold in basis points.
+func NewBorrowsReconciler(tokensProvider contracts.TokensProvider, interestAccrual models.InterestAccrual, thresholdBps uint64) Reconciler {
+	return &BorrowsReconciler{
+		tokens:          tokensProvider.GetTokens(),
+		interestAccrual: interestAccrual,
+		thresholdBps:    thresholdBps,
+	}
+}
+
+// Reconcile returns a report per market, sorted by token symbol.
+func (reconciler *BorrowsReconciler) Reconcile(ctx context.Context, accounts map[string]*models.Account, eventTotalBorrows map[string]*EventTotalBorrows) ([]*Report, error) {
+	storedBorrows := make(map[string]*big.Int)
+	for tokenSymbol := range reconciler.tokens {
+		storedBorrows[tokenSymbol] = big.NewInt(0)
+	}
+	for _, account := range accounts {
+		for tokenSymbol, borrows := range reconciler.interestAccrual.ProjectAccountBorrows(account) {
+			total, ok := storedBorrows[tokenSymbol]
+			if ok {
+				total.Add(total, borrows)
+			}
+		}
+	}
+	reports := []*Report{}
+	for tokenSymbol, token := range reconciler.tokens {
+		onChainTotalBorrows, err := token.TotalBorrows(&bind.CallOpts{Context: ctx})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %v total borrows: %v", tokenSymbol, err)
+		}
+		report := &Report{
+			TokenSymbol:         tokenSymbol,
+			StoredBorrows:       storedBorrows[tokenSymbol],
+			OnChainTotalBorrows: onChainTotalBorrows,
+			OnChainDriftBps:     GetDriftBps(storedBorrows[tokenSymbol], onChainTotalBorrows),
+		}
+		report.Drifted = report.OnChainDriftBps > reconciler.thresholdBps
+		event, ok := eventTotalBorrows[tokenSymbol]
+		if ok {
+			report.EventTotalBorrows = event.TotalBorrows
+			report.EventBlockNumber = event.BlockNumber
+			report.EventDriftBps = GetDriftBps(storedBorrows[tokenSymbol], event.TotalBorrows)
+			report.Drifted = report.Drifted || report.EventDriftBps > reconciler.thresholdBps
+		}
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].TokenSymbol < reports[j].TokenSymbol
+	})
+	return reports, nil
+}
+
+// GetDriftBps returns the difference of the totals relative to the larger one, in basis points.
+func GetDriftBps(a *big.Int, b *big.Int) uint64 {
+	larger := a
+	if b.Cmp(a) > 0 {
+		larger = b
+	}
+	if larger.Sign() <= 0 {
+		return 0
+	}
+	drift := new(big.Int).Sub(a, b)
+	drift.Abs(drift)
+	drift.Mul(drift, big.NewInt(MaxDriftBps))
+	return drift.Div(drift, larger).Uint64()
+}
+
+// String returns string representation of the report.
+func (report *Report) String() string {
+	return fmt.Sprintf("stored %v, event-reported %v at block # %v (%v bps), on-chain %v (%v bps)",
+		report.StoredBorrows,
+		report.EventTotalBorrows,
+		report.EventBlockNumber,
+		report.EventDriftBps,
+		report.OnChainTotalBorrows,
+		report.OnChainDriftBps)
+}
@@ -0,0 +1,89 @@
+package reconciliation
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/models"
+)
+
+func TestBorrowsReconciler_Reconcile(t *testing.T) {
+	// Arrange
+	accounts := map[string]*models.Account{
+		"0x1": {Address: "0x1", Borrows: map[string]*big.Int{contracts.CBATSymbol: big.NewInt(600)}},
+		"0x2": {Address: "0x2", Borrows: map[string]*big.Int{contracts.CBATSymbol: big.NewInt(400), contracts.CZRXSymbol: big.NewInt(50)}},
+	}
+	tests := []struct {
+		name              string
+		onChainBorrows    *big.Int
+		eventTotalBorrows map[string]*EventTotalBorrows
+		wantOnChainDrift  uint64
+		wantEventDrift    uint64
+		wantDrifted       bool
+	}{
+		{
+			name:             "Should not flag matching totals.",
+			onChainBorrows:   big.NewInt(1000),
+			wantOnChainDrift: 0,
+			wantDrifted:      false,
+		},
+		{
+			name:             "Should not flag drift within threshold.",
+			onChainBorrows:   big.NewInt(1005),
+			wantOnChainDrift: 49,
+			wantDrifted:      false,
+		},
+		{
+			name:             "Should flag on-chain drift beyond threshold.",
+			onChainBorrows:   big.NewInt(1100),
+			wantOnChainDrift: 909,
+			wantDrifted:      true,
+		},
+		{
+			name:           "Should flag event-reported drift beyond threshold.",
+			onChainBorrows: big.NewInt(1000),
+			eventTotalBorrows: map[string]*EventTotalBorrows{
+				contracts.CBATSymbol: {TotalBorrows: big.NewInt(0), BlockNumber: 7},
+			},
+			wantOnChainDrift: 0,
+			wantEventDrift:   MaxDriftBps,
+			wantDrifted:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokensProvider := &contracts.MockTokenContracts{
+				Contracts: map[string]contracts.Token{
+					contracts.CBATSymbol: &contracts.MockToken{
+						Market: contracts.MockTokenMarket{TotalBorrows: tt.onChainBorrows},
+					},
+				},
+			}
+			reconciler := NewBorrowsReconciler(tokensProvider, &models.MockInterestAccrual{}, 50)
+			// Act
+			reports, err := reconciler.Reconcile(context.Background(), accounts, tt.eventTotalBorrows)
+			// Assert
+			if err != nil {
+				t.Fatalf("reconciler.Reconcile() error = %v", err)
+			}
+			if len(reports) != 1 {
+				t.Fatalf("len(reports) = %v, want %v", len(reports), 1)
+			}
+			report := reports[0]
+			if report.StoredBorrows.Cmp(big.NewInt(1000)) != 0 {
+				t.Errorf("report.StoredBorrows = %v, want %v", report.StoredBorrows, 1000)
+			}
+			if report.OnChainDriftBps != tt.wantOnChainDrift {
+				t.Errorf("report.OnChainDriftBps = %v, want %v", report.OnChainDriftBps, tt.wantOnChainDrift)
+			}
+			if report.EventDriftBps != tt.wantEventDrift {
+				t.Errorf("report.EventDriftBps = %v, want %v", report.EventDriftBps, tt.wantEventDrift)
+			}
+			if report.Drifted != tt.wantDrifted {
+				t.Errorf("report.Drifted = %v, want %v", report.Drifted, tt.wantDrifted)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package resync
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+// Difference is a field of an account whose stored value differs from the chain.
+type Difference struct {
+	Field  string
+	Stored string
+	Chain  string
+}
+
+// Report lists the differences found for an account.
+type Report struct {
+	Address     string
+	Differences []Difference
+	// Rewritten is false in dry runs and for accounts without borrows, which are not stored.
+	Rewritten bool
+}
+
+// Resyncer rebuilds stored accounts from the chain.
+type Resyncer interface {
+	Resync(ctx context.Context, addresses []common.Address, blockNumber uint64, dryRun bool) ([]*Report, error)
+}
+
+// AccountsResyncer rebuilds accounts from the token and Comptroller contracts at a pinned block.
+type AccountsResyncer struct {
+	logger          logging.Logger
+	accountsService models.AccountsService
+	accountsHistory models.AccountsHistory
+	interestAccrual models.InterestAccrual
+}
+
+// NewAccountsResyncer creates a new Resyncer.
+func NewAccountsResyncer(
+	logger logging.Logger,
+	accountsService models.AccountsService,
+	accountsHistory models.AccountsHistory,
+	interestAccrual models.InterestAccrual) Resyncer {
+	return &AccountsResyncer{
+		logger:          logger,
+		accountsService: accountsService,
+		accountsHistory: accountsHistory,
+		interestAccrual: interestAccrual,
+	}
+}
+
+// Resync reads the accounts at the block, rewrites them unless dryRun is set, and reports their differences.
+// Every stored account is resynced when no addresses are given.
+func (resyncer *AccountsResyncer) Resync(ctx context.Context, addresses []common.Address, blockNumber uint64, dryRun bool) ([]*Report, error) {
+	stored := []*models.Account{}
+	err := resyncer.accountsService.GetAccounts(ctx, &stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored accounts: %v", err)
+	}
+	storedAccounts := make(map[string]*models.Account)
+	for _, account := range stored {
+		storedAccounts[account.Address] = account
+	}
+	if len(addresses) == 0 {
+		for _, account := range stored {
+			addresses = append(addresses, common.HexToAddress(account.Address))
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Hex() < addresses[j].Hex()
+	})
+	reports := []*Report{}
+	for _, address := range addresses {
+		account, err := resyncer.accountsHistory.GetAccountAt(ctx, address, blockNumber)
+		if err != nil {
+			return reports, err
+		}
+		storedAccount, ok := storedAccounts[address.Hex()]
+		if !ok {
+			storedAccount = &models.Account{}
+		}
+		report := &Report{
+			Address:     address.Hex(),
+			Differences: GetDifferences(storedAccount, account),
+		}
+		reports = append(reports, report)
+		if dryRun || len(account.Borrows) == 0 {
+			continue
+		}
+		account.ID = storedAccount.ID
+		if account.ID == "" {
+			account.ID = bson.NewObjectId()
+		}
+		account.BorrowIndexes = make(map[string]*big.Int)
+		for tokenSymbol := range account.Borrows {
+			// The stored borrow balances are as of each market's last accrual, as is its borrow index.
+			borrowIndex, err := resyncer.interestAccrual.GetBorrowIndexAt(ctx, tokenSymbol, blockNumber, ^uint(0))
+			if err != nil {
+				return reports, fmt.Errorf("failed to get %v borrow index at block # %v: %v", tokenSymbol, blockNumber, err)
+			}
+			account.BorrowIndexes[tokenSymbol] = borrowIndex
+		}
+		err = resyncer.accountsService.UpsertAccount(ctx, account)
+		if err != nil {
+			return reports, fmt.Errorf("failed to rewrite account %v: %v", account.Address, err)
+		}
+		report.Rewritten = true
+		resyncer.logger.Info("Rewrote account", logging.Fields{
+			logging.AddressField: account.Address,
+			logging.BlockField:   blockNumber,
+			"differences":        len(report.Differences),
+		})
+	}
+	return reports, nil
+}
+
+// GetDifferences compares the stored account with the account read from the chain.
+func GetDifferences(stored *models.Account, chain *models.Account) []Difference {
+	differences := []Difference{}
+	compare := func(field string, storedValue string, chainValue string) {
+		if storedValue != chainValue {
+			differences = append(differences, Difference{
+				Field:  field,
+				Stored: storedValue,
+				Chain:  chainValue,
+			})
+		}
+	}
+	compareAmounts := func(field string, storedAmounts map[string]*big.Int, chainAmounts map[string]*big.Int) {
+		tokenSymbols := make(map[string]bool)
+		for tokenSymbol := range storedAmounts {
+			tokenSymbols[tokenSymbol] = true
+		}
+		for tokenSymbol := range chainAmounts {
+			tokenSymbols[tokenSymbol] = true
+		}
+		sorted := []string{}
+		for tokenSymbol := range tokenSymbols {
+			sorted = append(sorted, tokenSymbol)
+		}
+		sort.Strings(sorted)
+		for _, tokenSymbol := range sorted {
+			compare(fmt.Sprintf("%v[%v]", field, tokenSymbol), amountString(storedAmounts[tokenSymbol]), amountString(chainAmounts[tokenSymbol]))
+		}
+	}
+	compareAmounts("Borrows", stored.Borrows, chain.Borrows)
+	compareAmounts("Collateral", stored.Collateral, chain.Collateral)
+	compare("Markets", marketsString(stored.Markets), marketsString(chain.Markets))
+	compare("Liquidity", amountString(stored.Liquidity), amountString(chain.Liquidity))
+	compare("Shortfall", amountString(stored.Shortfall), amountString(chain.Shortfall))
+	return differences
+}
+
+// String returns string representation of the difference.
+func (difference Difference) String() string {
+	return fmt.Sprintf("%v: stored %v, chain %v", difference.Field, difference.Stored, difference.Chain)
+}
+
+func amountString(amount *big.Int) string {
+	if amount == nil || amount.Sign() == 0 {
+		return "0"
+	}
+	return amount.String()
+}
+
+func marketsString(markets []string) string {
+	sorted := append([]string{}, markets...)
+	sort.Strings(sorted)
+	return fmt.Sprint(sorted)
+}
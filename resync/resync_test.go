@@ -0,0 +1,116 @@
+package resync
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/globalsign/mgo/bson"
+
+	"github.com/l3a0/carbon/contracts"
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+func TestAccountsResyncer_Resync(t *testing.T) {
+	// Arrange
+	borrower := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	repaid := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	cBAT := common.HexToAddress("0x6c8c6b02e7b2be14d4fa6022dfd6d75921d90e4e")
+	tokensProvider := &contracts.MockTokenContracts{
+		Contracts: map[string]contracts.Token{
+			contracts.CBATSymbol: &contracts.MockToken{
+				BorrowBalances: map[common.Address]*big.Int{borrower: big.NewInt(150)},
+			},
+		},
+		Addresses: map[string]common.Address{contracts.CBATSymbol: cBAT},
+	}
+	comptrollerService := &models.MockComptroller{
+		Accounts: map[common.Address]*models.MockComptrollerAccount{
+			borrower: {AssetsIn: []common.Address{cBAT}},
+		},
+	}
+	var buf bytes.Buffer
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+	accountsHistory := models.NewArchiveAccountsHistory(logger, tokensProvider, comptrollerService)
+	tests := []struct {
+		name            string
+		addresses       []common.Address
+		dryRun          bool
+		wantReports     []*Report
+		wantBorrows     *big.Int
+		wantBorrowIndex *big.Int
+	}{
+		{
+			name:   "Should report differences without rewriting.",
+			dryRun: true,
+			wantReports: []*Report{
+				{
+					Address: repaid.Hex(),
+					Differences: []Difference{
+						{Field: "Borrows[CBAT]", Stored: "10", Chain: "0"},
+						{Field: "Liquidity", Stored: "0", Chain: "1"},
+					},
+				},
+				{
+					Address: borrower.Hex(),
+					Differences: []Difference{
+						{Field: "Borrows[CBAT]", Stored: "100", Chain: "150"},
+						{Field: "Markets", Stored: "[]", Chain: "[CBAT]"},
+					},
+				},
+			},
+			wantBorrows: big.NewInt(100),
+		},
+		{
+			name:      "Should rewrite the listed account.",
+			addresses: []common.Address{borrower},
+			wantReports: []*Report{
+				{
+					Address: borrower.Hex(),
+					Differences: []Difference{
+						{Field: "Borrows[CBAT]", Stored: "100", Chain: "150"},
+						{Field: "Markets", Stored: "[]", Chain: "[CBAT]"},
+					},
+					Rewritten: true,
+				},
+			},
+			wantBorrows:     big.NewInt(150),
+			wantBorrowIndex: big.NewInt(1000000000000000000),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := bson.NewObjectId()
+			accountsService := &models.MockAccountsService{
+				Accounts: map[string]*models.Account{
+					borrower.Hex(): {ID: id, Address: borrower.Hex(), Borrows: map[string]*big.Int{contracts.CBATSymbol: big.NewInt(100)}},
+					repaid.Hex():   {Address: repaid.Hex(), Borrows: map[string]*big.Int{contracts.CBATSymbol: big.NewInt(10)}},
+				},
+			}
+			resyncer := NewAccountsResyncer(logger, accountsService, accountsHistory, &models.MockInterestAccrual{})
+			// Act
+			reports, err := resyncer.Resync(context.Background(), tt.addresses, 10, tt.dryRun)
+			// Assert
+			if err != nil {
+				t.Fatalf("resyncer.Resync() error = %v", err)
+			}
+			if !reflect.DeepEqual(reports, tt.wantReports) {
+				t.Errorf("resyncer.Resync() = %v, want %v", reports, tt.wantReports)
+			}
+			account := accountsService.Accounts[borrower.Hex()]
+			if account.ID != id {
+				t.Errorf("account.ID = %v, want %v", account.ID, id)
+			}
+			if account.Borrows[contracts.CBATSymbol].Cmp(tt.wantBorrows) != 0 {
+				t.Errorf("account.Borrows[CBAT] = %v, want %v", account.Borrows[contracts.CBATSymbol], tt.wantBorrows)
+			}
+			if !reflect.DeepEqual(account.BorrowIndexes[contracts.CBATSymbol], tt.wantBorrowIndex) {
+				t.Errorf("account.BorrowIndexes[CBAT] = %v, want %v", account.BorrowIndexes[contracts.CBATSymbol], tt.wantBorrowIndex)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package sharding
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+// ShardStore stores the worker heartbeats and shard assignments, e.g. models.BotsService.
+type ShardStore interface {
+	RegisterWorker(ctx context.Context, botType string, workerID string, heartbeat time.Time) error
+	GetWorkers(ctx context.Context, botType string, aliveSince time.Time) ([]*models.Worker, error)
+	GetShardAssignment(ctx context.Context, botType string) (*models.ShardAssignment, error)
+	UpdateShardAssignment(ctx context.Context, botType string, assignment *models.ShardAssignment) error
+}
+
+// Coordinator assigns the accounts of a bot type to its live workers.
+type Coordinator interface {
+	GetShard(ctx context.Context) (*Shard, error)
+	Rebalance(ctx context.Context) error
+}
+
+// Shard is the subset of accounts owned by a worker under an assignment version.
+type Shard struct {
+	WorkerID string
+	Version  int64
+	ring     *HashRing
+}
+
+// ShardCoordinator keeps the shard assignment in the ShardStore, rebalancing it when workers join or leave.
+type ShardCoordinator struct {
+	logger           logging.Logger
+	store            ShardStore
+	botType          string
+	workerID         string
+	heartbeatTimeout time.Duration
+	replicas         int
+	now              func() time.Time
+}
+
+// NewShardCoordinator creates a new Coordinator for the worker.
+func NewShardCoordinator(logger logging.Logger, store ShardStore, botType string, workerID string, heartbeatTimeout time.Duration, replicas int, now func() time.Time) Coordinator {
+	if now == nil {
+		now = time.Now
+	}
+	return &ShardCoordinator{
+		logger:           logger,
+		store:            store,
+		botType:          botType,
+		workerID:         workerID,
+		heartbeatTimeout: heartbeatTimeout,
+		replicas:         replicas,
+		now:              now,
+	}
+}
+
+// Owns returns whether the key belongs to the shard. A worker owns nothing until it is assigned.
+func (shard *Shard) Owns(key string) bool {
+	if shard.ring == nil {
+		return false
+	}
+	return shard.ring.GetOwner(key) == shard.WorkerID
+}
+
+// GetShard records the worker's heartbeat and returns its shard under the stored assignment.
+func (coordinator *ShardCoordinator) GetShard(ctx context.Context) (*Shard, error) {
+	err := coordinator.store.RegisterWorker(ctx, coordinator.botType, coordinator.workerID, coordinator.now())
+	if err != nil {
+		return nil, err
+	}
+	assignment, err := coordinator.store.GetShardAssignment(ctx, coordinator.botType)
+	if err != nil {
+		return nil, err
+	}
+	shard := &Shard{WorkerID: coordinator.workerID}
+	if assignment != nil {
+		shard.Version = assignment.Version
+		shard.ring = NewHashRing(assignment.Workers, assignment.Replicas)
+	}
+	return shard, nil
+}
+
+// Rebalance assigns the accounts to the workers with a recent heartbeat, storing a new version only if they changed.
+func (coordinator *ShardCoordinator) Rebalance(ctx context.Context) error {
+	now := coordinator.now()
+	err := coordinator.store.RegisterWorker(ctx, coordinator.botType, coordinator.workerID, now)
+	if err != nil {
+		return err
+	}
+	workers, err := coordinator.store.GetWorkers(ctx, coordinator.botType, now.Add(-coordinator.heartbeatTimeout))
+	if err != nil {
+		return err
+	}
+	workerIDs := []string{}
+	for _, worker := range workers {
+		workerIDs = append(workerIDs, worker.WorkerID)
+	}
+	sort.Strings(workerIDs)
+	assignment, err := coordinator.store.GetShardAssignment(ctx, coordinator.botType)
+	if err != nil {
+		return err
+	}
+	if assignment == nil {
+		assignment = &models.ShardAssignment{}
+	} else if reflect.DeepEqual(assignment.Workers, workerIDs) && assignment.Replicas == coordinator.replicas {
+		return nil
+	}
+	assignment.Workers = workerIDs
+	assignment.Replicas = coordinator.replicas
+	assignment.Version++
+	assignment.UpdatedTime = now
+	err = coordinator.store.UpdateShardAssignment(ctx, coordinator.botType, assignment)
+	if err != nil {
+		return err
+	}
+	coordinator.logger.Info("Rebalanced shards", logging.Fields{"botType": coordinator.botType, "workers": len(workerIDs), "version": assignment.Version})
+	return nil
+}
@@ -0,0 +1,110 @@
+package sharding
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+func TestShardCoordinator_Rebalance(t *testing.T) {
+	// Arrange
+	start := time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)
+	keys := []string{}
+	for i := 0; i < 1000; i++ {
+		keys = append(keys, fmt.Sprintf("0x%040x", i))
+	}
+	tests := []struct {
+		name        string
+		steps       []string
+		wantVersion int64
+		wantWorkers []string
+	}{
+		{
+			name:        "Should assign the leader alone.",
+			steps:       []string{"rebalance"},
+			wantVersion: 1,
+			wantWorkers: []string{"a"},
+		},
+		{
+			name:        "Should add a joining worker.",
+			steps:       []string{"rebalance", "b", "rebalance", "rebalance"},
+			wantVersion: 2,
+			wantWorkers: []string{"a", "b"},
+		},
+		{
+			name:        "Should remove a worker without heartbeats.",
+			steps:       []string{"b", "rebalance", "expire", "rebalance"},
+			wantVersion: 2,
+			wantWorkers: []string{"a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+			now := start
+			clock := func() time.Time {
+				return now
+			}
+			store := &models.MockBotsService{}
+			coordinators := map[string]Coordinator{
+				"a": NewShardCoordinator(logger, store, "AccountsBot", "a", time.Minute, DefaultReplicas, clock),
+				"b": NewShardCoordinator(logger, store, "AccountsBot", "b", time.Minute, DefaultReplicas, clock),
+			}
+			// Act
+			for _, step := range tt.steps {
+				var err error
+				switch step {
+				case "rebalance":
+					err = coordinators["a"].Rebalance(context.Background())
+				case "expire":
+					now = now.Add(2 * time.Minute)
+				default:
+					_, err = coordinators[step].GetShard(context.Background())
+				}
+				if err != nil {
+					t.Fatalf("step %v error = %v", step, err)
+				}
+				now = now.Add(time.Second)
+			}
+			// Assert
+			shards := []*Shard{}
+			for _, workerID := range []string{"a", "b"} {
+				shard, err := coordinators[workerID].GetShard(context.Background())
+				if err != nil {
+					t.Fatalf("GetShard() error = %v", err)
+				}
+				if shard.Version != tt.wantVersion {
+					t.Errorf("shard.Version = %v, want %v", shard.Version, tt.wantVersion)
+				}
+				shards = append(shards, shard)
+			}
+			owned := make(map[string]int)
+			for _, key := range keys {
+				owners := 0
+				for _, shard := range shards {
+					if shard.Owns(key) {
+						owned[shard.WorkerID]++
+						owners++
+					}
+				}
+				if owners != 1 {
+					t.Fatalf("owners of %v = %v, want %v", key, owners, 1)
+				}
+			}
+			for _, workerID := range tt.wantWorkers {
+				if owned[workerID] == 0 {
+					t.Errorf("owned[%v] = %v, want more than %v", workerID, owned[workerID], 0)
+				}
+			}
+			if len(owned) != len(tt.wantWorkers) {
+				t.Errorf("len(owned) = %v, want %v", len(owned), len(tt.wantWorkers))
+			}
+		})
+	}
+}
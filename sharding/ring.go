@@ -0,0 +1,61 @@
+package sharding
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// DefaultReplicas is the number of points of each worker on the ring.
+const DefaultReplicas = 128
+
+// HashRing maps keys to workers with consistent hashing, so a worker joining or leaving moves only its share of the keys.
+type HashRing struct {
+	hashes []uint64
+	owners map[uint64]string
+}
+
+// NewHashRing creates a new HashRing with the given number of points per worker.
+func NewHashRing(workers []string, replicas int) *HashRing {
+	if replicas <= 0 {
+		replicas = DefaultReplicas
+	}
+	ring := &HashRing{
+		owners: make(map[uint64]string),
+	}
+	for _, worker := range workers {
+		for i := 0; i < replicas; i++ {
+			hash := hashKey(fmt.Sprintf("%v#%v", worker, i))
+			if _, ok := ring.owners[hash]; ok {
+				continue
+			}
+			ring.owners[hash] = worker
+			ring.hashes = append(ring.hashes, hash)
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool {
+		return ring.hashes[i] < ring.hashes[j]
+	})
+	return ring
+}
+
+// GetOwner returns the worker owning the key, or an empty string if the ring has no workers.
+func (ring *HashRing) GetOwner(key string) string {
+	if len(ring.hashes) == 0 {
+		return ""
+	}
+	hash := hashKey(key)
+	i := sort.Search(len(ring.hashes), func(i int) bool {
+		return ring.hashes[i] >= hash
+	})
+	if i == len(ring.hashes) {
+		i = 0
+	}
+	return ring.owners[ring.hashes[i]]
+}
+
+func hashKey(key string) uint64 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,72 @@
+package sharding
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHashRing_GetOwner(t *testing.T) {
+	// Arrange
+	keys := []string{}
+	for i := 0; i < 10000; i++ {
+		keys = append(keys, fmt.Sprintf("0x%040x", i))
+	}
+	tests := []struct {
+		name         string
+		workers      []string
+		newWorkers   []string
+		wantMaxShare float64
+		wantMaxMoved float64
+	}{
+		{
+			name:         "Should move only the joining worker's share.",
+			workers:      []string{"a", "b", "c"},
+			newWorkers:   []string{"a", "b", "c", "d"},
+			wantMaxShare: 0.45,
+			wantMaxMoved: 0.35,
+		},
+		{
+			name:         "Should move only the leaving worker's share.",
+			workers:      []string{"a", "b", "c", "d"},
+			newWorkers:   []string{"a", "b", "d"},
+			wantMaxShare: 0.35,
+			wantMaxMoved: 0.35,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := NewHashRing(tt.workers, DefaultReplicas)
+			newRing := NewHashRing(tt.newWorkers, DefaultReplicas)
+			// Act
+			shares := make(map[string]int)
+			moved := 0
+			for _, key := range keys {
+				owner := ring.GetOwner(key)
+				shares[owner]++
+				if newRing.GetOwner(key) != owner {
+					moved++
+				}
+			}
+			// Assert
+			for worker, share := range shares {
+				if float64(share)/float64(len(keys)) > tt.wantMaxShare {
+					t.Errorf("share of %v = %v, want at most %v", worker, float64(share)/float64(len(keys)), tt.wantMaxShare)
+				}
+			}
+			if float64(moved)/float64(len(keys)) > tt.wantMaxMoved {
+				t.Errorf("moved = %v, want at most %v", float64(moved)/float64(len(keys)), tt.wantMaxMoved)
+			}
+		})
+	}
+}
+
+func TestHashRing_GetOwner_Empty(t *testing.T) {
+	// Arrange
+	ring := NewHashRing(nil, DefaultReplicas)
+	// Act
+	owner := ring.GetOwner("0x0")
+	// Assert
+	if owner != "" {
+		t.Errorf("ring.GetOwner() = %v, want %v", owner, "")
+	}
+}
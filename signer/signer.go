@@ -0,0 +1,209 @@
+package signer
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+var (
+	// ErrNotAuthorized is returned when asked to sign for an account other than the signer's.
+	ErrNotAuthorized = errors.New("not authorized to sign this account")
+
+	// ErrNoPassphrase is returned when a keystore is configured without a passphrase source.
+	ErrNoPassphrase = errors.New("no keystore passphrase file or environment variable configured")
+)
+
+// Signer signs transactions for the bot's liquidation account.
+type Signer interface {
+	GetAddress() common.Address
+	GetTransactOpts() *bind.TransactOpts
+}
+
+// Configuration contains signer configuration.
+// Exactly one of KeystorePath, PrivateKey and ExternalEndpoint should be set.
+type Configuration struct {
+	// KeystorePath is the path of a go-ethereum encrypted keystore file.
+	KeystorePath string
+	// PassphraseFile is the path of a file containing the keystore passphrase.
+	PassphraseFile string
+	// PassphraseEnv is the name of an environment variable containing the keystore passphrase.
+	PassphraseEnv string
+	// PrivateKey is a hex encoded private key. Only meant for test setups.
+	PrivateKey string
+	// ExternalEndpoint is the JSON-RPC endpoint of an external (clef-style) signer.
+	ExternalEndpoint string
+	// Address selects the external signer account. Defaults to the first account.
+	Address string
+	// ChainID is the chain ID transactions are signed for.
+	ChainID *big.Int
+}
+
+// KeySigner signs with a private key held in memory.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+	signer  types.Signer
+}
+
+// ExternalSigner signs through an external signer such as clef.
+type ExternalSigner struct {
+	client  *external.ExternalSigner
+	account accounts.Account
+	chainID *big.Int
+}
+
+// NewSigner creates a new Signer from the configuration.
+func NewSigner(logger logging.Logger, configuration Configuration) (Signer, error) {
+	switch {
+	case configuration.KeystorePath != "":
+		keyJSON, err := ioutil.ReadFile(configuration.KeystorePath)
+		if err != nil {
+			return nil, err
+		}
+		passphrase, err := readPassphrase(configuration)
+		if err != nil {
+			return nil, err
+		}
+		signer, err := NewKeystoreSigner(keyJSON, passphrase, configuration.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Loaded keystore signer", logging.Fields{logging.AddressField: signer.GetAddress().Hex(), "keystore": configuration.KeystorePath})
+		return signer, nil
+	case configuration.PrivateKey != "":
+		signer, err := NewPrivateKeySigner(configuration.PrivateKey, configuration.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Loaded private key signer", logging.Fields{logging.AddressField: signer.GetAddress().Hex()})
+		return signer, nil
+	case configuration.ExternalEndpoint != "":
+		signer, err := NewExternalSigner(configuration.ExternalEndpoint, configuration.Address, configuration.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Connected external signer", logging.Fields{logging.AddressField: signer.GetAddress().Hex(), "endpoint": configuration.ExternalEndpoint})
+		return signer, nil
+	}
+	return nil, errors.New("no keystore, private key or external signer configured")
+}
+
+// NewKeystoreSigner creates a new Signer from an encrypted keystore file.
+func NewKeystoreSigner(keyJSON []byte, passphrase string, chainID *big.Int) (Signer, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return newKeySigner(key.PrivateKey, chainID), nil
+}
+
+// NewPrivateKeySigner creates a new Signer from a hex encoded private key.
+func NewPrivateKeySigner(hexKey string, chainID *big.Int) (Signer, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return newKeySigner(key, chainID), nil
+}
+
+// NewExternalSigner creates a new Signer backed by an external signer at the endpoint.
+func NewExternalSigner(endpoint string, address string, chainID *big.Int) (Signer, error) {
+	client, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	signerAccounts := client.Accounts()
+	if len(signerAccounts) == 0 {
+		return nil, fmt.Errorf("external signer at %v has no accounts", endpoint)
+	}
+	account := signerAccounts[0]
+	if address != "" {
+		account = accounts.Account{Address: common.HexToAddress(address)}
+		if !client.Contains(account) {
+			return nil, fmt.Errorf("external signer at %v does not manage %v", endpoint, address)
+		}
+	}
+	return &ExternalSigner{
+		client:  client,
+		account: account,
+		chainID: chainID,
+	}, nil
+}
+
+func newKeySigner(key *ecdsa.PrivateKey, chainID *big.Int) *KeySigner {
+	return &KeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+		signer:  types.NewEIP155Signer(chainID),
+	}
+}
+
+func readPassphrase(configuration Configuration) (string, error) {
+	if configuration.PassphraseFile != "" {
+		passphrase, err := ioutil.ReadFile(configuration.PassphraseFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(passphrase), "\r\n"), nil
+	}
+	if configuration.PassphraseEnv != "" {
+		passphrase, ok := os.LookupEnv(configuration.PassphraseEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %v is not set", configuration.PassphraseEnv)
+		}
+		return passphrase, nil
+	}
+	return "", ErrNoPassphrase
+}
+
+// GetAddress returns the address of the account.
+func (s *KeySigner) GetAddress() common.Address {
+	return s.address
+}
+
+// GetTransactOpts returns options signing for the account with replay protection.
+func (s *KeySigner) GetTransactOpts() *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: s.address,
+		// the bindings ask for a homestead signature, so sign for the chain instead.
+		Signer: func(_ types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.address {
+				return nil, ErrNotAuthorized
+			}
+			return types.SignTx(tx, s.signer, s.key)
+		},
+	}
+}
+
+// GetAddress returns the address of the account.
+func (s *ExternalSigner) GetAddress() common.Address {
+	return s.account.Address
+}
+
+// GetTransactOpts returns options signing for the account through the external signer.
+func (s *ExternalSigner) GetTransactOpts() *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From: s.account.Address,
+		Signer: func(_ types.Signer, address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.account.Address {
+				return nil, ErrNotAuthorized
+			}
+			return s.client.SignTx(s.account, tx, s.chainID)
+		},
+	}
+}
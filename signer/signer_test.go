@@ -0,0 +1,195 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+// fakeClef implements the subset of the clef account API used by the external signer.
+type fakeClef struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+}
+
+type fakeSendTxArgs struct {
+	From     common.MixedcaseAddress  `json:"from"`
+	To       *common.MixedcaseAddress `json:"to"`
+	Gas      hexutil.Uint64           `json:"gas"`
+	GasPrice hexutil.Big              `json:"gasPrice"`
+	Value    hexutil.Big              `json:"value"`
+	Nonce    hexutil.Uint64           `json:"nonce"`
+	Data     *hexutil.Bytes           `json:"data"`
+}
+
+type fakeSignTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+func (clef *fakeClef) Version() string {
+	return "6.0.0"
+}
+
+func (clef *fakeClef) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(clef.key.PublicKey)}
+}
+
+func (clef *fakeClef) SignTransaction(args fakeSendTxArgs) (*fakeSignTransactionResult, error) {
+	tx := types.NewTransaction(uint64(args.Nonce), args.To.Address(), args.Value.ToInt(), uint64(args.Gas), args.GasPrice.ToInt(), *args.Data)
+	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(clef.chainID), clef.key)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeSignTransactionResult{Tx: signedTx}, nil
+}
+
+func TestNewSigner(t *testing.T) {
+	// Arrange
+	chainID := big.NewInt(1337)
+	key, _ := crypto.GenerateKey()
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	passphrase := "correct horse battery staple"
+	directory, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	account, err := keystore.NewKeyStore(directory, keystore.LightScryptN, keystore.LightScryptP).ImportECDSA(key, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystorePath := account.URL.Path
+	passphrasePath := filepath.Join(directory, "passphrase")
+	wrongPassphrasePath := filepath.Join(directory, "wrong-passphrase")
+	ioutil.WriteFile(passphrasePath, []byte(passphrase+"\n"), 0600)
+	ioutil.WriteFile(wrongPassphrasePath, []byte("wrong"), 0600)
+	os.Setenv("CARBON_TEST_PASSPHRASE", passphrase)
+	defer os.Unsetenv("CARBON_TEST_PASSPHRASE")
+	server := rpc.NewServer()
+	server.RegisterName("account", &fakeClef{key: key, chainID: chainID})
+	clef := httptest.NewServer(server)
+	defer clef.Close()
+	tests := []struct {
+		name          string
+		configuration Configuration
+		wantErr       bool
+	}{
+		{
+			name: "Should load keystore with passphrase file.",
+			configuration: Configuration{
+				KeystorePath:   keystorePath,
+				PassphraseFile: passphrasePath,
+				ChainID:        chainID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should load keystore with passphrase environment variable.",
+			configuration: Configuration{
+				KeystorePath:  keystorePath,
+				PassphraseEnv: "CARBON_TEST_PASSPHRASE",
+				ChainID:       chainID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should not load keystore with wrong passphrase.",
+			configuration: Configuration{
+				KeystorePath:   keystorePath,
+				PassphraseFile: wrongPassphrasePath,
+				ChainID:        chainID,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should not load keystore without passphrase.",
+			configuration: Configuration{
+				KeystorePath: keystorePath,
+				ChainID:      chainID,
+			},
+			wantErr: true,
+		},
+		{
+			name: "Should load private key.",
+			configuration: Configuration{
+				PrivateKey: hexutil.Encode(crypto.FromECDSA(key)),
+				ChainID:    chainID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should connect external signer.",
+			configuration: Configuration{
+				ExternalEndpoint: clef.URL,
+				ChainID:          chainID,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should not connect external signer without account.",
+			configuration: Configuration{
+				ExternalEndpoint: clef.URL,
+				Address:          "0x000000000000000000000000000000000000dEaD",
+				ChainID:          chainID,
+			},
+			wantErr: true,
+		},
+		{
+			name:          "Should not create signer without configuration.",
+			configuration: Configuration{},
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			// Act
+			got, err := NewSigner(logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat), tt.configuration)
+			// Assert
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSigner() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.GetAddress() != address {
+				t.Errorf("got.GetAddress() = %v, want %v", got.GetAddress().Hex(), address.Hex())
+			}
+			opts := got.GetTransactOpts()
+			tx := types.NewTransaction(0, common.HexToAddress("0x000000000000000000000000000000000000dEaD"), big.NewInt(1), 21000, big.NewInt(1), []byte{})
+			signedTx, err := opts.Signer(types.HomesteadSigner{}, opts.From, tx)
+			if err != nil {
+				t.Fatalf("opts.Signer() error = %v", err)
+			}
+			if signedTx.ChainId().Cmp(chainID) != 0 {
+				t.Errorf("signedTx.ChainId() = %v, want %v", signedTx.ChainId(), chainID)
+			}
+			sender, err := types.Sender(types.NewEIP155Signer(chainID), signedTx)
+			if err != nil {
+				t.Fatalf("types.Sender() error = %v", err)
+			}
+			if sender != address {
+				t.Errorf("sender = %v, want %v", sender.Hex(), address.Hex())
+			}
+			_, err = opts.Signer(types.HomesteadSigner{}, common.Address{}, tx)
+			if err != ErrNotAuthorized {
+				t.Errorf("opts.Signer() error = %v, want %v", err, ErrNotAuthorized)
+			}
+		})
+	}
+}
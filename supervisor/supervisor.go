@@ -0,0 +1,267 @@
+package supervisor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/cenkalti/backoff.v2"
+
+	"github.com/l3a0/carbon/accountsbot"
+	"github.com/l3a0/carbon/logging"
+)
+
+const (
+	// StatusStarting is a bot that has not finished a cycle yet.
+	StatusStarting = "starting"
+
+	// StatusHealthy is a bot whose last cycle succeeded.
+	StatusHealthy = "healthy"
+
+	// StatusRestarting is a bot waiting to restart after a failed cycle.
+	StatusRestarting = "restarting"
+
+	// StatusFailed is a bot that stopped restarting because its backoff gave up.
+	StatusFailed = "failed"
+
+	// StatusStopped is a bot that finished its cycles or was shut down.
+	StatusStopped = "stopped"
+)
+
+// Health is the health of a supervised bot.
+type Health struct {
+	Name          string
+	Status        string
+	Cycles        int64
+	Restarts      int64
+	LastError     string
+	LastCycleTime time.Time
+}
+
+// Supervisor runs the lifecycles of bots concurrently, restarting them when they fail.
+// It serves the health of the bots over HTTP.
+type Supervisor interface {
+	http.Handler
+	Register(name string, bot accountsbot.Bot)
+	Run(ctx context.Context)
+	GetHealth() []Health
+}
+
+// BotSupervisor runs a wake, work and sleep cycle of each bot every interval.
+// A cycle fails when a step panics or reports a non-zero status, and is retried after the bot's backoff.
+type BotSupervisor struct {
+	logger     logging.Logger
+	interval   time.Duration
+	newBackOff func() backoff.BackOff
+	bots       []*supervisedBot
+	mutex      sync.RWMutex
+}
+
+type supervisedBot struct {
+	bot     accountsbot.Bot
+	backOff backoff.BackOff
+	health  Health
+	// attempts numbers the cycles of the bot, for their cycle IDs.
+	attempts int64
+}
+
+// NewBotSupervisor creates a new Supervisor.
+// An interval of 0 runs a single successful cycle of each bot. newBackOff defaults to an exponential backoff.
+func NewBotSupervisor(logger logging.Logger, interval time.Duration, newBackOff func() backoff.BackOff) Supervisor {
+	if newBackOff == nil {
+		newBackOff = func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		}
+	}
+	return &BotSupervisor{
+		logger:     logger,
+		interval:   interval,
+		newBackOff: newBackOff,
+	}
+}
+
+// Register adds the bot to the supervisor. Bots registered while running start with the next Run.
+func (supervisor *BotSupervisor) Register(name string, bot accountsbot.Bot) {
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+	supervisor.bots = append(supervisor.bots, &supervisedBot{
+		bot:     bot,
+		backOff: supervisor.newBackOff(),
+		health:  Health{Name: name, Status: StatusStarting},
+	})
+}
+
+// Run runs the registered bots until they stop or the context is cancelled.
+// On cancellation, a step still running is abandoned, so a bot stuck on a call cannot delay Run.
+func (supervisor *BotSupervisor) Run(ctx context.Context) {
+	supervisor.mutex.RLock()
+	bots := append([]*supervisedBot{}, supervisor.bots...)
+	supervisor.mutex.RUnlock()
+	var waitGroup sync.WaitGroup
+	for _, bot := range bots {
+		waitGroup.Add(1)
+		go func(bot *supervisedBot) {
+			defer waitGroup.Done()
+			supervisor.supervise(ctx, bot)
+		}(bot)
+	}
+	waitGroup.Wait()
+	supervisor.logger.Info("Stopped bots", logging.Fields{"bots": len(bots)})
+}
+
+// GetHealth returns the health of the registered bots, by name.
+func (supervisor *BotSupervisor) GetHealth() []Health {
+	supervisor.mutex.RLock()
+	defer supervisor.mutex.RUnlock()
+	healths := []Health{}
+	for _, bot := range supervisor.bots {
+		healths = append(healths, bot.health)
+	}
+	sort.Slice(healths, func(i, j int) bool {
+		return healths[i].Name < healths[j].Name
+	})
+	return healths
+}
+
+// ServeHTTP writes the health of the bots as JSON, with status 503 if any bot failed.
+func (supervisor *BotSupervisor) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	healths := supervisor.GetHealth()
+	writer.Header().Set("Content-Type", "application/json")
+	for _, health := range healths {
+		if health.Status == StatusFailed {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			break
+		}
+	}
+	json.NewEncoder(writer).Encode(healths)
+}
+
+// supervise runs the bot's cycles, waiting for the interval after a success and the backoff after a failure.
+func (supervisor *BotSupervisor) supervise(ctx context.Context, bot *supervisedBot) {
+	name := bot.health.Name
+	bot.backOff.Reset()
+	for {
+		bot.attempts++
+		cycleID := fmt.Sprintf("%v-%v", name, bot.attempts)
+		err := runCycle(logging.WithCycleID(ctx, cycleID), bot.bot)
+		if err != nil && ctx.Err() != nil {
+			supervisor.setStatus(bot, StatusStopped)
+			return
+		}
+		var wait time.Duration
+		if err == nil {
+			bot.backOff.Reset()
+			supervisor.updateHealth(bot, func(health *Health) {
+				health.Status = StatusHealthy
+				health.Cycles++
+				health.LastError = ""
+				health.LastCycleTime = time.Now()
+			})
+			if supervisor.interval == 0 {
+				supervisor.setStatus(bot, StatusStopped)
+				return
+			}
+			wait = supervisor.interval
+		} else {
+			wait = bot.backOff.NextBackOff()
+			fields := logging.Fields{"bot": name, logging.CycleField: cycleID, logging.ErrorField: err}
+			if wait == backoff.Stop {
+				supervisor.updateHealth(bot, func(health *Health) {
+					health.Status = StatusFailed
+					health.LastError = err.Error()
+				})
+				supervisor.logger.Error("Bot failed, giving up restarting", fields)
+				return
+			}
+			supervisor.updateHealth(bot, func(health *Health) {
+				health.Status = StatusRestarting
+				health.Restarts++
+				health.LastError = err.Error()
+			})
+			fields["wait"] = wait.String()
+			supervisor.logger.Warn("Bot failed, restarting", fields)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			supervisor.setStatus(bot, StatusStopped)
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (supervisor *BotSupervisor) updateHealth(bot *supervisedBot, update func(health *Health)) {
+	supervisor.mutex.Lock()
+	defer supervisor.mutex.Unlock()
+	update(&bot.health)
+}
+
+func (supervisor *BotSupervisor) setStatus(bot *supervisedBot, status string) {
+	supervisor.updateHealth(bot, func(health *Health) {
+		health.Status = status
+	})
+}
+
+// runCycle wakes, works and sleeps the bot. A cancelled context skips the work, but a bot that woke
+// and was not abandoned while working always sleeps.
+func runCycle(ctx context.Context, bot accountsbot.Bot) error {
+	err := runStep(ctx, "wake", bot.Wake)
+	if err != nil {
+		return err
+	}
+	if ctx.Err() == nil {
+		err = runStep(ctx, "work", bot.Work)
+		if err != nil {
+			return err
+		}
+	}
+	return runStep(detachedContext{ctx}, "sleep", bot.Sleep)
+}
+
+// detachedContext keeps the values of its parent, e.g. the cycle ID, but is never cancelled.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+// runStep runs a step of the bot, turning a panic, a non-zero status or the cancellation of the context into an error.
+func runStep(ctx context.Context, name string, step func(ctx context.Context, statusChannel chan int)) error {
+	statusChannel := make(chan int, 1)
+	panics := make(chan interface{}, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				panics <- recovered
+			}
+		}()
+		step(ctx, statusChannel)
+	}()
+	select {
+	case status := <-statusChannel:
+		if status != 0 {
+			return fmt.Errorf("%v status %v", name, status)
+		}
+		return nil
+	case recovered := <-panics:
+		return fmt.Errorf("%v panicked: %v", name, recovered)
+	case <-ctx.Done():
+		return fmt.Errorf("%v abandoned: %v", name, ctx.Err())
+	}
+}
@@ -0,0 +1,209 @@
+package supervisor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/cenkalti/backoff.v2"
+
+	"github.com/l3a0/carbon/logging"
+)
+
+type fakeBot struct {
+	// failures are the work steps that fail: "panic", "status", "hang" until released or "" for success.
+	failures []string
+	release  chan struct{}
+	cycles   int
+	sleeps   int
+	mutex    sync.Mutex
+}
+
+func (bot *fakeBot) Wake(ctx context.Context, statusChannel chan int) {
+	statusChannel <- 0
+}
+
+func (bot *fakeBot) Work(ctx context.Context, statusChannel chan int) {
+	bot.mutex.Lock()
+	failure := ""
+	if bot.cycles < len(bot.failures) {
+		failure = bot.failures[bot.cycles]
+	}
+	bot.cycles++
+	bot.mutex.Unlock()
+	switch failure {
+	case "panic":
+		panic("work failed")
+	case "status":
+		statusChannel <- 1
+	case "hang":
+		<-bot.release
+		statusChannel <- 0
+	default:
+		statusChannel <- 0
+	}
+}
+
+func (bot *fakeBot) Sleep(ctx context.Context, statusChannel chan int) {
+	bot.mutex.Lock()
+	bot.sleeps++
+	bot.mutex.Unlock()
+	statusChannel <- 0
+}
+
+func TestBotSupervisor_Run(t *testing.T) {
+	// Arrange
+	tests := []struct {
+		name         string
+		failures     []string
+		maxRetries   uint64
+		wantStatus   string
+		wantCycles   int64
+		wantRestarts int64
+		wantHTTP     int
+	}{
+		{
+			name:         "Should run a single cycle.",
+			failures:     nil,
+			maxRetries:   3,
+			wantStatus:   StatusStopped,
+			wantCycles:   1,
+			wantRestarts: 0,
+			wantHTTP:     http.StatusOK,
+		},
+		{
+			name:         "Should restart after a panic and a failed status.",
+			failures:     []string{"panic", "status"},
+			maxRetries:   3,
+			wantStatus:   StatusStopped,
+			wantCycles:   1,
+			wantRestarts: 2,
+			wantHTTP:     http.StatusOK,
+		},
+		{
+			name:         "Should give up after the backoff stops.",
+			failures:     []string{"panic", "panic", "panic"},
+			maxRetries:   2,
+			wantStatus:   StatusFailed,
+			wantCycles:   0,
+			wantRestarts: 2,
+			wantHTTP:     http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+			supervisor := NewBotSupervisor(logger, 0, func() backoff.BackOff {
+				return backoff.WithMaxRetries(&backoff.ZeroBackOff{}, tt.maxRetries)
+			})
+			supervisor.Register("FakeBot", &fakeBot{failures: tt.failures})
+			// Act
+			supervisor.Run(context.Background())
+			// Assert
+			health := supervisor.GetHealth()[0]
+			if health.Status != tt.wantStatus {
+				t.Errorf("health.Status = %v, want %v", health.Status, tt.wantStatus)
+			}
+			if health.Cycles != tt.wantCycles {
+				t.Errorf("health.Cycles = %v, want %v", health.Cycles, tt.wantCycles)
+			}
+			if health.Restarts != tt.wantRestarts {
+				t.Errorf("health.Restarts = %v, want %v", health.Restarts, tt.wantRestarts)
+			}
+			recorder := httptest.NewRecorder()
+			supervisor.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+			if recorder.Code != tt.wantHTTP {
+				t.Errorf("recorder.Code = %v, want %v", recorder.Code, tt.wantHTTP)
+			}
+		})
+	}
+}
+
+func TestBotSupervisor_Run_Cancel(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+	supervisor := NewBotSupervisor(logger, time.Hour, nil)
+	bots := []*fakeBot{{}, {}}
+	supervisor.Register("FakeBot1", bots[0])
+	supervisor.Register("FakeBot2", bots[1])
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	// Act
+	go func() {
+		supervisor.Run(ctx)
+		close(done)
+	}()
+	for {
+		healths := supervisor.GetHealth()
+		if healths[0].Cycles > 0 && healths[1].Cycles > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("supervisor.Run() did not return after cancellation")
+	}
+	// Assert
+	for i, health := range supervisor.GetHealth() {
+		if health.Status != StatusStopped {
+			t.Errorf("health[%v].Status = %v, want %v", i, health.Status, StatusStopped)
+		}
+		if bots[i].sleeps != 1 {
+			t.Errorf("bots[%v].sleeps = %v, want %v", i, bots[i].sleeps, 1)
+		}
+	}
+}
+
+func TestBotSupervisor_Run_CancelHungWork(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger := logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat)
+	supervisor := NewBotSupervisor(logger, time.Hour, nil)
+	bot := &fakeBot{failures: []string{"hang"}, release: make(chan struct{})}
+	defer close(bot.release)
+	supervisor.Register("FakeBot", bot)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	// Act
+	go func() {
+		supervisor.Run(ctx)
+		close(done)
+	}()
+	for {
+		bot.mutex.Lock()
+		cycles := bot.cycles
+		bot.mutex.Unlock()
+		if cycles > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("supervisor.Run() did not return after cancellation")
+	}
+	// Assert
+	health := supervisor.GetHealth()[0]
+	if health.Status != StatusStopped {
+		t.Errorf("health.Status = %v, want %v", health.Status, StatusStopped)
+	}
+	if health.Restarts != 0 {
+		t.Errorf("health.Restarts = %v, want %v", health.Restarts, 0)
+	}
+	bot.mutex.Lock()
+	defer bot.mutex.Unlock()
+	if bot.sleeps != 0 {
+		t.Errorf("bot.sleeps = %v, want %v", bot.sleeps, 0)
+	}
+}
@@ -0,0 +1,78 @@
+package transactions
+
+import (
+	"context"
+	"math/big"
+)
+
+// GasPricer decides the gas price of new and replacement transactions.
+type GasPricer interface {
+	GasPrice(ctx context.Context) (*big.Int, error)
+	BumpGasPrice(ctx context.Context, previous *big.Int) (*big.Int, error)
+}
+
+// GasPriceSuggester suggests a gas price, e.g. ethclient.Client.
+type GasPriceSuggester interface {
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+// NodeGasPricer prices transactions from the node's suggested gas price.
+type NodeGasPricer struct {
+	suggester         GasPriceSuggester
+	multiplierPercent int64
+	bumpPercent       int64
+	maxGasPrice       *big.Int
+}
+
+// NewNodeGasPricer creates a new GasPricer.
+// New transactions pay multiplierPercent of the suggested gas price and replacements pay at least
+// bumpPercent more than the transaction they replace. Gas prices never exceed maxGasPrice, if set.
+func NewNodeGasPricer(suggester GasPriceSuggester, multiplierPercent int64, bumpPercent int64, maxGasPrice *big.Int) GasPricer {
+	return &NodeGasPricer{
+		suggester:         suggester,
+		multiplierPercent: multiplierPercent,
+		bumpPercent:       bumpPercent,
+		maxGasPrice:       maxGasPrice,
+	}
+}
+
+// GasPrice returns the gas price of a new transaction.
+func (pricer *NodeGasPricer) GasPrice(ctx context.Context) (*big.Int, error) {
+	suggested, err := pricer.suggester.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice := percentOf(suggested, pricer.multiplierPercent)
+	if pricer.maxGasPrice != nil && gasPrice.Cmp(pricer.maxGasPrice) > 0 {
+		gasPrice.Set(pricer.maxGasPrice)
+	}
+	return gasPrice, nil
+}
+
+// BumpGasPrice returns the gas price of a transaction replacing one priced at previous.
+func (pricer *NodeGasPricer) BumpGasPrice(ctx context.Context, previous *big.Int) (*big.Int, error) {
+	if pricer.maxGasPrice != nil && previous.Cmp(pricer.maxGasPrice) >= 0 {
+		return nil, ErrGasPriceCapped
+	}
+	gasPrice := percentOf(previous, 100+pricer.bumpPercent)
+	// nodes reject replacements that do not pay more.
+	if gasPrice.Cmp(previous) <= 0 {
+		gasPrice.Add(previous, big.NewInt(1))
+	}
+	suggested, err := pricer.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if suggested.Cmp(gasPrice) > 0 {
+		gasPrice = suggested
+	}
+	if pricer.maxGasPrice != nil && gasPrice.Cmp(pricer.maxGasPrice) > 0 {
+		gasPrice.Set(pricer.maxGasPrice)
+	}
+	return gasPrice, nil
+}
+
+func percentOf(value *big.Int, percent int64) *big.Int {
+	result := new(big.Int).Mul(value, big.NewInt(percent))
+	return result.Div(result, big.NewInt(100))
+}
@@ -0,0 +1,90 @@
+package transactions
+
+import (
+	"context"
+	"math/big"
+	"testing"
+)
+
+type mockGasPriceSuggester struct {
+	gasPrice *big.Int
+}
+
+func (suggester *mockGasPriceSuggester) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return suggester.gasPrice, nil
+}
+
+func TestNodeGasPricer_BumpGasPrice(t *testing.T) {
+	type fields struct {
+		suggested   *big.Int
+		maxGasPrice *big.Int
+	}
+	type args struct {
+		previous *big.Int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		want    *big.Int
+		wantErr error
+	}{
+		{
+			name: "Should bump previous gas price.",
+			fields: fields{
+				suggested: big.NewInt(100),
+			},
+			args: args{
+				previous: big.NewInt(100),
+			},
+			want: big.NewInt(110),
+		},
+		{
+			name: "Should use suggested gas price when higher.",
+			fields: fields{
+				suggested: big.NewInt(200),
+			},
+			args: args{
+				previous: big.NewInt(100),
+			},
+			want: big.NewInt(200),
+		},
+		{
+			name: "Should cap gas price.",
+			fields: fields{
+				suggested:   big.NewInt(100),
+				maxGasPrice: big.NewInt(105),
+			},
+			args: args{
+				previous: big.NewInt(100),
+			},
+			want: big.NewInt(105),
+		},
+		{
+			name: "Should not bump capped gas price.",
+			fields: fields{
+				suggested:   big.NewInt(100),
+				maxGasPrice: big.NewInt(100),
+			},
+			args: args{
+				previous: big.NewInt(100),
+			},
+			wantErr: ErrGasPriceCapped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			pricer := NewNodeGasPricer(&mockGasPriceSuggester{gasPrice: tt.fields.suggested}, 100, 10, tt.fields.maxGasPrice)
+			// Act
+			got, err := pricer.BumpGasPrice(context.Background(), tt.args.previous)
+			// Assert
+			if err != tt.wantErr {
+				t.Fatalf("pricer.BumpGasPrice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && got.Cmp(tt.want) != 0 {
+				t.Errorf("pricer.BumpGasPrice() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
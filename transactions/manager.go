@@ -0,0 +1,336 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"gopkg.in/cenkalti/backoff.v2"
+
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+var (
+	// ErrTransactionFailed is returned when a transaction is mined but reverted.
+	ErrTransactionFailed = errors.New("transaction reverted")
+
+	// ErrGasPriceCapped is returned when a gas price cannot be raised above the configured maximum.
+	ErrGasPriceCapped = errors.New("gas price is at the configured maximum")
+
+	// ErrTransactionDropped is returned when a transaction that can no longer be replaced is still not mined.
+	ErrTransactionDropped = errors.New("transaction not mined after the last replacement")
+)
+
+// Backend is the subset of ethclient.Client used to send and track transactions.
+type Backend interface {
+	bind.ContractTransactor
+	bind.DeployBackend
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// TransactFunc sends a transaction using the given options, e.g. a bound cToken method.
+type TransactFunc func(opts *bind.TransactOpts) (*types.Transaction, error)
+
+// TxManager is responsible for sending transactions and waiting for them to be mined.
+type TxManager interface {
+	Send(ctx context.Context, label string, transact TransactFunc) (*types.Receipt, error)
+}
+
+// Configuration contains TxManager configuration.
+type Configuration struct {
+	// Confirmations is the number of blocks, including the one the transaction is mined in, to wait for.
+	Confirmations uint64
+	// PollInterval is how often receipts and the head block are polled.
+	PollInterval time.Duration
+	// ReplaceAfter is how long a transaction may stay pending before it is replaced with a higher gas price.
+	ReplaceAfter time.Duration
+	// MaxReplacements is the maximum number of replacements sent for one nonce.
+	MaxReplacements int
+	// GasLimit is the gas limit of each transaction. Zero lets the transactor estimate it.
+	GasLimit uint64
+}
+
+// EthTxManager sends transactions through an Ethereum client.
+type EthTxManager struct {
+	logger              logging.Logger
+	backend             Backend
+	opts                *bind.TransactOpts
+	gasPricer           GasPricer
+	transactionsService models.TransactionsService
+	configuration       Configuration
+	nonceMutex          sync.Mutex
+	hasNonce            bool
+	nextNonce           uint64
+}
+
+// MockTxManager enables unit testing.
+type MockTxManager struct {
+	From   common.Address
+	Labels []string
+	mutex  sync.Mutex
+}
+
+// NewTxManager creates a new TxManager sending from opts.From and signing with opts.Signer.
+func NewTxManager(
+	logger logging.Logger,
+	backend Backend,
+	opts *bind.TransactOpts,
+	gasPricer GasPricer,
+	transactionsService models.TransactionsService,
+	configuration Configuration) TxManager {
+	if configuration.Confirmations == 0 {
+		configuration.Confirmations = 1
+	}
+	if configuration.PollInterval == 0 {
+		configuration.PollInterval = time.Second
+	}
+	return &EthTxManager{
+		logger:              logger,
+		backend:             backend,
+		opts:                opts,
+		gasPricer:           gasPricer,
+		transactionsService: transactionsService,
+		configuration:       configuration,
+	}
+}
+
+// Send sends a transaction and blocks until it has the configured number of confirmations.
+// A transaction that stays pending for longer than ReplaceAfter is replaced by one with the
+// same nonce and a higher gas price. The receipt of whichever transaction is mined is returned.
+// Once the gas price is at the maximum or MaxReplacements is reached, a transaction still pending
+// after another ReplaceAfter is given up with ErrTransactionDropped.
+func (manager *EthTxManager) Send(ctx context.Context, label string, transact TransactFunc) (*types.Receipt, error) {
+	tx, record, err := manager.sendNext(ctx, label, transact)
+	if err != nil {
+		return nil, err
+	}
+	sent := []*types.Transaction{tx}
+	records := []*models.TransactionRecord{record}
+	lastSentTime := time.Now()
+	replaceable := true
+	ticker := time.NewTicker(manager.configuration.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		receipt, err := manager.findReceipt(ctx, sent)
+		if err != nil {
+			manager.logger.Warn("Problem getting receipt", logging.Fields{"label": label, logging.ErrorField: err})
+			continue
+		}
+		if receipt != nil {
+			confirmed, err := manager.isConfirmed(ctx, receipt)
+			if err != nil {
+				manager.logger.Warn("Problem getting head block", logging.Fields{"label": label, logging.ErrorField: err})
+				continue
+			}
+			if !confirmed {
+				continue
+			}
+			manager.recordReceipt(ctx, records, receipt)
+			if receipt.Status == types.ReceiptStatusFailed {
+				return receipt, ErrTransactionFailed
+			}
+			return receipt, nil
+		}
+		if manager.configuration.ReplaceAfter == 0 ||
+			time.Since(lastSentTime) < manager.configuration.ReplaceAfter {
+			continue
+		}
+		if !replaceable {
+			manager.logger.Warn("Dropped transaction", logging.Fields{"label": label, "hash": tx.Hash().Hex(), "nonce": tx.Nonce()})
+			manager.dropNonce()
+			manager.recordDropped(ctx, records)
+			return nil, ErrTransactionDropped
+		}
+		if len(sent) > manager.configuration.MaxReplacements {
+			replaceable = false
+			lastSentTime = time.Now()
+			continue
+		}
+		gasPrice, err := manager.gasPricer.BumpGasPrice(ctx, tx.GasPrice())
+		if err != nil {
+			manager.logger.Warn("Not replacing transaction", logging.Fields{"label": label, "hash": tx.Hash().Hex(), logging.ErrorField: err})
+			replaceable = err != ErrGasPriceCapped
+			lastSentTime = time.Now()
+			continue
+		}
+		replacement, record, err := manager.send(ctx, label, transact, tx.Nonce(), gasPrice)
+		lastSentTime = time.Now()
+		if err != nil {
+			manager.logger.Warn("Problem replacing transaction", logging.Fields{"label": label, "hash": tx.Hash().Hex(), logging.ErrorField: err})
+			continue
+		}
+		manager.logger.Info("Replaced transaction", logging.Fields{"label": label, "hash": tx.Hash().Hex(), "replacement": replacement.Hash().Hex(), "gasPrice": gasPrice})
+		tx = replacement
+		sent = append(sent, replacement)
+		records = append(records, record)
+	}
+}
+
+// Send calls transact and returns a successful receipt for the transaction.
+func (manager *MockTxManager) Send(ctx context.Context, label string, transact TransactFunc) (*types.Receipt, error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+	opts := &bind.TransactOpts{
+		From:     manager.From,
+		Nonce:    big.NewInt(int64(len(manager.Labels))),
+		GasPrice: common.Big1,
+		Context:  ctx,
+	}
+	tx, err := transact(opts)
+	if err != nil {
+		return nil, err
+	}
+	manager.Labels = append(manager.Labels, label)
+	return &types.Receipt{Status: types.ReceiptStatusSuccessful, TxHash: tx.Hash()}, nil
+}
+
+// sendNext sends a transaction with the next nonce of the account.
+// The nonce is held for the duration of the send so transactions reach the node in nonce order.
+func (manager *EthTxManager) sendNext(ctx context.Context, label string, transact TransactFunc) (*types.Transaction, *models.TransactionRecord, error) {
+	manager.nonceMutex.Lock()
+	defer manager.nonceMutex.Unlock()
+	if !manager.hasNonce {
+		nonce, err := manager.backend.PendingNonceAt(ctx, manager.opts.From)
+		if err != nil {
+			return nil, nil, err
+		}
+		manager.nextNonce = nonce
+		manager.hasNonce = true
+	}
+	gasPrice, err := manager.gasPricer.GasPrice(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tx, record, err := manager.send(ctx, label, transact, manager.nextNonce, gasPrice)
+	if err != nil {
+		// the node may know better, e.g. after a nonce too low error, so ask it again next time.
+		manager.hasNonce = false
+		return nil, nil, err
+	}
+	manager.nextNonce++
+	return tx, record, nil
+}
+
+// dropNonce makes the next transaction ask the node for its nonce, as a dropped one may leave a gap.
+func (manager *EthTxManager) dropNonce() {
+	manager.nonceMutex.Lock()
+	defer manager.nonceMutex.Unlock()
+	manager.hasNonce = false
+}
+
+func (manager *EthTxManager) send(ctx context.Context, label string, transact TransactFunc, nonce uint64, gasPrice *big.Int) (*types.Transaction, *models.TransactionRecord, error) {
+	opts := &bind.TransactOpts{
+		From:     manager.opts.From,
+		Signer:   manager.opts.Signer,
+		Nonce:    new(big.Int).SetUint64(nonce),
+		GasPrice: gasPrice,
+		GasLimit: manager.configuration.GasLimit,
+		Context:  ctx,
+	}
+	tx, err := transact(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	manager.logger.Info("Sent transaction", logging.Fields{"label": label, "hash": tx.Hash().Hex(), "nonce": nonce, "gasPrice": gasPrice})
+	record := &models.TransactionRecord{
+		ShardKey: tx.Hash().Hex(),
+		Hash:     tx.Hash().Hex(),
+		Label:    label,
+		From:     manager.opts.From.Hex(),
+		Nonce:    nonce,
+		GasPrice: gasPrice.String(),
+		GasLimit: tx.Gas(),
+		Status:   models.TransactionStatusPending,
+		SentTime: time.Now(),
+	}
+	if tx.To() != nil {
+		record.To = tx.To().Hex()
+	}
+	operation := func() error {
+		err := manager.transactionsService.CreateTransaction(ctx, record)
+		if err != nil {
+			manager.logger.Warn("Problem inserting transaction", logging.Fields{"hash": record.Hash, logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err = backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		// the transaction is already on its way, so keep tracking it.
+		manager.logger.Error("Problem inserting transaction", logging.Fields{"hash": record.Hash, logging.ErrorField: err})
+	}
+	return tx, record, nil
+}
+
+func (manager *EthTxManager) findReceipt(ctx context.Context, sent []*types.Transaction) (*types.Receipt, error) {
+	for _, tx := range sent {
+		receipt, err := manager.backend.TransactionReceipt(ctx, tx.Hash())
+		if err != nil && err != ethereum.NotFound {
+			return nil, err
+		}
+		if receipt != nil {
+			return receipt, nil
+		}
+	}
+	return nil, nil
+}
+
+func (manager *EthTxManager) isConfirmed(ctx context.Context, receipt *types.Receipt) (bool, error) {
+	head, err := manager.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	return head.Number.Uint64()+1 >= receipt.BlockNumber.Uint64()+manager.configuration.Confirmations, nil
+}
+
+func (manager *EthTxManager) recordDropped(ctx context.Context, records []*models.TransactionRecord) {
+	for _, record := range records {
+		record.Status = models.TransactionStatusDropped
+		manager.updateTransaction(ctx, record)
+	}
+}
+
+func (manager *EthTxManager) recordReceipt(ctx context.Context, records []*models.TransactionRecord, receipt *types.Receipt) {
+	minedTime := time.Now()
+	for _, record := range records {
+		if record.Hash == receipt.TxHash.Hex() {
+			record.Status = models.TransactionStatusMined
+			if receipt.Status == types.ReceiptStatusFailed {
+				record.Status = models.TransactionStatusFailed
+			}
+			record.BlockNumber = receipt.BlockNumber.Uint64()
+			record.MinedTime = minedTime
+		} else {
+			record.Status = models.TransactionStatusReplaced
+			record.ReplacedBy = receipt.TxHash.Hex()
+		}
+		manager.updateTransaction(ctx, record)
+	}
+}
+
+func (manager *EthTxManager) updateTransaction(ctx context.Context, record *models.TransactionRecord) {
+	operation := func() error {
+		err := manager.transactionsService.UpdateTransaction(ctx, record)
+		if err != nil {
+			manager.logger.Warn("Problem updating transaction", logging.Fields{"hash": record.Hash, logging.ErrorField: err})
+			return err
+		}
+		return nil
+	}
+	err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+	if err != nil {
+		manager.logger.Error("Problem updating transaction", logging.Fields{"hash": record.Hash, logging.ErrorField: err})
+	}
+}
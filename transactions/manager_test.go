@@ -0,0 +1,297 @@
+package transactions
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/l3a0/carbon/logging"
+	"github.com/l3a0/carbon/models"
+)
+
+// mineBlocks commits a block on the simulated backend every interval until done is closed.
+func mineBlocks(backend *backends.SimulatedBackend, interval time.Duration, done chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			backend.Commit()
+		}
+	}
+}
+
+// transfer returns a TransactFunc sending value wei to the recipient.
+func transfer(backend *backends.SimulatedBackend, recipient common.Address, value *big.Int) TransactFunc {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx := types.NewTransaction(opts.Nonce.Uint64(), recipient, value, 21000, opts.GasPrice, nil)
+		signedTx, err := opts.Signer(types.HomesteadSigner{}, opts.From, tx)
+		if err != nil {
+			return nil, err
+		}
+		return signedTx, backend.SendTransaction(opts.Context, signedTx)
+	}
+}
+
+func TestEthTxManager_Send(t *testing.T) {
+	// Arrange
+	key, _ := crypto.GenerateKey()
+	opts := bind.NewKeyedTransactor(key)
+	recipient := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	type fields struct {
+		configuration Configuration
+	}
+	type args struct {
+		numberOfTransactions int
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "Should send transaction and wait for confirmations.",
+			fields: fields{
+				configuration: Configuration{
+					Confirmations: 3,
+					PollInterval:  10 * time.Millisecond,
+				},
+			},
+			args: args{
+				numberOfTransactions: 1,
+			},
+			wantErr: false,
+		},
+		{
+			name: "Should assign consecutive nonces to concurrent transactions.",
+			fields: fields{
+				configuration: Configuration{
+					Confirmations: 1,
+					PollInterval:  10 * time.Millisecond,
+				},
+			},
+			args: args{
+				numberOfTransactions: 5,
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+				opts.From: {Balance: big.NewInt(1000000000000000000)},
+			}, 8000000)
+			defer backend.Close()
+			done := make(chan struct{})
+			go mineBlocks(backend, 20*time.Millisecond, done)
+			defer close(done)
+			var buf bytes.Buffer
+			transactionsService := &models.MockTransactionsService{}
+			manager := NewTxManager(
+				logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat),
+				backend,
+				opts,
+				NewNodeGasPricer(backend, 100, 10, nil),
+				transactionsService,
+				tt.fields.configuration)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			// Act
+			receipts := make([]*types.Receipt, tt.args.numberOfTransactions)
+			errs := make([]error, tt.args.numberOfTransactions)
+			var wg sync.WaitGroup
+			for i := 0; i < tt.args.numberOfTransactions; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					receipts[i], errs[i] = manager.Send(ctx, "transfer", transfer(backend, recipient, big.NewInt(1)))
+				}(i)
+			}
+			wg.Wait()
+			// Assert
+			nonces := make(map[uint64]bool)
+			for i, receipt := range receipts {
+				if (errs[i] != nil) != tt.wantErr {
+					t.Fatalf("manager.Send() error = %v, wantErr %v", errs[i], tt.wantErr)
+				}
+				if receipt.Status != types.ReceiptStatusSuccessful {
+					t.Errorf("receipt.Status = %v, want %v", receipt.Status, types.ReceiptStatusSuccessful)
+				}
+				head, err := backend.HeaderByNumber(ctx, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				confirmations := head.Number.Uint64() + 1 - receipt.BlockNumber.Uint64()
+				if confirmations < tt.fields.configuration.Confirmations {
+					t.Errorf("confirmations = %v, want >= %v", confirmations, tt.fields.configuration.Confirmations)
+				}
+				record, ok := transactionsService.GetTransaction(receipt.TxHash.Hex())
+				if !ok {
+					t.Fatalf("transactionsService.GetTransaction(%v) not found", receipt.TxHash.Hex())
+				}
+				if record.Status != models.TransactionStatusMined {
+					t.Errorf("record.Status = %v, want %v", record.Status, models.TransactionStatusMined)
+				}
+				if record.Label != "transfer" {
+					t.Errorf("record.Label = %v, want %v", record.Label, "transfer")
+				}
+				if record.BlockNumber != receipt.BlockNumber.Uint64() {
+					t.Errorf("record.BlockNumber = %v, want %v", record.BlockNumber, receipt.BlockNumber.Uint64())
+				}
+				nonces[record.Nonce] = true
+			}
+			for nonce := uint64(0); nonce < uint64(tt.args.numberOfTransactions); nonce++ {
+				if !nonces[nonce] {
+					t.Errorf("nonces[%v] = false, want true", nonce)
+				}
+			}
+		})
+	}
+}
+
+// stuckTransfer returns a TransactFunc like transfer whose first stuckCalls transactions never reach the backend.
+func stuckTransfer(backend *backends.SimulatedBackend, recipient common.Address, value *big.Int, stuckCalls int) TransactFunc {
+	calls := 0
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		tx := types.NewTransaction(opts.Nonce.Uint64(), recipient, value, 21000, opts.GasPrice, nil)
+		signedTx, err := opts.Signer(types.HomesteadSigner{}, opts.From, tx)
+		if err != nil {
+			return nil, err
+		}
+		calls++
+		if calls <= stuckCalls {
+			return signedTx, nil
+		}
+		return signedTx, backend.SendTransaction(opts.Context, signedTx)
+	}
+}
+
+func TestEthTxManager_Send_Replace(t *testing.T) {
+	// Arrange
+	key, _ := crypto.GenerateKey()
+	opts := bind.NewKeyedTransactor(key)
+	recipient := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	type fields struct {
+		maxGasPrice     *big.Int
+		maxReplacements int
+	}
+	type args struct {
+		stuckCalls int
+	}
+	tests := []struct {
+		name         string
+		fields       fields
+		args         args
+		wantErr      error
+		wantStatuses []string
+	}{
+		{
+			name: "Should replace a pending transaction with a higher gas price.",
+			fields: fields{
+				maxReplacements: 3,
+			},
+			args: args{
+				stuckCalls: 1,
+			},
+			wantStatuses: []string{models.TransactionStatusReplaced, models.TransactionStatusMined},
+		},
+		{
+			name: "Should drop a transaction not mined at the maximum gas price.",
+			fields: fields{
+				maxGasPrice:     big.NewInt(1),
+				maxReplacements: 3,
+			},
+			args: args{
+				stuckCalls: 100,
+			},
+			wantErr:      ErrTransactionDropped,
+			wantStatuses: []string{models.TransactionStatusDropped},
+		},
+		{
+			name: "Should drop a transaction not mined after the maximum replacements.",
+			fields: fields{
+				maxReplacements: 1,
+			},
+			args: args{
+				stuckCalls: 100,
+			},
+			wantErr:      ErrTransactionDropped,
+			wantStatuses: []string{models.TransactionStatusDropped, models.TransactionStatusDropped},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := backends.NewSimulatedBackend(core.GenesisAlloc{
+				opts.From: {Balance: big.NewInt(1000000000000000000)},
+			}, 8000000)
+			defer backend.Close()
+			done := make(chan struct{})
+			go mineBlocks(backend, 20*time.Millisecond, done)
+			defer close(done)
+			var buf bytes.Buffer
+			transactionsService := &models.MockTransactionsService{}
+			manager := NewTxManager(
+				logging.NewLogger(&buf, logging.DebugLevel, logging.TextFormat),
+				backend,
+				opts,
+				NewNodeGasPricer(backend, 100, 10, tt.fields.maxGasPrice),
+				transactionsService,
+				Configuration{
+					Confirmations:   1,
+					PollInterval:    10 * time.Millisecond,
+					ReplaceAfter:    50 * time.Millisecond,
+					MaxReplacements: tt.fields.maxReplacements,
+				})
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			// Act
+			receipt, err := manager.Send(ctx, "transfer", stuckTransfer(backend, recipient, big.NewInt(1), tt.args.stuckCalls))
+			// Assert
+			if err != tt.wantErr {
+				t.Fatalf("manager.Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && receipt.Status != types.ReceiptStatusSuccessful {
+				t.Errorf("receipt.Status = %v, want %v", receipt.Status, types.ReceiptStatusSuccessful)
+			}
+			records := []models.TransactionRecord{}
+			for _, record := range transactionsService.Records {
+				records = append(records, *record)
+			}
+			sort.Slice(records, func(i, j int) bool { return records[i].SentTime.Before(records[j].SentTime) })
+			if len(records) != len(tt.wantStatuses) {
+				t.Fatalf("len(records) = %v, want %v", len(records), len(tt.wantStatuses))
+			}
+			for i, record := range records {
+				if record.Status != tt.wantStatuses[i] {
+					t.Errorf("records[%v].Status = %v, want %v", i, record.Status, tt.wantStatuses[i])
+				}
+				if record.Nonce != 0 {
+					t.Errorf("records[%v].Nonce = %v, want %v", i, record.Nonce, 0)
+				}
+				if i > 0 && !isHigher(record.GasPrice, records[i-1].GasPrice) {
+					t.Errorf("records[%v].GasPrice = %v, want > %v", i, record.GasPrice, records[i-1].GasPrice)
+				}
+			}
+		})
+	}
+}
+
+func isHigher(gasPrice string, previous string) bool {
+	value, _ := new(big.Int).SetString(gasPrice, 10)
+	previousValue, _ := new(big.Int).SetString(previous, 10)
+	return value.Cmp(previousValue) > 0
+}